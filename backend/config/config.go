@@ -10,12 +10,17 @@ import (
 var globalConfig = new(GlobalConfig)
 
 type GlobalConfig struct {
-	*SvrConfig   `mapstructure:"svr_config"`
-	*LogConfig   `mapstructure:"log" json:"log" yaml:"log"`
-	*Neo4jConfig `mapstructure:"neo4j"`
-	*DbConfig    `mapstructure:"mysql"`
-	*JwtConfig   `mapstructure:"jwt"`
-	*MinioConfig `mapstructure:"minio"`
+	*SvrConfig        `mapstructure:"svr_config"`
+	*LogConfig        `mapstructure:"log" json:"log" yaml:"log"`
+	*Neo4jConfig      `mapstructure:"neo4j"`
+	*DbConfig         `mapstructure:"mysql"`
+	*JwtConfig        `mapstructure:"jwt"`
+	*MinioConfig      `mapstructure:"minio"`
+	*ExtractorConfig  `mapstructure:"extractor"`
+	*DifficultyConfig `mapstructure:"difficulty"`
+	*BKTConfig        `mapstructure:"bkt"`
+	*VideoConfig      `mapstructure:"video"`
+	*RecommendConfig  `mapstructure:"recommend"`
 }
 
 type SvrConfig struct {
@@ -53,8 +58,10 @@ type Neo4jConfig struct {
 	Password string `json:"password"`
 }
 type JwtConfig struct {
-	Issuer  string `json:"issuer"`
-	JwtSalt string `json:"jwt_salt"`
+	Issuer          string `json:"issuer"`
+	JwtSalt         string `json:"jwt_salt"`
+	AccessTokenTTL  int    `mapstructure:"access_token_ttl"`  // 访问令牌有效期（分钟）
+	RefreshTokenTTL int    `mapstructure:"refresh_token_ttl"` // 刷新令牌有效期（小时），每次刷新后重新计算
 }
 type MinioConfig struct {
 	Host            string `mapstructure:"host"`
@@ -69,6 +76,46 @@ type MinioConfig struct {
 	FilePath        string `mapstructure:"file_path"`
 }
 
+type ExtractorConfig struct {
+	Endpoint string `mapstructure:"endpoint"` // 外部 NLP/LLM 抽取服务地址，为空时不启用
+	ApiKey   string `mapstructure:"api_key"`
+	Timeout  int    `mapstructure:"timeout"` // 请求超时时间(s)
+}
+
+// DifficultyConfig 知识点难度评分中各因素的权重
+type DifficultyConfig struct {
+	ChainWeight    float64 `mapstructure:"chain_weight"`    // 最长前置链长度
+	PrereqWeight   float64 `mapstructure:"prereq_weight"`   // 传递前置知识点数量
+	FanInWeight    float64 `mapstructure:"fan_in_weight"`   // 直接前置知识点数量
+	ExerciseWeight float64 `mapstructure:"exercise_weight"` // 习题平均难度(easy=1, medium=2, hard=3)
+}
+
+// BKTConfig 贝叶斯知识追踪的默认参数，单个知识点可以在 bkt_params 表中覆盖
+type BKTConfig struct {
+	PInit            float64 `mapstructure:"p_init"`            // 初始掌握概率
+	PTransit         float64 `mapstructure:"p_transit"`         // 每次练习后从未掌握变为掌握的概率
+	PSlip            float64 `mapstructure:"p_slip"`            // 已掌握但答错的概率
+	PGuess           float64 `mapstructure:"p_guess"`           // 未掌握但猜对的概率
+	PropagationDecay float64 `mapstructure:"propagation_decay"` // 答对时向前置知识点传播证据的衰减系数
+	PropagationDepth int     `mapstructure:"propagation_depth"` // 向前置知识点传播的最大层数
+}
+
+// VideoConfig 视频观看进度的判定参数
+type VideoConfig struct {
+	CompletionThreshold float64 `mapstructure:"completion_threshold"` // 观看覆盖率达到该值时视为看完
+	MaxPlaybackRate     float64 `mapstructure:"max_playback_rate"`    // 两次心跳之间播放位置前进的速度上限，超过视为拖动进度条
+	HeartbeatSlack      float64 `mapstructure:"heartbeat_slack"`      // 允许的网络延迟（秒）
+	MaxHeartbeatGap     float64 `mapstructure:"max_heartbeat_gap"`    // 两次心跳间隔超过该值（秒）时视为重新打开视频，不计入观看区间
+}
+
+// RecommendConfig 基于协同过滤的资源推荐
+type RecommendConfig struct {
+	RefreshInterval int     `mapstructure:"refresh_interval"` // 重新计算资源相似度的间隔(min)，0 表示只在启动时计算一次
+	Neighbors       int     `mapstructure:"neighbors"`        // 每个资源保留的最相似资源数
+	MinCommonUsers  int     `mapstructure:"min_common_users"` // 两个资源至少有多少名共同学习者才计算相似度
+	Shrinkage       float64 `mapstructure:"shrinkage"`        // 相似度按共同学习者数收缩，n / (n + shrinkage)
+}
+
 func Init() (err error) {
 	// 自动推导项目根目录
 	configFile := GetRootDir() + "/config/config.yaml"
@@ -0,0 +1,44 @@
+-- +goose Up
+
+-- 创建知识抽取暂存批次表
+CREATE TABLE IF NOT EXISTS `staging_batches` (
+    `id` BIGINT NOT NULL AUTO_INCREMENT COMMENT '批次ID',
+    `file_name` VARCHAR(255) NOT NULL COMMENT '上传的教材文件名',
+    `extractor` VARCHAR(32) NOT NULL COMMENT '使用的抽取器',
+    `created_by` VARCHAR(32) NOT NULL COMMENT '创建人ID',
+    `status` ENUM('pending', 'committing', 'committed', 'discarded') NOT NULL DEFAULT 'pending' COMMENT '批次状态',
+    `created_at` TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP COMMENT '创建时间',
+    `updated_at` TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP COMMENT '更新时间',
+    PRIMARY KEY (`id`),
+    INDEX `idx_created_by` (`created_by`) COMMENT '创建人索引'
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='知识抽取暂存批次表';
+
+-- 创建知识抽取暂存条目表
+CREATE TABLE IF NOT EXISTS `staging_items` (
+    `id` BIGINT NOT NULL AUTO_INCREMENT COMMENT '条目ID',
+    `batch_id` BIGINT NOT NULL COMMENT '所属批次ID',
+    `item_type` ENUM('node', 'relation') NOT NULL COMMENT '条目类型',
+    `name` VARCHAR(100) COMMENT '节点名称',
+    `node_type` VARCHAR(16) COMMENT '节点类型',
+    `description` TEXT COMMENT '节点描述',
+    `relation_type` VARCHAR(16) COMMENT '关系类型',
+    `source_type` VARCHAR(16) COMMENT '源节点类型',
+    `source_name` VARCHAR(100) COMMENT '源节点名称',
+    `target_type` VARCHAR(16) COMMENT '目标节点类型',
+    `target_name` VARCHAR(100) COMMENT '目标节点名称',
+    `confidence` DOUBLE NOT NULL DEFAULT 0 COMMENT '置信度(0-1)',
+    `status` ENUM('pending', 'accepted', 'rejected', 'committed') NOT NULL DEFAULT 'pending' COMMENT '审核状态',
+    `created_at` TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP COMMENT '创建时间',
+    `updated_at` TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP COMMENT '更新时间',
+    PRIMARY KEY (`id`),
+    INDEX `idx_batch_id` (`batch_id`) COMMENT '批次ID索引'
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='知识抽取暂存条目表';
+
+
+-- +goose Down
+
+-- 删除暂存条目表
+DROP TABLE IF EXISTS `staging_items`;
+
+-- 删除暂存批次表
+DROP TABLE IF EXISTS `staging_batches`;
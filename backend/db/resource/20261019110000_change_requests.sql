@@ -0,0 +1,34 @@
+-- +goose Up
+
+-- 创建图谱修改申请表
+CREATE TABLE IF NOT EXISTS `change_requests` (
+    `id` BIGINT NOT NULL AUTO_INCREMENT COMMENT '申请ID',
+    `submitter` VARCHAR(32) NOT NULL COMMENT '提交人ID',
+    `comment` TEXT COMMENT '修改说明',
+    `operations` MEDIUMTEXT NOT NULL COMMENT 'JSON编码的修改操作列表',
+    `status` ENUM('pending', 'approved', 'rejected') NOT NULL DEFAULT 'pending' COMMENT '审核状态',
+    `reviewer` VARCHAR(32) COMMENT '审核人ID',
+    `feedback` TEXT COMMENT '审核意见',
+    `reviewed_at` TIMESTAMP NULL COMMENT '审核时间',
+    `created_at` TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP COMMENT '创建时间',
+    `updated_at` TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP COMMENT '更新时间',
+    PRIMARY KEY (`id`),
+    INDEX `idx_submitter` (`submitter`) COMMENT '提交人索引',
+    INDEX `idx_status` (`status`) COMMENT '状态索引'
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='图谱修改申请表';
+
+-- 创建课程负责人表
+CREATE TABLE IF NOT EXISTS `course_owners` (
+    `user_id` VARCHAR(32) NOT NULL COMMENT '负责人ID',
+    `created_at` TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP COMMENT '创建时间',
+    PRIMARY KEY (`user_id`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='课程负责人表';
+
+
+-- +goose Down
+
+-- 删除课程负责人表
+DROP TABLE IF EXISTS `course_owners`;
+
+-- 删除图谱修改申请表
+DROP TABLE IF EXISTS `change_requests`;
@@ -0,0 +1,17 @@
+-- +goose Up
+
+-- 创建学生知识点掌握度表
+CREATE TABLE IF NOT EXISTS `learner_masteries` (
+    `user_id` VARCHAR(32) NOT NULL COMMENT '学生ID',
+    `knowledge_point_id` BIGINT NOT NULL COMMENT '知识点ID',
+    `mastery` DOUBLE NOT NULL DEFAULT 0 COMMENT '掌握度(0-1)',
+    `updated_at` TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP COMMENT '更新时间',
+    PRIMARY KEY (`user_id`, `knowledge_point_id`),
+    INDEX `idx_knowledge_point_id` (`knowledge_point_id`) COMMENT '知识点索引'
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='学生知识点掌握度表';
+
+
+-- +goose Down
+
+-- 删除学生知识点掌握度表
+DROP TABLE IF EXISTS `learner_masteries`;
@@ -0,0 +1,20 @@
+-- +goose Up
+
+-- 创建学习资源反馈表
+CREATE TABLE IF NOT EXISTS `resource_feedbacks` (
+    `user_id` VARCHAR(32) NOT NULL COMMENT '学生ID',
+    `resource_type` ENUM('video', 'exercise', 'courseware') NOT NULL COMMENT '资源类型',
+    `resource_id` BIGINT NOT NULL COMMENT '资源ID',
+    `rating` TINYINT COMMENT '评分(1-5)',
+    `completed` BOOLEAN NOT NULL DEFAULT FALSE COMMENT '是否已完成',
+    `created_at` TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP COMMENT '创建时间',
+    `updated_at` TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP COMMENT '更新时间',
+    PRIMARY KEY (`user_id`, `resource_type`, `resource_id`),
+    INDEX `idx_resource` (`resource_type`, `resource_id`) COMMENT '资源索引'
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='学习资源反馈表';
+
+
+-- +goose Down
+
+-- 删除学习资源反馈表
+DROP TABLE IF EXISTS `resource_feedbacks`;
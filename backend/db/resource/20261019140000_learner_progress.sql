@@ -0,0 +1,19 @@
+-- +goose Up
+
+-- 创建学生知识点学习进度表
+CREATE TABLE IF NOT EXISTS `learner_progresses` (
+    `user_id` VARCHAR(32) NOT NULL COMMENT '学生ID',
+    `knowledge_point_id` BIGINT NOT NULL COMMENT '知识点ID',
+    `status` ENUM('not_started', 'in_progress', 'completed') NOT NULL DEFAULT 'not_started' COMMENT '学习状态',
+    `started_at` TIMESTAMP NULL COMMENT '开始学习时间',
+    `completed_at` TIMESTAMP NULL COMMENT '完成时间',
+    `last_activity_at` TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP COMMENT '最近学习时间',
+    PRIMARY KEY (`user_id`, `knowledge_point_id`),
+    INDEX `idx_knowledge_point_id` (`knowledge_point_id`) COMMENT '知识点索引'
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='学生知识点学习进度表';
+
+
+-- +goose Down
+
+-- 删除学生知识点学习进度表
+DROP TABLE IF EXISTS `learner_progresses`;
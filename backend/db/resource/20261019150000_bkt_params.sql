@@ -0,0 +1,18 @@
+-- +goose Up
+
+-- 创建知识点贝叶斯知识追踪参数表
+CREATE TABLE IF NOT EXISTS `bkt_params` (
+    `knowledge_point_id` BIGINT NOT NULL COMMENT '知识点ID',
+    `p_init` DOUBLE NOT NULL COMMENT '初始掌握概率',
+    `p_transit` DOUBLE NOT NULL COMMENT '学习转移概率',
+    `p_slip` DOUBLE NOT NULL COMMENT '失误概率',
+    `p_guess` DOUBLE NOT NULL COMMENT '猜测概率',
+    `updated_at` TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP COMMENT '更新时间',
+    PRIMARY KEY (`knowledge_point_id`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='知识点贝叶斯知识追踪参数表';
+
+
+-- +goose Down
+
+-- 删除知识点贝叶斯知识追踪参数表
+DROP TABLE IF EXISTS `bkt_params`;
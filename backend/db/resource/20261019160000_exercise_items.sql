@@ -0,0 +1,45 @@
+-- +goose Up
+
+-- 创建结构化习题表
+CREATE TABLE IF NOT EXISTS `exercise_items` (
+    `id` BIGINT NOT NULL AUTO_INCREMENT COMMENT '习题ID',
+    `type` ENUM('single_choice', 'multiple_choice', 'true_false', 'fill_blank', 'numeric') NOT NULL COMMENT '题型',
+    `stem` TEXT NOT NULL COMMENT '题干',
+    `options` TEXT COMMENT '选项(JSON)',
+    `answer` TEXT NOT NULL COMMENT '答案(JSON)',
+    `explanation` TEXT COMMENT '解析',
+    `difficulty` ENUM('easy', 'medium', 'hard') NOT NULL DEFAULT 'medium' COMMENT '难度',
+    `created_by` VARCHAR(32) NOT NULL COMMENT '创建人',
+    `created_at` TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP COMMENT '创建时间',
+    `updated_at` TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP COMMENT '更新时间',
+    PRIMARY KEY (`id`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='结构化习题表';
+
+-- 创建习题与知识点关联表
+CREATE TABLE IF NOT EXISTS `exercise_item_points` (
+    `item_id` BIGINT NOT NULL COMMENT '习题ID',
+    `knowledge_point_id` BIGINT NOT NULL COMMENT '知识点ID',
+    PRIMARY KEY (`item_id`, `knowledge_point_id`),
+    INDEX `idx_knowledge_point_id` (`knowledge_point_id`) COMMENT '知识点索引'
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='习题与知识点关联表';
+
+-- 创建作答记录表
+CREATE TABLE IF NOT EXISTS `exercise_attempts` (
+    `id` BIGINT NOT NULL AUTO_INCREMENT COMMENT '作答ID',
+    `user_id` VARCHAR(32) NOT NULL COMMENT '学生ID',
+    `item_id` BIGINT NOT NULL COMMENT '习题ID',
+    `response` TEXT NOT NULL COMMENT '作答内容(JSON)',
+    `correct` BOOLEAN NOT NULL COMMENT '是否正确',
+    `score` DOUBLE NOT NULL COMMENT '得分(0-1)',
+    `scored` BOOLEAN NOT NULL DEFAULT FALSE COMMENT '是否计入掌握度和学习进度',
+    `created_at` TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP COMMENT '作答时间',
+    PRIMARY KEY (`id`),
+    INDEX `idx_user_item` (`user_id`, `item_id`) COMMENT '学生习题索引'
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='作答记录表';
+
+
+-- +goose Down
+
+DROP TABLE IF EXISTS `exercise_attempts`;
+DROP TABLE IF EXISTS `exercise_item_points`;
+DROP TABLE IF EXISTS `exercise_items`;
@@ -0,0 +1,50 @@
+-- +goose Up
+
+-- 习题增加布鲁姆认知层次和版本号
+ALTER TABLE `exercise_items`
+    ADD COLUMN `bloom_level` ENUM('remember', 'understand', 'apply', 'analyze', 'evaluate', 'create') NOT NULL DEFAULT 'understand' COMMENT '布鲁姆认知层次' AFTER `difficulty`,
+    ADD COLUMN `version` INT NOT NULL DEFAULT 1 COMMENT '当前版本' AFTER `bloom_level`,
+    ADD INDEX `idx_created_by` (`created_by`) COMMENT '作者索引';
+
+-- 创建习题版本快照表
+CREATE TABLE IF NOT EXISTS `exercise_item_versions` (
+    `item_id` BIGINT NOT NULL COMMENT '习题ID',
+    `version` INT NOT NULL COMMENT '版本',
+    `type` ENUM('single_choice', 'multiple_choice', 'true_false', 'fill_blank', 'numeric') NOT NULL COMMENT '题型',
+    `stem` TEXT NOT NULL COMMENT '题干',
+    `options` TEXT COMMENT '选项(JSON)',
+    `answer` TEXT NOT NULL COMMENT '答案(JSON)',
+    `explanation` TEXT COMMENT '解析',
+    `difficulty` ENUM('easy', 'medium', 'hard') NOT NULL COMMENT '难度',
+    `bloom_level` ENUM('remember', 'understand', 'apply', 'analyze', 'evaluate', 'create') NOT NULL COMMENT '布鲁姆认知层次',
+    `edited_by` VARCHAR(32) NOT NULL COMMENT '修改人',
+    `created_at` TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP COMMENT '创建时间',
+    PRIMARY KEY (`item_id`, `version`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='习题版本快照表';
+
+-- 已有习题补一份初始版本快照
+INSERT INTO `exercise_item_versions` (`item_id`, `version`, `type`, `stem`, `options`, `answer`, `explanation`, `difficulty`, `bloom_level`, `edited_by`, `created_at`)
+SELECT `id`, 1, `type`, `stem`, `options`, `answer`, `explanation`, `difficulty`, `bloom_level`, `created_by`, `created_at` FROM `exercise_items`;
+
+-- 创建习题标签表
+CREATE TABLE IF NOT EXISTS `exercise_item_tags` (
+    `item_id` BIGINT NOT NULL COMMENT '习题ID',
+    `tag` VARCHAR(32) NOT NULL COMMENT '标签',
+    PRIMARY KEY (`item_id`, `tag`),
+    INDEX `idx_tag` (`tag`) COMMENT '标签索引'
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='习题标签表';
+
+-- 作答记录增加习题版本
+ALTER TABLE `exercise_attempts`
+    ADD COLUMN `item_version` INT NOT NULL DEFAULT 1 COMMENT '作答时的习题版本' AFTER `item_id`;
+
+
+-- +goose Down
+
+ALTER TABLE `exercise_attempts` DROP COLUMN `item_version`;
+DROP TABLE IF EXISTS `exercise_item_tags`;
+DROP TABLE IF EXISTS `exercise_item_versions`;
+ALTER TABLE `exercise_items`
+    DROP INDEX `idx_created_by`,
+    DROP COLUMN `version`,
+    DROP COLUMN `bloom_level`;
@@ -0,0 +1,38 @@
+-- +goose Up
+
+-- 创建测验表
+CREATE TABLE IF NOT EXISTS `quizzes` (
+    `id` BIGINT NOT NULL AUTO_INCREMENT COMMENT '测验ID',
+    `created_by` VARCHAR(32) NOT NULL COMMENT '创建人',
+    `scope_type` ENUM('chapter', 'section') NOT NULL COMMENT '范围类型',
+    `scope_id` BIGINT NOT NULL COMMENT '章节或小节ID',
+    `seed` BIGINT NOT NULL COMMENT '随机种子',
+    `blueprint` TEXT NOT NULL COMMENT '组卷蓝图(JSON)',
+    `questions` MEDIUMTEXT NOT NULL COMMENT '题目及版本(JSON)',
+    `time_limit` INT NOT NULL DEFAULT 0 COMMENT '限时(分钟)',
+    `estimated_minutes` DOUBLE NOT NULL COMMENT '预计用时(分钟)',
+    `created_at` TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP COMMENT '创建时间',
+    PRIMARY KEY (`id`),
+    INDEX `idx_created_by` (`created_by`) COMMENT '创建人索引'
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='测验表';
+
+-- 创建测验提交表
+CREATE TABLE IF NOT EXISTS `quiz_submissions` (
+    `id` BIGINT NOT NULL AUTO_INCREMENT COMMENT '提交ID',
+    `quiz_id` BIGINT NOT NULL COMMENT '测验ID',
+    `user_id` VARCHAR(32) NOT NULL COMMENT '学生ID',
+    `score` DOUBLE NOT NULL COMMENT '得分',
+    `max_score` DOUBLE NOT NULL COMMENT '满分',
+    `results` MEDIUMTEXT NOT NULL COMMENT '逐题结果(JSON)',
+    `scored` BOOLEAN NOT NULL DEFAULT FALSE COMMENT '是否计入掌握度和学习进度',
+    `created_at` TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP COMMENT '提交时间',
+    PRIMARY KEY (`id`),
+    INDEX `idx_quiz_id` (`quiz_id`) COMMENT '测验索引',
+    INDEX `idx_user_id` (`user_id`) COMMENT '学生索引'
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='测验提交表';
+
+
+-- +goose Down
+
+DROP TABLE IF EXISTS `quiz_submissions`;
+DROP TABLE IF EXISTS `quizzes`;
@@ -0,0 +1,27 @@
+-- +goose Up
+
+-- 创建视频观看记录表
+CREATE TABLE IF NOT EXISTS `video_watches` (
+    `user_id` VARCHAR(32) NOT NULL COMMENT '学生ID',
+    `video_id` BIGINT NOT NULL COMMENT '视频ID',
+    `duration` DOUBLE NOT NULL COMMENT '视频时长(秒)',
+    `intervals` TEXT NOT NULL COMMENT '已观看区间(JSON)',
+    `watched_seconds` DOUBLE NOT NULL DEFAULT 0 COMMENT '已观看时长(秒)',
+    `last_position` DOUBLE NOT NULL DEFAULT 0 COMMENT '最近播放位置(秒)',
+    `completed` BOOLEAN NOT NULL DEFAULT FALSE COMMENT '是否看完',
+    `completed_at` TIMESTAMP NULL COMMENT '看完时间',
+    `updated_at` TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP COMMENT '最近心跳时间',
+    PRIMARY KEY (`user_id`, `video_id`),
+    INDEX `idx_video_id` (`video_id`) COMMENT '视频索引'
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='视频观看记录表';
+
+-- 视频时长，上传时读取，观看进度按该时长计算
+ALTER TABLE `videos` ADD COLUMN `duration` DOUBLE NOT NULL DEFAULT 0 COMMENT '视频时长(秒)，0 表示未知' AFTER `knowledge_point_id`;
+
+
+-- +goose Down
+
+-- 删除视频观看记录表
+DROP TABLE IF EXISTS `video_watches`;
+
+ALTER TABLE `videos` DROP COLUMN `duration`;
@@ -0,0 +1,25 @@
+-- +goose Up
+
+-- 创建作业表
+CREATE TABLE IF NOT EXISTS `assignments` (
+    `id` BIGINT NOT NULL AUTO_INCREMENT COMMENT '作业ID',
+    `group_id` BIGINT NOT NULL COMMENT '学生组ID',
+    `title` VARCHAR(100) NOT NULL COMMENT '标题',
+    `description` TEXT COMMENT '说明',
+    `kind` ENUM('points', 'path', 'quiz') NOT NULL COMMENT '作业类型',
+    `target_id` BIGINT NOT NULL DEFAULT 0 COMMENT '学习路径目标知识点或测验ID',
+    `point_ids` TEXT NOT NULL COMMENT '知识点列表(JSON)',
+    `open_at` TIMESTAMP NOT NULL COMMENT '开放时间',
+    `due_at` TIMESTAMP NOT NULL COMMENT '截止时间',
+    `created_by` VARCHAR(32) NOT NULL COMMENT '布置人',
+    `created_at` TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP COMMENT '创建时间',
+    `updated_at` TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP COMMENT '更新时间',
+    PRIMARY KEY (`id`),
+    INDEX `idx_group_id` (`group_id`) COMMENT '学生组索引'
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='作业表';
+
+
+-- +goose Down
+
+-- 删除作业表
+DROP TABLE IF EXISTS `assignments`;
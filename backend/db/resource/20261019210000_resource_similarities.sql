@@ -0,0 +1,19 @@
+-- +goose Up
+
+-- 创建资源相似度表
+CREATE TABLE IF NOT EXISTS `resource_similarities` (
+    `resource_type` ENUM('video', 'exercise', 'courseware') NOT NULL COMMENT '资源类型',
+    `resource_id` BIGINT NOT NULL COMMENT '资源ID',
+    `similar_type` ENUM('video', 'exercise', 'courseware') NOT NULL COMMENT '相似资源类型',
+    `similar_id` BIGINT NOT NULL COMMENT '相似资源ID',
+    `score` DOUBLE NOT NULL COMMENT '相似度',
+    `common_users` INT NOT NULL COMMENT '共同学习者数',
+    `computed_at` TIMESTAMP NOT NULL COMMENT '计算时间',
+    PRIMARY KEY (`resource_type`, `resource_id`, `similar_type`, `similar_id`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='资源相似度表';
+
+
+-- +goose Down
+
+-- 删除资源相似度表
+DROP TABLE IF EXISTS `resource_similarities`;
@@ -74,7 +74,7 @@ CREATE TABLE `problem`.`t_submit_history`(
 CREATE TABLE `user`.`t_account`(
     `user_id` VARCHAR(32) NOT NULL PRIMARY KEY COMMENT '账号',
     `nickname` VARCHAR(128) NOT NULL DEFAULT '' unique COMMENT 'encode后昵称',
-    `password` VARCHAR(64) NOT NULL COMMENT '哈希后的密码',
+    `password` VARCHAR(64) NOT NULL COMMENT '哈希后的密码，v1$ 开头为 bcrypt，32 位十六进制为旧版 MD5',
     `user_type` VARCHAR(32) NOT NULL COMMENT '账号类型'
 );
 
@@ -92,3 +92,30 @@ CREATE TABLE `user`.`t_group_user`(
     PRIMARY KEY(`group_id`,`user_id`),
     INDEX `idx_user`(`user_id`)
 );
+
+-- 角色权限，管理员可在线调整；表为空时使用程序内置的默认映射
+CREATE TABLE `user`.`t_role_permission`(
+    `user_type` VARCHAR(32) NOT NULL COMMENT '账号类型',
+    `permission` VARCHAR(64) NOT NULL COMMENT '权限，如 graph.write',
+    PRIMARY KEY(`user_type`,`permission`)
+);
+
+INSERT INTO `user`.`t_role_permission` (`user_type`, `permission`) VALUES
+    ('admin', 'graph.read'), ('admin', 'graph.write'), ('admin', 'resource.upload'),
+    ('admin', 'user.manage'), ('admin', 'group.manage'), ('admin', 'system.manage'),
+    ('teacher', 'graph.read'), ('teacher', 'graph.write'), ('teacher', 'resource.upload'),
+    ('teacher', 'group.manage'),
+    ('student', 'graph.read');
+
+-- 登录会话，访问令牌携带 session_id，会话撤销后其访问令牌和刷新令牌一并失效
+CREATE TABLE `user`.`t_session`(
+    `session_id` CHAR(32) NOT NULL PRIMARY KEY,
+    `user_id` VARCHAR(32) NOT NULL,
+    `refresh_hash` CHAR(64) NOT NULL COMMENT '当前刷新令牌的 SHA-256，每次刷新轮换',
+    `prev_refresh_hash` CHAR(64) NULL DEFAULT NULL COMMENT '上一个刷新令牌的 SHA-256，轮换后短时间内仍可使用',
+    `rotated_at` DATETIME NULL DEFAULT NULL COMMENT '最近一次轮换时间',
+    `expires_at` DATETIME NOT NULL,
+    `revoked_at` DATETIME NULL DEFAULT NULL,
+    `created_at` DATETIME NOT NULL DEFAULT now(),
+    INDEX `idx_user` (`user_id`)
+);
@@ -0,0 +1,172 @@
+package analysis
+
+import "math"
+
+// NodeCentrality 节点的各项中心度指标
+type NodeCentrality struct {
+	graphNode
+	InDegree    int     `json:"in_degree"`
+	OutDegree   int     `json:"out_degree"`
+	Degree      float64 `json:"degree"`
+	PageRank    float64 `json:"pagerank"`
+	Betweenness float64 `json:"betweenness"`
+	Closeness   float64 `json:"closeness"`
+}
+
+const (
+	pageRankDamping    = 0.85
+	pageRankIterations = 100
+	pageRankTolerance  = 1e-8
+)
+
+// computeCentrality 计算全部节点的中心度。度中心度和 PageRank 按关系方向计算，
+// 介数和接近中心度把关系视为无向边，因为 相关 等关系本身没有方向含义
+func computeCentrality(g *courseGraph) []NodeCentrality {
+	n := len(g.nodes)
+	results := make([]NodeCentrality, n)
+	pageRank := computePageRank(g)
+	betweenness := computeBetweenness(g)
+	closeness := computeCloseness(g)
+	for i, node := range g.nodes {
+		results[i] = NodeCentrality{
+			graphNode:   node,
+			InDegree:    len(g.in[i]),
+			OutDegree:   len(g.out[i]),
+			PageRank:    pageRank[i],
+			Betweenness: betweenness[i],
+			Closeness:   closeness[i],
+		}
+		if n > 1 {
+			results[i].Degree = float64(len(g.undir[i])) / float64(n-1)
+		}
+	}
+	return results
+}
+
+// computePageRank 幂迭代计算 PageRank，没有出边的节点把分数平均分给所有节点
+func computePageRank(g *courseGraph) []float64 {
+	n := len(g.nodes)
+	rank := make([]float64, n)
+	if n == 0 {
+		return rank
+	}
+	for i := range rank {
+		rank[i] = 1 / float64(n)
+	}
+	next := make([]float64, n)
+	for iter := 0; iter < pageRankIterations; iter++ {
+		var dangling float64
+		for i := 0; i < n; i++ {
+			if len(g.out[i]) == 0 {
+				dangling += rank[i]
+			}
+		}
+		base := (1-pageRankDamping)/float64(n) + pageRankDamping*dangling/float64(n)
+		for i := range next {
+			next[i] = base
+		}
+		for i := 0; i < n; i++ {
+			if len(g.out[i]) == 0 {
+				continue
+			}
+			share := pageRankDamping * rank[i] / float64(len(g.out[i]))
+			for _, t := range g.out[i] {
+				next[t] += share
+			}
+		}
+		var delta float64
+		for i := range rank {
+			delta += math.Abs(next[i] - rank[i])
+		}
+		rank, next = next, rank
+		if delta < pageRankTolerance {
+			break
+		}
+	}
+	return rank
+}
+
+// computeBetweenness Brandes 算法计算无向图的归一化介数中心度
+func computeBetweenness(g *courseGraph) []float64 {
+	n := len(g.nodes)
+	cb := make([]float64, n)
+	sigma := make([]float64, n)
+	dist := make([]int, n)
+	delta := make([]float64, n)
+	preds := make([][]int, n)
+	for s := 0; s < n; s++ {
+		stack := make([]int, 0, n)
+		for i := 0; i < n; i++ {
+			sigma[i] = 0
+			dist[i] = -1
+			delta[i] = 0
+			preds[i] = preds[i][:0]
+		}
+		sigma[s] = 1
+		dist[s] = 0
+		queue := []int{s}
+		for len(queue) > 0 {
+			v := queue[0]
+			queue = queue[1:]
+			stack = append(stack, v)
+			for _, w := range g.undir[v] {
+				if dist[w] < 0 {
+					dist[w] = dist[v] + 1
+					queue = append(queue, w)
+				}
+				if dist[w] == dist[v]+1 {
+					sigma[w] += sigma[v]
+					preds[w] = append(preds[w], v)
+				}
+			}
+		}
+		for i := len(stack) - 1; i >= 0; i-- {
+			w := stack[i]
+			for _, v := range preds[w] {
+				delta[v] += sigma[v] / sigma[w] * (1 + delta[w])
+			}
+			if w != s {
+				cb[w] += delta[w]
+			}
+		}
+	}
+	// 无向图中每条最短路径被统计两次，再按 (n-1)(n-2) 归一化
+	if n > 2 {
+		scale := 1 / float64((n-1)*(n-2))
+		for i := range cb {
+			cb[i] *= scale
+		}
+	}
+	return cb
+}
+
+// computeCloseness 计算调和接近中心度，图不连通时仍然有意义
+func computeCloseness(g *courseGraph) []float64 {
+	n := len(g.nodes)
+	closeness := make([]float64, n)
+	if n < 2 {
+		return closeness
+	}
+	dist := make([]int, n)
+	for s := 0; s < n; s++ {
+		for i := range dist {
+			dist[i] = -1
+		}
+		dist[s] = 0
+		queue := []int{s}
+		var sum float64
+		for len(queue) > 0 {
+			v := queue[0]
+			queue = queue[1:]
+			for _, w := range g.undir[v] {
+				if dist[w] < 0 {
+					dist[w] = dist[v] + 1
+					sum += 1 / float64(dist[w])
+					queue = append(queue, w)
+				}
+			}
+		}
+		closeness[s] = sum / float64(n-1)
+	}
+	return closeness
+}
@@ -0,0 +1,62 @@
+package analysis
+
+import (
+	"math"
+	"testing"
+)
+
+func testGraph(edges [][2]int64) *courseGraph {
+	seen := make(map[int64]bool)
+	var nodes []graphNode
+	for _, e := range edges {
+		for _, id := range e {
+			if !seen[id] {
+				seen[id] = true
+				nodes = append(nodes, graphNode{ID: id, Type: "point"})
+			}
+		}
+	}
+	return newCourseGraph(nodes, edges, nil)
+}
+
+func TestCentralityStar(t *testing.T) {
+	// 星形图：中心节点 1 位于所有其他节点之间
+	g := testGraph([][2]int64{{2, 1}, {3, 1}, {4, 1}, {1, 5}})
+	results := computeCentrality(g)
+	hub := results[g.index[1]]
+	leaf := results[g.index[2]]
+
+	if hub.Degree != 1 {
+		t.Errorf("hub degree = %v, want 1", hub.Degree)
+	}
+	if math.Abs(hub.Betweenness-1) > 1e-9 {
+		t.Errorf("hub betweenness = %v, want 1", hub.Betweenness)
+	}
+	if leaf.Betweenness != 0 {
+		t.Errorf("leaf betweenness = %v, want 0", leaf.Betweenness)
+	}
+	if hub.Closeness != 1 {
+		t.Errorf("hub closeness = %v, want 1", hub.Closeness)
+	}
+	if hub.PageRank <= leaf.PageRank {
+		t.Errorf("hub pagerank %v should exceed leaf pagerank %v", hub.PageRank, leaf.PageRank)
+	}
+
+	var total float64
+	for _, r := range results {
+		total += r.PageRank
+	}
+	if math.Abs(total-1) > 1e-6 {
+		t.Errorf("pagerank should sum to 1, got %v", total)
+	}
+}
+
+func TestParseRelationTypes(t *testing.T) {
+	types, err := parseRelationTypes("相关, 前置,相关")
+	if err != nil || len(types) != 2 {
+		t.Fatalf("parseRelationTypes = %v, %v", types, err)
+	}
+	if _, err := parseRelationTypes("依赖"); err == nil {
+		t.Error("expected error for unknown relation type")
+	}
+}
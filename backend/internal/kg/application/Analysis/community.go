@@ -0,0 +1,351 @@
+package analysis
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/RMS_V3/log"
+	"github.com/RMS_V3/middleware/neo4jUtils"
+	"github.com/RMS_V3/pkg/response"
+	"github.com/gin-gonic/gin"
+)
+
+// communityRelationTypes 参与社区发现的知识点间关系，包含 关系体现的是章节结构，用于对比而不参与划分
+var communityRelationTypes = []string{"前置", "相关", "扩展"}
+
+type ClusterMember struct {
+	ID      int64  `json:"id"`
+	Name    string `json:"name"`
+	Section string `json:"section"`
+}
+
+// Cluster 一个知识点社区，DominantSection 为成员最多的小节，Purity 为该小节成员所占比例
+type Cluster struct {
+	ID              int             `json:"id"`
+	Members         []ClusterMember `json:"members"`
+	DominantSection string          `json:"dominant_section"`
+	Purity          float64         `json:"purity"`
+}
+
+// InterClusterEdge 连接不同社区的关系
+type InterClusterEdge struct {
+	SourceID      int64  `json:"source_id"`
+	SourceName    string `json:"source_name"`
+	TargetID      int64  `json:"target_id"`
+	TargetName    string `json:"target_name"`
+	Type          string `json:"type"`
+	SourceCluster int    `json:"source_cluster"`
+	TargetCluster int    `json:"target_cluster"`
+}
+
+// MisplacedPoint 所在小节与所属社区的主要小节不一致的知识点
+type MisplacedPoint struct {
+	ClusterMember
+	Cluster          int    `json:"cluster"`
+	SuggestedSection string `json:"suggested_section"`
+}
+
+type CommunityResult struct {
+	Modularity        float64            `json:"modularity"`
+	Clusters          []Cluster          `json:"clusters"`
+	Isolated          []ClusterMember    `json:"isolated"`
+	InterClusterEdges []InterClusterEdge `json:"inter_cluster_edges"`
+	Misplaced         []MisplacedPoint   `json:"misplaced"`
+}
+
+var communityCache = newAnalysisCache()
+
+// DetectKnowledgeCommunities 在知识点及其 前置/相关/扩展 关系上运行 Louvain 社区发现，
+// 并与小节划分对比，找出可能放错小节的知识点
+func DetectKnowledgeCommunities(c *gin.Context) {
+	relationTypes := communityRelationTypes
+	if raw := c.Query("relation_types"); raw != "" {
+		var err error
+		if relationTypes, err = parseRelationTypes(raw); err != nil {
+			c.JSON(400, response.Error(400, err.Error()))
+			return
+		}
+	}
+	key := strings.Join(relationTypes, ",")
+	cached, computedAt, ok := communityCache.get(key)
+	if !ok || c.Query("refresh") == "true" {
+		session := neo4jUtils.GetSession()
+		if session == nil {
+			c.JSON(500, response.Error(500, "无法获取 Neo4j 会话"))
+			return
+		}
+		defer session.Close()
+
+		// 一并读取 包含 关系以确定知识点所在小节
+		graph, err := loadCourseGraph(session, append([]string{"包含"}, relationTypes...))
+		if err != nil {
+			log.Errorf("load course graph failed: %v", err)
+			c.JSON(500, response.Error(500, "分析失败"))
+			return
+		}
+		cached = detectCommunities(graph, relationTypes)
+		computedAt = communityCache.set(key, cached)
+	}
+
+	c.JSON(200, response.Success(map[string]interface{}{
+		"relation_types": relationTypes,
+		"computed_at":    computedAt,
+		"result":         cached,
+	}))
+}
+
+// detectCommunities 对图中的知识点划分社区，只使用 relationTypes 中的关系
+func detectCommunities(g *courseGraph, relationTypes []string) *CommunityResult {
+	useType := make(map[string]bool, len(relationTypes))
+	for _, t := range relationTypes {
+		useType[t] = t != "包含"
+	}
+
+	// 知识点在子图中的下标
+	var points []int
+	local := make(map[int]int)
+	for i, n := range g.nodes {
+		if n.Type == "point" {
+			local[i] = len(points)
+			points = append(points, i)
+		}
+	}
+	sectionOf := make(map[int]string)
+	adj := make([]map[int]float64, len(points))
+	for i := range adj {
+		adj[i] = make(map[int]float64)
+	}
+	var used []graphEdge
+	for _, e := range g.edges {
+		if e.Type == "包含" {
+			if g.nodes[e.Source].Type == "section" && g.nodes[e.Target].Type == "point" {
+				sectionOf[e.Target] = g.nodes[e.Source].Name
+			}
+			continue
+		}
+		s, ok1 := local[e.Source]
+		t, ok2 := local[e.Target]
+		if !ok1 || !ok2 || !useType[e.Type] {
+			continue
+		}
+		// 同一对知识点之间的多条关系合并为一条无向边
+		adj[s][t] = 1
+		adj[t][s] = 1
+		used = append(used, e)
+	}
+
+	community, modularity := louvain(adj)
+
+	result := &CommunityResult{
+		Modularity:        modularity,
+		Clusters:          []Cluster{},
+		Isolated:          []ClusterMember{},
+		InterClusterEdges: []InterClusterEdge{},
+		Misplaced:         []MisplacedPoint{},
+	}
+	member := func(i int) ClusterMember {
+		n := g.nodes[i]
+		return ClusterMember{ID: n.ID, Name: n.Name, Section: sectionOf[i]}
+	}
+
+	groups := make(map[int][]int)
+	for li, comm := range community {
+		groups[comm] = append(groups[comm], points[li])
+	}
+	comms := make([]int, 0, len(groups))
+	for comm := range groups {
+		comms = append(comms, comm)
+	}
+	// 按社区大小降序编号，结果稳定
+	sort.Slice(comms, func(i, j int) bool {
+		a, b := groups[comms[i]], groups[comms[j]]
+		if len(a) != len(b) {
+			return len(a) > len(b)
+		}
+		return g.nodes[a[0]].ID < g.nodes[b[0]].ID
+	})
+
+	clusterOf := make(map[int]int)
+	for _, comm := range comms {
+		nodes := groups[comm]
+		if len(nodes) == 1 {
+			result.Isolated = append(result.Isolated, member(nodes[0]))
+			clusterOf[nodes[0]] = -1
+			continue
+		}
+		cluster := Cluster{ID: len(result.Clusters)}
+		sectionCount := make(map[string]int)
+		for _, i := range nodes {
+			clusterOf[i] = cluster.ID
+			cluster.Members = append(cluster.Members, member(i))
+			if s := sectionOf[i]; s != "" {
+				sectionCount[s]++
+			}
+		}
+		best := 0
+		for s, count := range sectionCount {
+			if count > best || (count == best && s < cluster.DominantSection) {
+				best, cluster.DominantSection = count, s
+			}
+		}
+		cluster.Purity = float64(best) / float64(len(nodes))
+		// 只有当社区中多数知识点属于同一小节时，才认为其余知识点可能放错了位置
+		if cluster.Purity > 0.5 {
+			for _, m := range cluster.Members {
+				if m.Section != cluster.DominantSection {
+					result.Misplaced = append(result.Misplaced, MisplacedPoint{
+						ClusterMember:    m,
+						Cluster:          cluster.ID,
+						SuggestedSection: cluster.DominantSection,
+					})
+				}
+			}
+		}
+		result.Clusters = append(result.Clusters, cluster)
+	}
+
+	for _, e := range used {
+		sc, tc := clusterOf[e.Source], clusterOf[e.Target]
+		if sc == tc {
+			continue
+		}
+		result.InterClusterEdges = append(result.InterClusterEdges, InterClusterEdge{
+			SourceID:      g.nodes[e.Source].ID,
+			SourceName:    g.nodes[e.Source].Name,
+			TargetID:      g.nodes[e.Target].ID,
+			TargetName:    g.nodes[e.Target].Name,
+			Type:          e.Type,
+			SourceCluster: sc,
+			TargetCluster: tc,
+		})
+	}
+	return result
+}
+
+// louvain 对无向加权图进行 Louvain 社区发现，返回每个节点的社区编号和最终模块度。
+// adj[i][j] 为边权，自环权重按两倍计入，以便聚合后的社区内部边自然成为自环
+func louvain(adj []map[int]float64) ([]int, float64) {
+	n := len(adj)
+	community := make([]int, n)
+	for i := range community {
+		community[i] = i
+	}
+	current := adj
+	for {
+		moved, local := louvainLocalMoving(current)
+		for i := range community {
+			community[i] = local[community[i]]
+		}
+		if !moved {
+			break
+		}
+		current = louvainAggregate(current, local)
+	}
+	return community, modularity(adj, community)
+}
+
+// louvainLocalMoving 将每个节点移动到模块度增益最大的相邻社区，直到没有节点移动。
+// 返回是否发生移动以及重新编号为 0..k-1 的社区
+func louvainLocalMoving(adj []map[int]float64) (bool, []int) {
+	n := len(adj)
+	comm := make([]int, n)
+	degree := make([]float64, n)
+	total := make([]float64, n)
+	var m2 float64
+	for i := range adj {
+		comm[i] = i
+		for _, w := range adj[i] {
+			degree[i] += w
+		}
+		total[i] = degree[i]
+		m2 += degree[i]
+	}
+	if m2 == 0 {
+		return false, comm
+	}
+
+	moved := false
+	for improved := true; improved; {
+		improved = false
+		for i := 0; i < n; i++ {
+			links := make(map[int]float64)
+			for j, w := range adj[i] {
+				if j != i {
+					links[comm[j]] += w
+				}
+			}
+			old := comm[i]
+			total[old] -= degree[i]
+			best, bestGain := old, links[old]-total[old]*degree[i]/m2
+			// 按社区编号遍历，保证结果可复现
+			candidates := make([]int, 0, len(links))
+			for c := range links {
+				candidates = append(candidates, c)
+			}
+			sort.Ints(candidates)
+			for _, c := range candidates {
+				if gain := links[c] - total[c]*degree[i]/m2; gain > bestGain+1e-12 {
+					best, bestGain = c, gain
+				}
+			}
+			total[best] += degree[i]
+			if best != old {
+				comm[i] = best
+				improved = true
+				moved = true
+			}
+		}
+	}
+
+	renumber := make(map[int]int)
+	for i, c := range comm {
+		if _, ok := renumber[c]; !ok {
+			renumber[c] = len(renumber)
+		}
+		comm[i] = renumber[c]
+	}
+	return moved, comm
+}
+
+// louvainAggregate 把每个社区合并为一个节点
+func louvainAggregate(adj []map[int]float64, comm []int) []map[int]float64 {
+	k := 0
+	for _, c := range comm {
+		if c+1 > k {
+			k = c + 1
+		}
+	}
+	next := make([]map[int]float64, k)
+	for i := range next {
+		next[i] = make(map[int]float64)
+	}
+	for i := range adj {
+		for j, w := range adj[i] {
+			next[comm[i]][comm[j]] += w
+		}
+	}
+	return next
+}
+
+func modularity(adj []map[int]float64, comm []int) float64 {
+	var m2 float64
+	internal := make(map[int]float64)
+	total := make(map[int]float64)
+	for i := range adj {
+		for j, w := range adj[i] {
+			m2 += w
+			total[comm[i]] += w
+			if comm[i] == comm[j] {
+				internal[comm[i]] += w
+			}
+		}
+	}
+	if m2 == 0 {
+		return 0
+	}
+	var q float64
+	for c, tot := range total {
+		q += internal[c]/m2 - (tot/m2)*(tot/m2)
+	}
+	return q
+}
@@ -0,0 +1,36 @@
+package analysis
+
+import "testing"
+
+func TestDetectCommunitiesTwoTriangles(t *testing.T) {
+	// 两个三角形通过 3-4 相连；知识点 3 被放在了小节 B
+	nodes := []graphNode{
+		{ID: 100, Name: "A", Type: "section"},
+		{ID: 101, Name: "B", Type: "section"},
+	}
+	for id := int64(1); id <= 6; id++ {
+		nodes = append(nodes, graphNode{ID: id, Name: string(rune('a' + id - 1)), Type: "point"})
+	}
+	edges := [][2]int64{
+		{100, 1}, {100, 2}, {101, 3}, {101, 4}, {101, 5}, {101, 6},
+		{1, 2}, {2, 3}, {1, 3},
+		{4, 5}, {5, 6}, {4, 6},
+		{3, 4},
+	}
+	types := []string{"包含", "包含", "包含", "包含", "包含", "包含",
+		"前置", "相关", "相关", "前置", "相关", "扩展", "相关"}
+	result := detectCommunities(newCourseGraph(nodes, edges, types), communityRelationTypes)
+
+	if len(result.Clusters) != 2 {
+		t.Fatalf("expected 2 clusters, got %+v", result.Clusters)
+	}
+	if len(result.InterClusterEdges) != 1 || result.InterClusterEdges[0].SourceID != 3 {
+		t.Errorf("unexpected inter-cluster edges: %+v", result.InterClusterEdges)
+	}
+	if len(result.Misplaced) != 1 || result.Misplaced[0].ID != 3 || result.Misplaced[0].SuggestedSection != "A" {
+		t.Errorf("unexpected misplaced points: %+v", result.Misplaced)
+	}
+	if result.Modularity <= 0.3 {
+		t.Errorf("modularity too low: %v", result.Modularity)
+	}
+}
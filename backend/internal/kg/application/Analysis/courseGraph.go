@@ -0,0 +1,199 @@
+package analysis
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/neo4j/neo4j-go-driver/v4/neo4j"
+)
+
+// allRelationTypes 图谱中使用的全部关系类型
+var allRelationTypes = []string{"包含", "前置", "相关", "扩展"}
+
+type graphNode struct {
+	ID          int64  `json:"id"`
+	Name        string `json:"name"`
+	Type        string `json:"type"`
+	Description string `json:"-"`
+}
+
+type graphEdge struct {
+	Source int // 节点下标
+	Target int
+	Type   string
+}
+
+// courseGraph 在内存中表示整个课程图谱，节点以下标访问，供各分析算法使用
+type courseGraph struct {
+	nodes []graphNode
+	index map[int64]int
+	edges []graphEdge
+	out   [][]int // 有向邻接表
+	in    [][]int
+	undir [][]int // 无向邻接表，已去重
+}
+
+func newCourseGraph(nodes []graphNode, edges [][2]int64, types []string) *courseGraph {
+	g := &courseGraph{
+		nodes: nodes,
+		index: make(map[int64]int, len(nodes)),
+		out:   make([][]int, len(nodes)),
+		in:    make([][]int, len(nodes)),
+		undir: make([][]int, len(nodes)),
+	}
+	for i, n := range nodes {
+		g.index[n.ID] = i
+	}
+	seen := make(map[[2]int]bool)
+	for i, e := range edges {
+		s, ok1 := g.index[e[0]]
+		t, ok2 := g.index[e[1]]
+		if !ok1 || !ok2 || s == t {
+			continue
+		}
+		edgeType := ""
+		if i < len(types) {
+			edgeType = types[i]
+		}
+		g.edges = append(g.edges, graphEdge{Source: s, Target: t, Type: edgeType})
+		g.out[s] = append(g.out[s], t)
+		g.in[t] = append(g.in[t], s)
+		key := [2]int{s, t}
+		if s > t {
+			key = [2]int{t, s}
+		}
+		if !seen[key] {
+			seen[key] = true
+			g.undir[s] = append(g.undir[s], t)
+			g.undir[t] = append(g.undir[t], s)
+		}
+	}
+	return g
+}
+
+// parseRelationTypes 解析逗号分隔的关系类型，为空时返回全部类型
+func parseRelationTypes(raw string) ([]string, error) {
+	if strings.TrimSpace(raw) == "" {
+		return allRelationTypes, nil
+	}
+	valid := make(map[string]bool, len(allRelationTypes))
+	for _, t := range allRelationTypes {
+		valid[t] = true
+	}
+	selected := make(map[string]bool)
+	for _, t := range strings.Split(raw, ",") {
+		t = strings.TrimSpace(t)
+		if t == "" {
+			continue
+		}
+		if !valid[t] {
+			return nil, fmt.Errorf("无效的关系类型: %s", t)
+		}
+		selected[t] = true
+	}
+	types := make([]string, 0, len(selected))
+	for t := range selected {
+		types = append(types, t)
+	}
+	sort.Strings(types)
+	return types, nil
+}
+
+// loadCourseGraph 从 Neo4j 读取全部章节、小节、知识点以及指定类型的关系
+func loadCourseGraph(session neo4j.Session, relationTypes []string) (*courseGraph, error) {
+	result, err := session.Run(`
+	MATCH (n)
+	WHERE n:chapter OR n:section OR n:point
+	RETURN id(n) AS id, n.name AS name, labels(n)[0] AS type, n.description AS description
+	ORDER BY id(n)`, nil)
+	if err != nil {
+		return nil, err
+	}
+	var nodes []graphNode
+	for result.Next() {
+		record := result.Record()
+		id, _ := record.Get("id")
+		name, _ := record.Get("name")
+		nodeType, _ := record.Get("type")
+		description, _ := record.Get("description")
+		nodeId, ok := id.(int64)
+		if !ok {
+			continue
+		}
+		n := graphNode{ID: nodeId}
+		n.Name, _ = name.(string)
+		n.Type, _ = nodeType.(string)
+		n.Description, _ = description.(string)
+		nodes = append(nodes, n)
+	}
+	if err := result.Err(); err != nil {
+		return nil, err
+	}
+
+	result, err = session.Run(`
+	MATCH (a)-[r]->(b)
+	WHERE type(r) IN $types
+	RETURN id(a) AS source, id(b) AS target, type(r) AS type`,
+		map[string]interface{}{"types": relationTypes})
+	if err != nil {
+		return nil, err
+	}
+	var edges [][2]int64
+	var types []string
+	for result.Next() {
+		record := result.Record()
+		source, _ := record.Get("source")
+		target, _ := record.Get("target")
+		relType, _ := record.Get("type")
+		s, ok1 := source.(int64)
+		t, ok2 := target.(int64)
+		if !ok1 || !ok2 {
+			continue
+		}
+		typeName, _ := relType.(string)
+		edges = append(edges, [2]int64{s, t})
+		types = append(types, typeName)
+	}
+	if err := result.Err(); err != nil {
+		return nil, err
+	}
+	return newCourseGraph(nodes, edges, types), nil
+}
+
+// analysisCache 按关系类型缓存分析结果，图谱修改后最多 analysisCacheTTL 内生效，也可以通过 refresh 参数强制重新计算
+type analysisCache struct {
+	mu      sync.Mutex
+	entries map[string]analysisCacheEntry
+}
+
+type analysisCacheEntry struct {
+	value    interface{}
+	computed time.Time
+}
+
+const analysisCacheTTL = 5 * time.Minute
+
+func newAnalysisCache() *analysisCache {
+	return &analysisCache{entries: make(map[string]analysisCacheEntry)}
+}
+
+func (c *analysisCache) get(key string) (interface{}, time.Time, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	entry, ok := c.entries[key]
+	if !ok || time.Since(entry.computed) > analysisCacheTTL {
+		return nil, time.Time{}, false
+	}
+	return entry.value, entry.computed, true
+}
+
+func (c *analysisCache) set(key string, value interface{}) time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	now := time.Now()
+	c.entries[key] = analysisCacheEntry{value: value, computed: now}
+	return now
+}
@@ -0,0 +1,173 @@
+package analysis
+
+import (
+	"github.com/RMS_V3/config"
+)
+
+// DifficultyWeights 难度评分中各因素的权重
+type DifficultyWeights struct {
+	Chain    float64 `json:"chain"`
+	Prereq   float64 `json:"prereq"`
+	FanIn    float64 `json:"fan_in"`
+	Exercise float64 `json:"exercise"`
+}
+
+var defaultDifficultyWeights = DifficultyWeights{
+	Chain:    1.5,
+	Prereq:   1.0,
+	FanIn:    0.5,
+	Exercise: 1.0,
+}
+
+// difficultyWeights 读取配置中的权重
+func difficultyWeights() DifficultyWeights {
+	return weightsFromConfig(config.GetGlobalConfig().DifficultyConfig)
+}
+
+// weightsFromConfig 逐项取配置的权重，未配置或不为正数的项使用默认值
+func weightsFromConfig(cfg *config.DifficultyConfig) DifficultyWeights {
+	w := defaultDifficultyWeights
+	if cfg == nil {
+		return w
+	}
+	for _, f := range []struct {
+		configured float64
+		weight     *float64
+	}{{cfg.ChainWeight, &w.Chain}, {cfg.PrereqWeight, &w.Prereq}, {cfg.FanInWeight, &w.FanIn}, {cfg.ExerciseWeight, &w.Exercise}} {
+		if f.configured > 0 {
+			*f.weight = f.configured
+		}
+	}
+	return w
+}
+
+// exerciseLevels 习题难度对应的数值
+var exerciseLevels = map[string]float64{
+	"easy":   1,
+	"medium": 2,
+	"hard":   3,
+}
+
+// PointDifficulty 知识点的难度因素和评分
+type PointDifficulty struct {
+	PointID int64  `json:"point_id"`
+	Name    string `json:"name"`
+	// LongestChain 以该知识点结尾的最长前置链上的前置知识点数量
+	LongestChain int64 `json:"longest_chain"`
+	// PrereqCount 直接和间接前置知识点的数量
+	PrereqCount int64 `json:"prereq_count"`
+	// FanIn 直接前置知识点的数量
+	FanIn         int64            `json:"fan_in"`
+	ExerciseMix   map[string]int64 `json:"exercise_mix"`
+	ExerciseLevel float64          `json:"exercise_level"`
+	Score         float64          `json:"difficulty_score"`
+}
+
+// prerequisiteGraph 由 前置 关系构成的有向图，preds[p] 为 p 的直接前置知识点
+type prerequisiteGraph struct {
+	preds map[int64][]int64
+	depth map[int64]int64
+}
+
+// newPrerequisiteGraph edges 中每一项为 [前置知识点, 后续知识点]
+func newPrerequisiteGraph(edges [][2]int64) *prerequisiteGraph {
+	g := &prerequisiteGraph{
+		preds: make(map[int64][]int64),
+		depth: make(map[int64]int64),
+	}
+	for _, e := range edges {
+		g.preds[e[1]] = append(g.preds[e[1]], e[0])
+	}
+	return g
+}
+
+// longestChain 返回以 p 结尾的最长前置链长度，图中存在环时忽略回边，避免无限递归
+func (g *prerequisiteGraph) longestChain(p int64) int64 {
+	return g.longestChainFrom(p, make(map[int64]bool))
+}
+
+func (g *prerequisiteGraph) longestChainFrom(p int64, onPath map[int64]bool) int64 {
+	if d, ok := g.depth[p]; ok {
+		return d
+	}
+	onPath[p] = true
+	var best int64
+	for _, q := range g.preds[p] {
+		if onPath[q] {
+			continue
+		}
+		if d := g.longestChainFrom(q, onPath) + 1; d > best {
+			best = d
+		}
+	}
+	delete(onPath, p)
+	g.depth[p] = best
+	return best
+}
+
+// prereqCount 统计 p 的直接和间接前置知识点数量，不含 p 本身
+func (g *prerequisiteGraph) prereqCount(p int64) int64 {
+	visited := map[int64]bool{p: true}
+	queue := []int64{p}
+	var count int64
+	for len(queue) > 0 {
+		cur := queue[0]
+		queue = queue[1:]
+		for _, q := range g.preds[cur] {
+			if visited[q] {
+				continue
+			}
+			visited[q] = true
+			count++
+			queue = append(queue, q)
+		}
+	}
+	return count
+}
+
+func (g *prerequisiteGraph) fanIn(p int64) int64 {
+	seen := make(map[int64]bool)
+	for _, q := range g.preds[p] {
+		seen[q] = true
+	}
+	return int64(len(seen))
+}
+
+// assessPoint 计算单个知识点的难度因素和加权评分
+func (g *prerequisiteGraph) assessPoint(pointId int64, name string, exerciseMix map[string]int64, weights DifficultyWeights) PointDifficulty {
+	d := PointDifficulty{
+		PointID:      pointId,
+		Name:         name,
+		LongestChain: g.longestChain(pointId),
+		PrereqCount:  g.prereqCount(pointId),
+		FanIn:        g.fanIn(pointId),
+		ExerciseMix:  exerciseMix,
+	}
+	if d.ExerciseMix == nil {
+		d.ExerciseMix = map[string]int64{}
+	}
+	d.ExerciseLevel = averageExerciseLevel(d.ExerciseMix)
+	d.Score = calculateDifficultyScore(d, weights)
+	return d
+}
+
+// averageExerciseLevel 习题的平均难度，没有习题时为 0
+func averageExerciseLevel(mix map[string]int64) float64 {
+	var total, count float64
+	for difficulty, n := range mix {
+		total += exerciseLevels[difficulty] * float64(n)
+		count += float64(n)
+	}
+	if count == 0 {
+		return 0
+	}
+	return total / count
+}
+
+// 计算难度分数
+func calculateDifficultyScore(d PointDifficulty, weights DifficultyWeights) float64 {
+	return float64(d.LongestChain)*weights.Chain +
+		float64(d.PrereqCount)*weights.Prereq +
+		float64(d.FanIn)*weights.FanIn +
+		d.ExerciseLevel*weights.Exercise
+}
@@ -0,0 +1,54 @@
+package analysis
+
+import (
+	"testing"
+
+	"github.com/RMS_V3/config"
+)
+
+func TestPrerequisiteGraphDepth(t *testing.T) {
+	// 1 -> 2 -> 4, 3 -> 4, 1 -> 4：最长链 1->2->4，路径数量不影响深度
+	g := newPrerequisiteGraph([][2]int64{{1, 2}, {2, 4}, {3, 4}, {1, 4}})
+
+	if got := g.longestChain(4); got != 2 {
+		t.Errorf("longestChain(4) = %d, want 2", got)
+	}
+	if got := g.prereqCount(4); got != 3 {
+		t.Errorf("prereqCount(4) = %d, want 3", got)
+	}
+	if got := g.fanIn(4); got != 3 {
+		t.Errorf("fanIn(4) = %d, want 3", got)
+	}
+	if got := g.longestChain(1); got != 0 {
+		t.Errorf("longestChain(1) = %d, want 0", got)
+	}
+}
+
+func TestPrerequisiteGraphCycle(t *testing.T) {
+	g := newPrerequisiteGraph([][2]int64{{1, 2}, {2, 1}, {2, 3}})
+	if got := g.longestChain(3); got != 2 {
+		t.Errorf("longestChain(3) = %d, want 2", got)
+	}
+	if got := g.prereqCount(3); got != 2 {
+		t.Errorf("prereqCount(3) = %d, want 2", got)
+	}
+}
+
+func TestAssessPointScore(t *testing.T) {
+	g := newPrerequisiteGraph([][2]int64{{1, 2}})
+	weights := DifficultyWeights{Chain: 2, Prereq: 1, FanIn: 0.5, Exercise: 1}
+	d := g.assessPoint(2, "函数", map[string]int64{"easy": 1, "hard": 1}, weights)
+	// 链长 1*2 + 前置 1*1 + 入度 1*0.5 + 习题平均难度 2*1
+	if d.Score != 5.5 {
+		t.Errorf("score = %v, want 5.5", d.Score)
+	}
+}
+
+func TestWeightsFromConfig(t *testing.T) {
+	got := weightsFromConfig(&config.DifficultyConfig{FanInWeight: 2, ExerciseWeight: -1})
+	want := defaultDifficultyWeights
+	want.FanIn = 2
+	if got != want {
+		t.Errorf("partial config gave %+v, want %+v", got, want)
+	}
+}
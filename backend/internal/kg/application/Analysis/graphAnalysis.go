@@ -1,8 +1,11 @@
 package analysis
 
 import (
+	"sort"
 	"strconv"
+	"strings"
 
+	"github.com/RMS_V3/internal/kg/repository"
 	"github.com/RMS_V3/log"
 
 	"github.com/RMS_V3/middleware/neo4jUtils"
@@ -11,45 +14,82 @@ import (
 	"github.com/neo4j/neo4j-go-driver/v4/neo4j"
 )
 
-// 分析知识点关联度
-func AnalyzeKnowledgeConnections(c *gin.Context) {
-	session := neo4jUtils.GetSession()
-	defer session.Close()
+var centralityCache = newAnalysisCache()
 
-	// 使用Neo4j的中心度算法
-	query := `
-    CALL gds.degree.stream('knowledge-graph')
-    YIELD nodeId, score
-    RETURN gds.util.asNode(nodeId).name AS name, score
-    ORDER BY score DESC
-    `
+// 中心度排序字段
+var centralitySorters = map[string]func(NodeCentrality) float64{
+	"degree":      func(n NodeCentrality) float64 { return n.Degree },
+	"pagerank":    func(n NodeCentrality) float64 { return n.PageRank },
+	"betweenness": func(n NodeCentrality) float64 { return n.Betweenness },
+	"closeness":   func(n NodeCentrality) float64 { return n.Closeness },
+}
 
-	result, err := session.Run(query, nil)
+// 分析知识点关联度
+//
+// 在进程内计算度中心度、PageRank、介数和接近中心度，不依赖 GDS 插件。
+// relation_types 为逗号分隔的关系类型，node_type 只返回指定类型的节点，
+// sort 指定排序指标，limit 限制返回数量，refresh=true 时忽略缓存重新计算
+func AnalyzeKnowledgeConnections(c *gin.Context) {
+	relationTypes, err := parseRelationTypes(c.Query("relation_types"))
 	if err != nil {
-		c.JSON(500, response.Error(500, "分析失败"))
+		c.JSON(400, response.Error(400, err.Error()))
 		return
 	}
+	sortBy := c.DefaultQuery("sort", "degree")
+	sorter, ok := centralitySorters[sortBy]
+	if !ok {
+		c.JSON(400, response.Error(400, "sort 只能是 degree, pagerank, betweenness 或 closeness"))
+		return
+	}
+	limit := 0
+	if limitStr := c.Query("limit"); limitStr != "" {
+		if limit, err = strconv.Atoi(limitStr); err != nil || limit < 0 {
+			c.JSON(400, response.Error(400, "无效的 limit"))
+			return
+		}
+	}
+	nodeType := c.Query("node_type")
 
-	// 处理分析结果
-	analysis := processAnalysisResult(result)
-	c.JSON(200, response.Success(analysis))
-}
-
-// 处理分析结果
-func processAnalysisResult(result neo4j.Result) []map[string]interface{} {
-	var analysis []map[string]interface{}
+	key := strings.Join(relationTypes, ",")
+	cached, computedAt, ok := centralityCache.get(key)
+	if !ok || c.Query("refresh") == "true" {
+		session := neo4jUtils.GetSession()
+		if session == nil {
+			c.JSON(500, response.Error(500, "无法获取 Neo4j 会话"))
+			return
+		}
+		defer session.Close()
 
-	for result.Next() {
-		record := result.Record()
-		name, _ := record.Get("name")
-		score, _ := record.Get("score")
+		graph, err := loadCourseGraph(session, relationTypes)
+		if err != nil {
+			log.Errorf("load course graph failed: %v", err)
+			c.JSON(500, response.Error(500, "分析失败"))
+			return
+		}
+		cached = computeCentrality(graph)
+		computedAt = centralityCache.set(key, cached)
+	}
 
-		analysis = append(analysis, map[string]interface{}{
-			"name":  name.(string),
-			"score": score.(float64),
-		})
+	all := cached.([]NodeCentrality)
+	analysis := make([]NodeCentrality, 0, len(all))
+	for _, n := range all {
+		if nodeType == "" || n.Type == nodeType {
+			analysis = append(analysis, n)
+		}
+	}
+	sort.SliceStable(analysis, func(i, j int) bool {
+		return sorter(analysis[i]) > sorter(analysis[j])
+	})
+	if limit > 0 && len(analysis) > limit {
+		analysis = analysis[:limit]
 	}
-	return analysis
+
+	c.JSON(200, response.Success(map[string]interface{}{
+		"relation_types": relationTypes,
+		"sort":           sortBy,
+		"computed_at":    computedAt,
+		"nodes":          analysis,
+	}))
 }
 
 // 评估学习难度
@@ -60,65 +100,197 @@ func AssessLearningDifficulty(c *gin.Context) {
 		c.JSON(400, response.Error(400, "缺少参数: point_id"))
 		return
 	}
-	pointId, err := strconv.Atoi(pointIdStr)
-	// Neo4j 查询语句
-	query := `
-	MATCH (p:point)
-	WHERE id(p) = $pointId
-	CALL {
-		WITH p
-		MATCH (p)<-[:前置*]-(prereq)
-		RETURN count(prereq) AS prereqCount,
-			max(COUNT { (prereq)-[:前置*]->(p) }) AS maxDepth
-	}
-	RETURN prereqCount, maxDepth
-	`
-
-	// 执行查询
+	pointId, err := strconv.ParseInt(pointIdStr, 10, 64)
+	if err != nil {
+		c.JSON(400, response.Error(400, "无效的 point_id"))
+		return
+	}
+
 	session := neo4jUtils.GetSession()
+	if session == nil {
+		c.JSON(500, response.Error(500, "无法获取 Neo4j 会话"))
+		return
+	}
 	defer session.Close()
 
-	result, err := session.Run(query, map[string]interface{}{"pointId": pointId})
+	points, err := loadPoints(session, "MATCH (p:point) WHERE id(p) = $pointId RETURN id(p) AS id, p.name AS name",
+		map[string]interface{}{"pointId": pointId})
 	if err != nil {
+		log.Errorf("query point %d failed: %v", pointId, err)
 		c.JSON(500, response.Error(500, "查询失败"))
 		return
 	}
-	log.Infof("查询结果: %v", result)
-
-	// 处理查询结果
-	var prereqCount int64
-	var maxDepth int64
-	if result.Next() {
-		record := result.Record()
-		prereqCountValue, _ := record.Get("prereqCount")
-		maxDepthValue, _ := record.Get("maxDepth")
-
-		prereqCount = prereqCountValue.(int64)
-		if maxDepthValue != nil {
-			maxDepth = maxDepthValue.(int64)
-		} else {
-			maxDepth = 0 // 如果没有前置知识点，深度为 0
-		}
-	} else {
+	if len(points) == 0 {
 		c.JSON(404, response.Error(404, "未找到对应的知识点"))
 		return
 	}
 
-	// 计算难度分数
-	difficultyScore := calculateDifficultyScore(prereqCount, maxDepth)
+	results, err := assessPoints(session, points)
+	if err != nil {
+		log.Errorf("assess difficulty of point %d failed: %v", pointId, err)
+		c.JSON(500, response.Error(500, "查询失败"))
+		return
+	}
 
 	// 返回结果
+	c.JSON(200, response.Success(results[0]))
+}
+
+// AssessLearningDifficultyBatch 计算课程中所有知识点的难度，可通过 chapter_id 限定在某一章内，按难度从高到低返回
+func AssessLearningDifficultyBatch(c *gin.Context) {
+	query := "MATCH (p:point) RETURN id(p) AS id, p.name AS name"
+	params := map[string]interface{}{}
+	if chapterIdStr := c.Query("chapter_id"); chapterIdStr != "" {
+		chapterId, err := strconv.ParseInt(chapterIdStr, 10, 64)
+		if err != nil {
+			c.JSON(400, response.Error(400, "无效的 chapter_id"))
+			return
+		}
+		query = `
+		MATCH (c:chapter)-[:包含*]->(p:point)
+		WHERE id(c) = $chapterId
+		RETURN DISTINCT id(p) AS id, p.name AS name`
+		params["chapterId"] = chapterId
+	}
+
+	session := neo4jUtils.GetSession()
+	if session == nil {
+		c.JSON(500, response.Error(500, "无法获取 Neo4j 会话"))
+		return
+	}
+	defer session.Close()
+
+	points, err := loadPoints(session, query, params)
+	if err != nil {
+		log.Errorf("query points failed: %v", err)
+		c.JSON(500, response.Error(500, "查询失败"))
+		return
+	}
+	results, err := assessPoints(session, points)
+	if err != nil {
+		log.Errorf("assess difficulty failed: %v", err)
+		c.JSON(500, response.Error(500, "查询失败"))
+		return
+	}
+	sort.SliceStable(results, func(i, j int) bool {
+		return results[i].Score > results[j].Score
+	})
+
 	c.JSON(200, response.Success(map[string]interface{}{
-		"point_id":         pointId,
-		"prereq_count":     prereqCount,
-		"max_depth":        maxDepth,
-		"difficulty_score": difficultyScore,
+		"weights": difficultyWeights(),
+		"points":  results,
 	}))
 }
 
-// 计算难度分数
-func calculateDifficultyScore(prereqCount, maxDepth int64) float64 {
-	// 示例公式：难度分数 = 前置知识点数量 + 最深深度 * 权重
-	depthWeight := 1.5 // 深度权重，可以根据实际情况调整
-	return float64(prereqCount) + float64(maxDepth)*depthWeight
+type pointRef struct {
+	ID   int64
+	Name string
+}
+
+// loadPoints 执行返回 id 和 name 列的查询
+func loadPoints(session neo4j.Session, query string, params map[string]interface{}) ([]pointRef, error) {
+	result, err := session.Run(query, params)
+	if err != nil {
+		return nil, err
+	}
+	var points []pointRef
+	for result.Next() {
+		record := result.Record()
+		id, _ := record.Get("id")
+		name, _ := record.Get("name")
+		pointId, ok := id.(int64)
+		if !ok {
+			continue
+		}
+		pointName, _ := name.(string)
+		points = append(points, pointRef{ID: pointId, Name: pointName})
+	}
+	return points, result.Err()
+}
+
+// loadPrerequisiteEdges 读取图谱中全部 前置 关系，前置链可能跨越章节，因此不按章节过滤
+func loadPrerequisiteEdges(session neo4j.Session) ([][2]int64, error) {
+	result, err := session.Run(`
+	MATCH (a:point)-[:前置]->(b:point)
+	RETURN id(a) AS source, id(b) AS target`, nil)
+	if err != nil {
+		return nil, err
+	}
+	var edges [][2]int64
+	for result.Next() {
+		record := result.Record()
+		source, _ := record.Get("source")
+		target, _ := record.Get("target")
+		s, ok1 := source.(int64)
+		t, ok2 := target.(int64)
+		if ok1 && ok2 {
+			edges = append(edges, [2]int64{s, t})
+		}
+	}
+	return edges, result.Err()
+}
+
+func assessPoints(session neo4j.Session, points []pointRef) ([]PointDifficulty, error) {
+	edges, err := loadPrerequisiteEdges(session)
+	if err != nil {
+		return nil, err
+	}
+	pointIds := make([]int64, len(points))
+	for i, p := range points {
+		pointIds[i] = p.ID
+	}
+	exerciseMix, err := repository.CountExerciseDifficulty(pointIds)
+	if err != nil {
+		return nil, err
+	}
+
+	graph := newPrerequisiteGraph(edges)
+	weights := difficultyWeights()
+	results := make([]PointDifficulty, 0, len(points))
+	for _, p := range points {
+		results = append(results, graph.assessPoint(p.ID, p.Name, exerciseMix[p.ID], weights))
+	}
+	return results, nil
+}
+
+// PointDifficultyScores 计算指定知识点的难度评分，供学习路径推荐使用
+func PointDifficultyScores(session neo4j.Session, pointIds []int64) (map[int64]float64, error) {
+	points := make([]pointRef, len(pointIds))
+	for i, id := range pointIds {
+		points[i] = pointRef{ID: id}
+	}
+	results, err := assessPoints(session, points)
+	if err != nil {
+		return nil, err
+	}
+	scores := make(map[int64]float64, len(results))
+	for _, r := range results {
+		scores[r.PointID] = r.Score
+	}
+	return scores, nil
+}
+
+// PointCentralityScores 返回知识点在全部关系上的 PageRank，供其他模块按重要程度加权，与关联度分析共用缓存
+func PointCentralityScores(session neo4j.Session, pointIds []int64) (map[int64]float64, error) {
+	key := strings.Join(allRelationTypes, ",")
+	cached, _, ok := centralityCache.get(key)
+	if !ok {
+		graph, err := loadCourseGraph(session, allRelationTypes)
+		if err != nil {
+			return nil, err
+		}
+		cached = computeCentrality(graph)
+		centralityCache.set(key, cached)
+	}
+	wanted := make(map[int64]bool, len(pointIds))
+	for _, id := range pointIds {
+		wanted[id] = true
+	}
+	scores := make(map[int64]float64, len(pointIds))
+	for _, n := range cached.([]NodeCentrality) {
+		if wanted[n.ID] {
+			scores[n.ID] = n.PageRank
+		}
+	}
+	return scores, nil
 }
@@ -0,0 +1,452 @@
+package analysis
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/RMS_V3/internal/kg/repository"
+	"github.com/RMS_V3/log"
+	"github.com/RMS_V3/middleware/neo4jUtils"
+	"github.com/RMS_V3/pkg/response"
+	"github.com/gin-gonic/gin"
+)
+
+// 检查结果的严重程度
+const (
+	SeverityError   = "error"
+	SeverityWarning = "warning"
+	SeverityInfo    = "info"
+)
+
+var severityRank = map[string]int{SeverityInfo: 0, SeverityWarning: 1, SeverityError: 2}
+
+// 检查项
+const (
+	LintIsolatedPoint     = "isolated_point"
+	LintOrphanPoint       = "orphan_point"
+	LintEmptySection      = "empty_section"
+	LintEmptyChapter      = "empty_chapter"
+	LintMissingResource   = "missing_resource"
+	LintEmptyDescription  = "empty_description"
+	LintDuplicateName     = "duplicate_name"
+	LintNearDuplicateName = "near_duplicate_name"
+	LintBackwardPrereq    = "backward_prerequisite"
+	LintPrereqCycle       = "prerequisite_cycle"
+)
+
+// LintFinding 一条检查结果，Link 为前端图谱页面中定位该节点的地址
+type LintFinding struct {
+	Severity string      `json:"severity"`
+	Check    string      `json:"check"`
+	Message  string      `json:"message"`
+	Node     graphNode   `json:"node"`
+	Link     string      `json:"link"`
+	Related  []graphNode `json:"related,omitempty"`
+}
+
+type LintReport struct {
+	GeneratedAt time.Time      `json:"generated_at"`
+	Counts      map[string]int `json:"counts"`
+	Findings    []LintFinding  `json:"findings"`
+}
+
+func nodeLink(n graphNode) string {
+	return fmt.Sprintf("/graph?node_id=%d&type=%s", n.ID, n.Type)
+}
+
+// LintKnowledgeGraph 审查课程图谱的质量问题，severity 参数指定返回的最低严重程度
+func LintKnowledgeGraph(c *gin.Context) {
+	minSeverity := c.DefaultQuery("severity", SeverityInfo)
+	if _, ok := severityRank[minSeverity]; !ok {
+		c.JSON(400, response.Error(400, "severity 只能是 error, warning 或 info"))
+		return
+	}
+	report, err := buildLintReport(minSeverity)
+	if err != nil {
+		log.Errorf("lint knowledge graph failed: %v", err)
+		c.JSON(500, response.Error(500, "图谱检查失败"))
+		return
+	}
+	c.JSON(200, response.Success(report))
+}
+
+func buildLintReport(minSeverity string) (*LintReport, error) {
+	session := neo4jUtils.GetSession()
+	if session == nil {
+		return nil, fmt.Errorf("无法获取 Neo4j 会话")
+	}
+	defer session.Close()
+
+	graph, err := loadCourseGraph(session, allRelationTypes)
+	if err != nil {
+		return nil, err
+	}
+	withResources, err := repository.PointsWithResources()
+	if err != nil {
+		return nil, err
+	}
+	return lintCourseGraph(graph, withResources, minSeverity), nil
+}
+
+// lintCourseGraph 对内存中的图谱执行全部检查
+func lintCourseGraph(g *courseGraph, withResources map[int64]bool, minSeverity string) *LintReport {
+	var findings []LintFinding
+	add := func(severity, check string, node graphNode, message string, related ...graphNode) {
+		if severityRank[severity] < severityRank[minSeverity] {
+			return
+		}
+		findings = append(findings, LintFinding{
+			Severity: severity,
+			Check:    check,
+			Message:  message,
+			Node:     node,
+			Link:     nodeLink(node),
+			Related:  related,
+		})
+	}
+
+	// 按 包含 关系整理层级
+	parent := make(map[int]int)
+	children := make(map[int]int)
+	for _, e := range g.edges {
+		if e.Type == "包含" {
+			parent[e.Target] = e.Source
+			children[e.Source]++
+		}
+	}
+
+	for i, n := range g.nodes {
+		switch n.Type {
+		case "chapter":
+			if children[i] == 0 {
+				add(SeverityWarning, LintEmptyChapter, n, fmt.Sprintf("章节 '%s' 下没有小节", n.Name))
+			}
+		case "section":
+			if children[i] == 0 {
+				add(SeverityWarning, LintEmptySection, n, fmt.Sprintf("小节 '%s' 下没有知识点", n.Name))
+			}
+		case "point":
+			if _, ok := parent[i]; !ok {
+				add(SeverityWarning, LintOrphanPoint, n, fmt.Sprintf("知识点 '%s' 不属于任何小节", n.Name))
+			}
+			related := 0
+			for _, j := range g.undir[i] {
+				if g.nodes[j].Type == "point" {
+					related++
+				}
+			}
+			if related == 0 {
+				add(SeverityWarning, LintIsolatedPoint, n, fmt.Sprintf("知识点 '%s' 与其他知识点之间没有任何关系", n.Name))
+			}
+			if !withResources[n.ID] {
+				add(SeverityInfo, LintMissingResource, n, fmt.Sprintf("知识点 '%s' 没有关联视频、习题或课件", n.Name))
+			}
+		}
+		if strings.TrimSpace(n.Description) == "" {
+			add(SeverityInfo, LintEmptyDescription, n, fmt.Sprintf("节点 '%s' 缺少描述", n.Name))
+		}
+	}
+
+	lintDuplicateNames(g, add)
+	lintBackwardPrerequisites(g, parent, add)
+	for _, cycle := range prerequisiteCycles(g) {
+		names := make([]string, len(cycle))
+		related := make([]graphNode, len(cycle))
+		for k, i := range cycle {
+			names[k] = g.nodes[i].Name
+			related[k] = g.nodes[i]
+		}
+		add(SeverityError, LintPrereqCycle, g.nodes[cycle[0]],
+			fmt.Sprintf("前置关系存在环: %s", strings.Join(names, " -> ")), related...)
+	}
+
+	sort.SliceStable(findings, func(i, j int) bool {
+		return severityRank[findings[i].Severity] > severityRank[findings[j].Severity]
+	})
+	report := &LintReport{
+		GeneratedAt: time.Now(),
+		Counts:      map[string]int{SeverityError: 0, SeverityWarning: 0, SeverityInfo: 0},
+		Findings:    findings,
+	}
+	if report.Findings == nil {
+		report.Findings = []LintFinding{}
+	}
+	for _, f := range findings {
+		report.Counts[f.Severity]++
+	}
+	return report
+}
+
+type lintAdder func(severity, check string, node graphNode, message string, related ...graphNode)
+
+// normalizeName 去掉空白和标点并转为小写，用于发现近似重复的名称
+func normalizeName(name string) string {
+	var b strings.Builder
+	for _, r := range name {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			b.WriteRune(unicode.ToLower(r))
+		}
+	}
+	return b.String()
+}
+
+// editDistance 按字符计算编辑距离
+func editDistance(a, b []rune) int {
+	prev := make([]int, len(b)+1)
+	cur := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		cur[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			cur[j] = min(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+		}
+		prev, cur = cur, prev
+	}
+	return prev[len(b)]
+}
+
+// lintDuplicateNames 同名节点会导致按名称定位节点的接口行为不确定，
+// 去掉标点后相同或只差一个字符（名称至少四个字符）的视为近似重复
+func lintDuplicateNames(g *courseGraph, add lintAdder) {
+	normalized := make([][]rune, len(g.nodes))
+	for i, n := range g.nodes {
+		normalized[i] = []rune(normalizeName(n.Name))
+	}
+	for i := range g.nodes {
+		for j := i + 1; j < len(g.nodes); j++ {
+			a, b := g.nodes[i], g.nodes[j]
+			switch {
+			case a.Name == b.Name && a.Type == b.Type:
+				add(SeverityError, LintDuplicateName, a, fmt.Sprintf("存在两个名为 '%s' 的%s节点", a.Name, a.Type), b)
+			case a.Name == b.Name:
+				add(SeverityWarning, LintDuplicateName, a, fmt.Sprintf("%s节点和%s节点同名: '%s'", a.Type, b.Type, a.Name), b)
+			case string(normalized[i]) == string(normalized[j]):
+				add(SeverityWarning, LintNearDuplicateName, a, fmt.Sprintf("'%s' 与 '%s' 名称近似", a.Name, b.Name), b)
+			case len(normalized[i]) >= 4 && len(normalized[j]) >= 4 &&
+				abs(len(normalized[i])-len(normalized[j])) <= 1 &&
+				editDistance(normalized[i], normalized[j]) <= 1:
+				add(SeverityInfo, LintNearDuplicateName, a, fmt.Sprintf("'%s' 与 '%s' 名称近似", a.Name, b.Name), b)
+			}
+		}
+	}
+}
+
+func abs(x int) int {
+	if x < 0 {
+		return -x
+	}
+	return x
+}
+
+var chapterNumberPattern = regexp.MustCompile(`^(?:第\s*([0-9一二三四五六七八九十百零〇两]+)\s*章|(?i:chapter)\s*(\d+)|(\d+))`)
+
+// chapterOrder 从章节名称中解析章号，无法解析时返回 -1
+func chapterOrder(name string) int {
+	m := chapterNumberPattern.FindStringSubmatch(strings.TrimSpace(name))
+	if m == nil {
+		return -1
+	}
+	for _, group := range m[1:] {
+		if group == "" {
+			continue
+		}
+		if n, err := strconv.Atoi(group); err == nil {
+			return n
+		}
+		return parseChineseNumber(group)
+	}
+	return -1
+}
+
+// parseChineseNumber 解析一百以内的中文数字
+func parseChineseNumber(s string) int {
+	digits := map[rune]int{'零': 0, '〇': 0, '一': 1, '二': 2, '两': 2, '三': 3, '四': 4, '五': 5, '六': 6, '七': 7, '八': 8, '九': 9}
+	total, current := 0, 0
+	for _, r := range s {
+		switch r {
+		case '十':
+			if current == 0 {
+				current = 1
+			}
+			total += current * 10
+			current = 0
+		case '百':
+			if current == 0 {
+				current = 1
+			}
+			total += current * 100
+			current = 0
+		default:
+			d, ok := digits[r]
+			if !ok {
+				return -1
+			}
+			current = d
+		}
+	}
+	return total + current
+}
+
+// lintBackwardPrerequisites 前置知识点位于比后续知识点更靠后的章节时，学习顺序与教材顺序相反。
+// 章节顺序优先取名称中的章号，无法解析时按节点创建顺序
+func lintBackwardPrerequisites(g *courseGraph, parent map[int]int, add lintAdder) {
+	var chapters []int
+	for i, n := range g.nodes {
+		if n.Type == "chapter" {
+			chapters = append(chapters, i)
+		}
+	}
+	sort.SliceStable(chapters, func(a, b int) bool {
+		oa, ob := chapterOrder(g.nodes[chapters[a]].Name), chapterOrder(g.nodes[chapters[b]].Name)
+		if oa >= 0 && ob >= 0 && oa != ob {
+			return oa < ob
+		}
+		return g.nodes[chapters[a]].ID < g.nodes[chapters[b]].ID
+	})
+	position := make(map[int]int, len(chapters))
+	for pos, i := range chapters {
+		position[i] = pos
+	}
+	chapterOf := func(i int) (int, bool) {
+		for depth := 0; depth < 3; depth++ {
+			if g.nodes[i].Type == "chapter" {
+				return i, true
+			}
+			p, ok := parent[i]
+			if !ok {
+				return 0, false
+			}
+			i = p
+		}
+		return 0, false
+	}
+
+	for _, e := range g.edges {
+		if e.Type != "前置" {
+			continue
+		}
+		sc, ok1 := chapterOf(e.Source)
+		tc, ok2 := chapterOf(e.Target)
+		if !ok1 || !ok2 || position[sc] <= position[tc] {
+			continue
+		}
+		source, target := g.nodes[e.Source], g.nodes[e.Target]
+		add(SeverityWarning, LintBackwardPrereq, target,
+			fmt.Sprintf("'%s'(%s) 的前置知识点 '%s' 位于更靠后的章节 %s", target.Name, g.nodes[tc].Name, source.Name, g.nodes[sc].Name),
+			source)
+	}
+}
+
+// prerequisiteCycles 用 Tarjan 算法找出 前置 关系中的强连通分量，每个包含多个节点的分量都是一个环
+func prerequisiteCycles(g *courseGraph) [][]int {
+	n := len(g.nodes)
+	succ := make([][]int, n)
+	for _, e := range g.edges {
+		if e.Type == "前置" {
+			succ[e.Source] = append(succ[e.Source], e.Target)
+		}
+	}
+	index := make([]int, n)
+	low := make([]int, n)
+	onStack := make([]bool, n)
+	for i := range index {
+		index[i] = -1
+	}
+	var stack []int
+	var cycles [][]int
+	counter := 0
+
+	var strongConnect func(v int)
+	strongConnect = func(v int) {
+		index[v], low[v] = counter, counter
+		counter++
+		stack = append(stack, v)
+		onStack[v] = true
+		for _, w := range succ[v] {
+			if index[w] < 0 {
+				strongConnect(w)
+				low[v] = min(low[v], low[w])
+			} else if onStack[w] {
+				low[v] = min(low[v], index[w])
+			}
+		}
+		if low[v] != index[v] {
+			return
+		}
+		var component []int
+		for {
+			w := stack[len(stack)-1]
+			stack = stack[:len(stack)-1]
+			onStack[w] = false
+			component = append(component, w)
+			if w == v {
+				break
+			}
+		}
+		if len(component) > 1 {
+			sort.Ints(component)
+			cycles = append(cycles, component)
+		}
+	}
+	for v := 0; v < n; v++ {
+		if index[v] < 0 {
+			strongConnect(v)
+		}
+	}
+	return cycles
+}
+
+// RunLintCommand 命令行执行图谱检查，发现 error 级别问题时返回非零退出码
+//
+//	用法: main lint [-format text|json] [-severity info|warning|error]
+func RunLintCommand(args []string, out io.Writer) int {
+	flags := flag.NewFlagSet("lint", flag.ContinueOnError)
+	flags.SetOutput(out)
+	format := flags.String("format", "text", "输出格式: text 或 json")
+	minSeverity := flags.String("severity", SeverityInfo, "输出的最低严重程度: info, warning 或 error")
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+	if _, ok := severityRank[*minSeverity]; !ok {
+		fmt.Fprintf(out, "无效的严重程度: %s\n", *minSeverity)
+		return 2
+	}
+
+	report, err := buildLintReport(*minSeverity)
+	if err != nil {
+		fmt.Fprintf(out, "图谱检查失败: %v\n", err)
+		return 1
+	}
+
+	if *format == "json" {
+		encoder := json.NewEncoder(out)
+		encoder.SetIndent("", "  ")
+		if err := encoder.Encode(report); err != nil {
+			return 1
+		}
+	} else {
+		for _, f := range report.Findings {
+			fmt.Fprintf(out, "[%s] %s: %s (%s)\n", f.Severity, f.Check, f.Message, f.Link)
+		}
+		fmt.Fprintf(out, "共 %d 个错误, %d 个警告, %d 条提示\n",
+			report.Counts[SeverityError], report.Counts[SeverityWarning], report.Counts[SeverityInfo])
+	}
+	if report.Counts[SeverityError] > 0 {
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,47 @@
+package analysis
+
+import "testing"
+
+func TestLintCourseGraph(t *testing.T) {
+	nodes := []graphNode{
+		{ID: 1, Name: "第二章 函数", Type: "chapter", Description: "d"},
+		{ID: 2, Name: "第一章 集合", Type: "chapter", Description: "d"},
+		{ID: 3, Name: "2.1 函数的概念", Type: "section", Description: "d"},
+		{ID: 4, Name: "1.1 集合的概念", Type: "section", Description: "d"},
+		{ID: 5, Name: "函数定义", Type: "point", Description: "d"},
+		{ID: 6, Name: "集合运算", Type: "point", Description: "d"},
+		{ID: 7, Name: "集合 运算", Type: "point"},
+		{ID: 8, Name: "第三章 空章", Type: "chapter", Description: "d"},
+	}
+	edges := [][2]int64{{1, 3}, {2, 4}, {3, 5}, {4, 6}, {4, 7}, {5, 6}, {6, 7}, {7, 6}}
+	types := []string{"包含", "包含", "包含", "包含", "包含", "前置", "前置", "前置"}
+	report := lintCourseGraph(newCourseGraph(nodes, edges, types), map[int64]bool{5: true, 6: true, 7: true}, SeverityInfo)
+
+	found := make(map[string]int)
+	for _, f := range report.Findings {
+		found[f.Check]++
+	}
+	for check, want := range map[string]int{
+		LintEmptyChapter:      1,
+		LintBackwardPrereq:    1, // 第二章的 函数定义 是第一章 集合运算 的前置
+		LintPrereqCycle:       1,
+		LintNearDuplicateName: 1,
+		LintEmptyDescription:  1,
+		LintMissingResource:   0,
+	} {
+		if found[check] != want {
+			t.Errorf("%s: got %d findings, want %d (%+v)", check, found[check], want, report.Findings)
+		}
+	}
+	if report.Counts[SeverityError] != 1 {
+		t.Errorf("expected 1 error, got %d", report.Counts[SeverityError])
+	}
+}
+
+func TestChapterOrder(t *testing.T) {
+	for name, want := range map[string]int{"第十二章 导数": 12, "Chapter 3 Limits": 3, "4 积分": 4, "附录": -1} {
+		if got := chapterOrder(name); got != want {
+			t.Errorf("chapterOrder(%q) = %d, want %d", name, got, want)
+		}
+	}
+}
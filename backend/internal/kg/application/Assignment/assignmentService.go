@@ -0,0 +1,417 @@
+package assignment
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/RMS_V3/internal/kg/repository"
+	"github.com/RMS_V3/internal/kg/repository/models"
+	"github.com/RMS_V3/internal/user"
+	"github.com/RMS_V3/log"
+	"github.com/RMS_V3/middleware/neo4jUtils"
+	"github.com/RMS_V3/pkg/response"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// maxPathDepth 学习路径类作业展开前置知识点的最大层数
+const maxPathDepth = 10
+
+// AssignmentView 返回给前端的作业
+type AssignmentView struct {
+	models.Assignment
+	PointIDs []int64        `json:"point_ids"`
+	Status   *StudentStatus `json:"status,omitempty"`
+}
+
+func decodePoints(a *models.Assignment) []int64 {
+	ids := []int64{}
+	if a.PointIDs == "" {
+		return ids
+	}
+	if err := json.Unmarshal([]byte(a.PointIDs), &ids); err != nil {
+		log.Errorf("decode points of assignment %d failed: %v", a.ID, err)
+	}
+	return ids
+}
+
+// existingPoints 返回 ids 中在图谱里存在的知识点
+func existingPoints(ids []int64) (map[int64]bool, error) {
+	session := neo4jUtils.GetSession()
+	if session == nil {
+		return nil, fmt.Errorf("无法获取 Neo4j 会话")
+	}
+	defer session.Close()
+	result, err := session.Run(`
+	MATCH (p:point)
+	WHERE id(p) IN $ids
+	RETURN id(p) AS id`, map[string]interface{}{"ids": ids})
+	if err != nil {
+		return nil, err
+	}
+	found := make(map[int64]bool)
+	for result.Next() {
+		id, _ := result.Record().Get("id")
+		if pid, ok := id.(int64); ok {
+			found[pid] = true
+		}
+	}
+	return found, result.Err()
+}
+
+// pathPoints 学习路径涉及的知识点：目标知识点及其 maxPathDepth 层以内的全部前置知识点，
+// 目标不存在时返回 nil
+func pathPoints(target int64) ([]int64, error) {
+	session := neo4jUtils.GetSession()
+	if session == nil {
+		return nil, fmt.Errorf("无法获取 Neo4j 会话")
+	}
+	defer session.Close()
+	result, err := session.Run(fmt.Sprintf(`
+	MATCH (t:point)
+	WHERE id(t) = $id
+	OPTIONAL MATCH (p:point)-[:前置*1..%d]->(t)
+	RETURN collect(DISTINCT id(p)) AS prerequisites`, maxPathDepth),
+		map[string]interface{}{"id": target})
+	if err != nil {
+		return nil, err
+	}
+	if !result.Next() {
+		return nil, result.Err()
+	}
+	ids := []int64{target}
+	raw, _ := result.Record().Get("prerequisites")
+	if list, ok := raw.([]interface{}); ok {
+		for _, v := range list {
+			if id, ok := v.(int64); ok {
+				ids = append(ids, id)
+			}
+		}
+	}
+	return ids, nil
+}
+
+// checkGroupOwner 只有组的创建者和拥有 user.manage 权限的账号可以管理组的作业，失败时已写入响应
+func checkGroupOwner(c *gin.Context, u *user.User, groupId int64) bool {
+	if user.HasPermission(u.UserType, user.UserManage) {
+		return true
+	}
+	return user.IsGroupOwner(u, int(groupId), c)
+}
+
+type CreateAssignmentRequest struct {
+	GroupID     int64   `json:"group_id" binding:"required"`
+	Title       string  `json:"title" binding:"required,max=100"`
+	Description string  `json:"description" binding:"max=65535"`
+	Kind        string  `json:"kind" binding:"required,oneof=points path quiz"`
+	PointIDs    []int64 `json:"point_ids"`
+	// TargetID 学习路径的目标知识点或测验ID
+	TargetID int64      `json:"target_id"`
+	OpenAt   *time.Time `json:"open_at"`
+	DueAt    time.Time  `json:"due_at" binding:"required"`
+}
+
+// resolveContent 校验作业内容并得到作业涉及的知识点，失败时已写入响应
+func resolveContent(c *gin.Context, req *CreateAssignmentRequest) ([]int64, bool) {
+	switch req.Kind {
+	case models.AssignmentPoints:
+		if len(req.PointIDs) == 0 {
+			c.JSON(400, response.Error(400, "请选择知识点"))
+			return nil, false
+		}
+		found, err := existingPoints(req.PointIDs)
+		if err != nil {
+			log.Errorf("check assignment points failed: %v", err)
+			c.JSON(500, response.Error(500, "校验知识点失败"))
+			return nil, false
+		}
+		ids := make([]int64, 0, len(req.PointIDs))
+		seen := make(map[int64]bool)
+		for _, id := range req.PointIDs {
+			if !found[id] {
+				c.JSON(400, response.Error(400, fmt.Sprintf("知识点不存在: %d", id)))
+				return nil, false
+			}
+			if !seen[id] {
+				seen[id] = true
+				ids = append(ids, id)
+			}
+		}
+		return ids, true
+	case models.AssignmentPath:
+		ids, err := pathPoints(req.TargetID)
+		if err != nil {
+			log.Errorf("load learning path to point %d failed: %v", req.TargetID, err)
+			c.JSON(500, response.Error(500, "读取学习路径失败"))
+			return nil, false
+		}
+		if ids == nil {
+			c.JSON(400, response.Error(400, "目标知识点不存在"))
+			return nil, false
+		}
+		return ids, true
+	default:
+		if _, err := repository.GetQuiz(req.TargetID); err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				c.JSON(400, response.Error(400, "测验不存在"))
+				return nil, false
+			}
+			log.Errorf("load quiz %d failed: %v", req.TargetID, err)
+			c.JSON(500, response.Error(500, "读取测验失败"))
+			return nil, false
+		}
+		return []int64{}, true
+	}
+}
+
+// CreateAssignment 教师给自己的学生组布置作业：一组知识点、到目标知识点的学习路径或一次测验
+func CreateAssignment(c *gin.Context) {
+	u := user.CurrentUser(c)
+	var req CreateAssignmentRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(400, response.Error(400, "参数验证失败"))
+		return
+	}
+	if !checkGroupOwner(c, u, req.GroupID) {
+		return
+	}
+	openAt := time.Now()
+	if req.OpenAt != nil {
+		openAt = *req.OpenAt
+	}
+	if !req.DueAt.After(openAt) {
+		c.JSON(400, response.Error(400, "截止时间必须晚于开放时间"))
+		return
+	}
+	points, ok := resolveContent(c, &req)
+	if !ok {
+		return
+	}
+	encoded, _ := json.Marshal(points)
+	assignment := &models.Assignment{
+		GroupID:     req.GroupID,
+		Title:       req.Title,
+		Description: req.Description,
+		Kind:        req.Kind,
+		PointIDs:    string(encoded),
+		OpenAt:      openAt,
+		DueAt:       req.DueAt,
+		CreatedBy:   u.Id,
+	}
+	if req.Kind != models.AssignmentPoints {
+		assignment.TargetID = req.TargetID
+	}
+	if err := repository.CreateAssignment(assignment); err != nil {
+		log.Errorf("create assignment failed: %v", err)
+		c.JSON(500, response.Error(500, "布置作业失败"))
+		return
+	}
+	c.JSON(200, response.Success(AssignmentView{Assignment: *assignment, PointIDs: points}))
+}
+
+// loadOwnAssignment 读取作业并检查当前用户是否可以管理，失败时已写入响应
+func loadOwnAssignment(c *gin.Context, u *user.User, id int64) (*models.Assignment, bool) {
+	assignment, err := repository.GetAssignment(id)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		c.JSON(404, response.Error(404, "作业不存在"))
+		return nil, false
+	}
+	if err != nil {
+		log.Errorf("load assignment %d failed: %v", id, err)
+		c.JSON(500, response.Error(500, "读取作业失败"))
+		return nil, false
+	}
+	if !checkGroupOwner(c, u, assignment.GroupID) {
+		return nil, false
+	}
+	return assignment, true
+}
+
+type UpdateAssignmentRequest struct {
+	ID          int64     `json:"id" binding:"required"`
+	Title       string    `json:"title" binding:"required,max=100"`
+	Description string    `json:"description" binding:"max=65535"`
+	OpenAt      time.Time `json:"open_at" binding:"required"`
+	DueAt       time.Time `json:"due_at" binding:"required"`
+}
+
+// UpdateAssignment 修改作业的标题、说明和时间，作业内容不能修改
+func UpdateAssignment(c *gin.Context) {
+	u := user.CurrentUser(c)
+	var req UpdateAssignmentRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(400, response.Error(400, "参数验证失败"))
+		return
+	}
+	if !req.DueAt.After(req.OpenAt) {
+		c.JSON(400, response.Error(400, "截止时间必须晚于开放时间"))
+		return
+	}
+	assignment, ok := loadOwnAssignment(c, u, req.ID)
+	if !ok {
+		return
+	}
+	assignment.Title = req.Title
+	assignment.Description = req.Description
+	assignment.OpenAt = req.OpenAt
+	assignment.DueAt = req.DueAt
+	if err := repository.UpdateAssignment(assignment); err != nil {
+		log.Errorf("update assignment %d failed: %v", req.ID, err)
+		c.JSON(500, response.Error(500, "修改作业失败"))
+		return
+	}
+	c.JSON(200, response.Success(AssignmentView{Assignment: *assignment, PointIDs: decodePoints(assignment)}))
+}
+
+// DeleteAssignment 删除作业
+func DeleteAssignment(c *gin.Context) {
+	u := user.CurrentUser(c)
+	id, err := strconv.ParseInt(c.Query("id"), 10, 64)
+	if err != nil {
+		c.JSON(400, response.Error(400, "无效的作业ID"))
+		return
+	}
+	if _, ok := loadOwnAssignment(c, u, id); !ok {
+		return
+	}
+	if err := repository.DeleteAssignment(id); err != nil {
+		log.Errorf("delete assignment %d failed: %v", id, err)
+		c.JSON(500, response.Error(500, "删除作业失败"))
+		return
+	}
+	c.JSON(200, response.Success(nil))
+}
+
+// completions 计算多名学生在一个作业上的完成情况
+func completions(a *models.Assignment, userIds []string) (map[string]completion, error) {
+	result := make(map[string]completion, len(userIds))
+	if a.Kind == models.AssignmentQuiz {
+		submissions, err := repository.GetFirstQuizSubmissions(a.TargetID, userIds, a.OpenAt)
+		if err != nil {
+			return nil, err
+		}
+		for _, id := range userIds {
+			var sub *models.QuizSubmission
+			if s, ok := submissions[id]; ok {
+				sub = &s
+			}
+			result[id] = quizCompletion(sub)
+		}
+		return result, nil
+	}
+	points := decodePoints(a)
+	progress, err := repository.GetUsersProgress(userIds, points)
+	if err != nil {
+		return nil, err
+	}
+	for _, id := range userIds {
+		result[id] = pointsCompletion(points, progress[id], a.OpenAt)
+	}
+	return result, nil
+}
+
+// MyAssignments 当前学生所在组已开放的作业及自己的完成状态
+func MyAssignments(c *gin.Context) {
+	u := user.CurrentUser(c)
+	groups, err := user.GetUserGroupIds(u.Id)
+	if err != nil {
+		log.Errorf("load groups of user %s failed: %v", u.Id, err)
+		c.JSON(500, response.Error(500, "读取学生组失败"))
+		return
+	}
+	now := time.Now()
+	assignments, err := repository.ListAssignments(groups, now)
+	if err != nil {
+		log.Errorf("list assignments failed: %v", err)
+		c.JSON(500, response.Error(500, "读取作业失败"))
+		return
+	}
+	views := make([]AssignmentView, 0, len(assignments))
+	for i := range assignments {
+		a := &assignments[i]
+		done, err := completions(a, []string{u.Id})
+		if err != nil {
+			log.Errorf("load completion of assignment %d failed: %v", a.ID, err)
+			c.JSON(500, response.Error(500, "读取作业完成情况失败"))
+			return
+		}
+		status := studentStatus(u.Id, done[u.Id], a.DueAt, now)
+		views = append(views, AssignmentView{Assignment: *a, PointIDs: decodePoints(a), Status: &status})
+	}
+	c.JSON(200, response.Success(views))
+}
+
+// ListGroupAssignments 列出学生组的作业，组的创建者和拥有 user.manage 权限的账号可以看到尚未开放的作业
+func ListGroupAssignments(c *gin.Context) {
+	u := user.CurrentUser(c)
+	groupId, err := strconv.ParseInt(c.Query("group_id"), 10, 64)
+	if err != nil {
+		c.JSON(400, response.Error(400, "无效的学生组ID"))
+		return
+	}
+	isAdmin := user.HasPermission(u.UserType, user.UserManage)
+	if !isAdmin && !user.IsGroupOwnerOrMember(u, int(groupId), c) {
+		return
+	}
+	openBefore := time.Now()
+	if isAdmin {
+		openBefore = time.Time{}
+	} else if group, err := user.GetGroupDB(int(groupId)); err == nil && group.Owner.User_id == u.Id {
+		openBefore = time.Time{}
+	}
+	assignments, err := repository.ListAssignments([]int64{groupId}, openBefore)
+	if err != nil {
+		log.Errorf("list assignments of group %d failed: %v", groupId, err)
+		c.JSON(500, response.Error(500, "读取作业失败"))
+		return
+	}
+	views := make([]AssignmentView, len(assignments))
+	for i := range assignments {
+		views[i] = AssignmentView{Assignment: assignments[i], PointIDs: decodePoints(&assignments[i])}
+	}
+	c.JSON(200, response.Success(views))
+}
+
+// AssignmentOverview 教师查看组内每名学生的完成状态以及各状态的人数
+func AssignmentOverview(c *gin.Context) {
+	u := user.CurrentUser(c)
+	id, err := strconv.ParseInt(c.Query("id"), 10, 64)
+	if err != nil {
+		c.JSON(400, response.Error(400, "无效的作业ID"))
+		return
+	}
+	assignment, ok := loadOwnAssignment(c, u, id)
+	if !ok {
+		return
+	}
+	group, err := user.GetGroupDB(int(assignment.GroupID))
+	if err != nil {
+		log.Errorf("load group %d failed: %v", assignment.GroupID, err)
+		c.JSON(500, response.Error(500, "读取学生组失败"))
+		return
+	}
+	userIds := make([]string, len(group.Users))
+	for i, member := range group.Users {
+		userIds[i] = member.User_id
+	}
+	done, err := completions(assignment, userIds)
+	if err != nil {
+		log.Errorf("load completion of assignment %d failed: %v", id, err)
+		c.JSON(500, response.Error(500, "读取作业完成情况失败"))
+		return
+	}
+	now := time.Now()
+	students := make([]StudentStatus, len(group.Users))
+	for i, member := range group.Users {
+		students[i] = studentStatus(member.User_id, done[member.User_id], assignment.DueAt, now)
+		students[i].Nickname = member.Nickname
+	}
+	c.JSON(200, response.Success(map[string]interface{}{
+		"assignment": AssignmentView{Assignment: *assignment, PointIDs: decodePoints(assignment)},
+		"counts":     countStatuses(students),
+		"students":   students,
+	}))
+}
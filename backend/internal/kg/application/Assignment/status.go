@@ -0,0 +1,122 @@
+package assignment
+
+import (
+	"time"
+
+	"github.com/RMS_V3/internal/kg/repository/models"
+)
+
+// 学生在作业上的完成状态
+const (
+	StatusNotStarted = "not_started"
+	StatusInProgress = "in_progress"
+	StatusCompleted  = "completed" // 截止前完成
+	StatusLate       = "late"      // 截止后才完成
+	StatusOverdue    = "overdue"   // 已截止仍未完成
+)
+
+// completion 学生在作业上的完成情况
+type completion struct {
+	Total     int
+	Completed int
+	Started   bool
+	// FinishedAt 全部完成的时间，未全部完成时为空
+	FinishedAt *time.Time
+	// Score 测验作业第一次提交的得分率
+	Score *float64
+}
+
+// pointsCompletion 根据知识点学习进度统计完成情况，最后一个知识点的完成时间即作业的完成时间。
+// 只统计作业开放之后的学习，开放前已完成的知识点不计入
+func pointsCompletion(pointIds []int64, progress map[int64]models.LearnerProgress, openAt time.Time) completion {
+	c := completion{Total: len(pointIds)}
+	var last time.Time
+	for _, id := range pointIds {
+		p, ok := progress[id]
+		if !ok || p.Status == models.ProgressNotStarted || p.LastActivityAt.Before(openAt) {
+			continue
+		}
+		c.Started = true
+		if p.Status != models.ProgressCompleted || p.CompletedAt == nil || p.CompletedAt.Before(openAt) {
+			continue
+		}
+		c.Completed++
+		if p.CompletedAt.After(last) {
+			last = *p.CompletedAt
+		}
+	}
+	if c.Total > 0 && c.Completed == c.Total {
+		c.FinishedAt = &last
+	}
+	return c
+}
+
+// quizCompletion 测验作业以开放后的第一次提交为准
+func quizCompletion(submission *models.QuizSubmission) completion {
+	c := completion{Total: 1}
+	if submission == nil {
+		return c
+	}
+	c.Started = true
+	c.Completed = 1
+	at := submission.CreatedAt
+	c.FinishedAt = &at
+	if submission.MaxScore > 0 {
+		score := submission.Score / submission.MaxScore
+		c.Score = &score
+	}
+	return c
+}
+
+// assignmentStatus 根据完成情况和截止时间判断状态
+func assignmentStatus(c completion, due time.Time, now time.Time) string {
+	switch {
+	case c.FinishedAt != nil && c.FinishedAt.After(due):
+		return StatusLate
+	case c.FinishedAt != nil:
+		return StatusCompleted
+	case now.After(due):
+		return StatusOverdue
+	case c.Started:
+		return StatusInProgress
+	default:
+		return StatusNotStarted
+	}
+}
+
+// StudentStatus 一名学生在作业上的完成状态
+type StudentStatus struct {
+	UserID     string     `json:"user_id"`
+	Nickname   string     `json:"nickname,omitempty"`
+	Status     string     `json:"status"`
+	Completed  int        `json:"completed"`
+	Total      int        `json:"total"`
+	FinishedAt *time.Time `json:"finished_at,omitempty"`
+	Score      *float64   `json:"score,omitempty"`
+}
+
+func studentStatus(userId string, c completion, due time.Time, now time.Time) StudentStatus {
+	return StudentStatus{
+		UserID:     userId,
+		Status:     assignmentStatus(c, due, now),
+		Completed:  c.Completed,
+		Total:      c.Total,
+		FinishedAt: c.FinishedAt,
+		Score:      c.Score,
+	}
+}
+
+// countStatuses 统计各状态的学生人数，没有学生的状态计为 0
+func countStatuses(students []StudentStatus) map[string]int {
+	counts := map[string]int{
+		StatusNotStarted: 0,
+		StatusInProgress: 0,
+		StatusCompleted:  0,
+		StatusLate:       0,
+		StatusOverdue:    0,
+	}
+	for _, s := range students {
+		counts[s.Status]++
+	}
+	return counts
+}
@@ -0,0 +1,68 @@
+package assignment
+
+import (
+	"testing"
+	"time"
+
+	"github.com/RMS_V3/internal/kg/repository/models"
+)
+
+func TestPointsCompletion(t *testing.T) {
+	t1 := time.Date(2026, 10, 1, 9, 0, 0, 0, time.UTC)
+	t2 := t1.Add(48 * time.Hour)
+	open := t1.Add(-time.Hour)
+	progress := map[int64]models.LearnerProgress{
+		1: {Status: models.ProgressCompleted, CompletedAt: &t2, LastActivityAt: t2},
+		2: {Status: models.ProgressCompleted, CompletedAt: &t1, LastActivityAt: t1},
+		3: {Status: models.ProgressInProgress, LastActivityAt: t1},
+		9: {Status: models.ProgressCompleted, CompletedAt: &t1, LastActivityAt: t1},
+	}
+	c := pointsCompletion([]int64{1, 2, 3}, progress, open)
+	if c.Total != 3 || c.Completed != 2 || !c.Started || c.FinishedAt != nil {
+		t.Errorf("completion = %+v, want 2 of 3 started and unfinished", c)
+	}
+	c = pointsCompletion([]int64{1, 2}, progress, open)
+	if c.FinishedAt == nil || !c.FinishedAt.Equal(t2) {
+		t.Errorf("finished at %v, want the last completion %v", c.FinishedAt, t2)
+	}
+	if c := pointsCompletion([]int64{4}, progress, open); c.Started || c.Completed != 0 {
+		t.Errorf("completion = %+v, want not started", c)
+	}
+	// 作业开放前完成的知识点不计入，开放后仍有学习的算作已开始
+	c = pointsCompletion([]int64{1, 2}, progress, t1.Add(time.Hour))
+	if c.Completed != 1 || !c.Started || c.FinishedAt != nil {
+		t.Errorf("completion = %+v, want only the point completed after opening", c)
+	}
+	if c := pointsCompletion([]int64{2}, progress, t2); c.Started || c.Completed != 0 {
+		t.Errorf("completion = %+v, want not started after opening", c)
+	}
+}
+
+func TestAssignmentStatus(t *testing.T) {
+	due := time.Date(2026, 10, 10, 23, 59, 0, 0, time.UTC)
+	before, after := due.Add(-time.Hour), due.Add(time.Hour)
+	cases := []struct {
+		name string
+		c    completion
+		now  time.Time
+		want string
+	}{
+		{"not started", completion{Total: 2}, before, StatusNotStarted},
+		{"in progress", completion{Total: 2, Started: true}, before, StatusInProgress},
+		{"overdue", completion{Total: 2, Started: true}, after, StatusOverdue},
+		{"completed on time", completion{Total: 1, Completed: 1, FinishedAt: &before}, after, StatusCompleted},
+		{"completed late", completion{Total: 1, Completed: 1, FinishedAt: &after}, after, StatusLate},
+		{"quiz submitted", quizCompletion(&models.QuizSubmission{Score: 3, MaxScore: 4, CreatedAt: before}), after, StatusCompleted},
+		{"quiz missing", quizCompletion(nil), after, StatusOverdue},
+	}
+	for _, tc := range cases {
+		if got := assignmentStatus(tc.c, due, tc.now); got != tc.want {
+			t.Errorf("%s: status = %s, want %s", tc.name, got, tc.want)
+		}
+	}
+	students := []StudentStatus{{Status: StatusLate}, {Status: StatusLate}, {Status: StatusCompleted}}
+	counts := countStatuses(students)
+	if counts[StatusLate] != 2 || counts[StatusCompleted] != 1 || counts[StatusNotStarted] != 0 {
+		t.Errorf("counts = %v", counts)
+	}
+}
@@ -0,0 +1,228 @@
+package exercise
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/RMS_V3/internal/kg/repository"
+	"github.com/RMS_V3/internal/kg/repository/models"
+	"github.com/RMS_V3/internal/user"
+	"github.com/RMS_V3/log"
+	"github.com/RMS_V3/pkg/response"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+const (
+	defaultPageSize = 20
+	maxPageSize     = 100
+)
+
+// normalizeTags 去掉标签首尾空白、空标签和重复标签
+func normalizeTags(tags []string) []string {
+	seen := make(map[string]bool, len(tags))
+	result := make([]string, 0, len(tags))
+	for _, tag := range tags {
+		tag = strings.TrimSpace(tag)
+		if tag == "" || seen[tag] {
+			continue
+		}
+		seen[tag] = true
+		result = append(result, tag)
+	}
+	return result
+}
+
+// parsePage 解析分页参数 page 和 page_size
+func parsePage(c *gin.Context) (int, int, error) {
+	page, pageSize := 1, defaultPageSize
+	if raw := c.Query("page"); raw != "" {
+		p, err := strconv.Atoi(raw)
+		if err != nil || p < 1 {
+			return 0, 0, errors.New("page 必须是正整数")
+		}
+		page = p
+	}
+	if raw := c.Query("page_size"); raw != "" {
+		size, err := strconv.Atoi(raw)
+		if err != nil || size < 1 || size > maxPageSize {
+			return 0, 0, fmt.Errorf("page_size 必须在 1 到 %d 之间", maxPageSize)
+		}
+		pageSize = size
+	}
+	return page, pageSize, nil
+}
+
+// SearchItems 教师检索题库，支持关键字、题型、难度、布鲁姆层次、作者、知识点和标签筛选以及分页；
+// tags 为逗号分隔的标签，需要全部命中
+func SearchItems(c *gin.Context) {
+	page, pageSize, err := parsePage(c)
+	if err != nil {
+		c.JSON(400, response.Error(400, err.Error()))
+		return
+	}
+	filter := repository.ItemFilter{
+		Keyword:    strings.TrimSpace(c.Query("q")),
+		Type:       c.Query("type"),
+		Difficulty: c.Query("difficulty"),
+		BloomLevel: c.Query("bloom_level"),
+		Author:     c.Query("author"),
+		Tags:       normalizeTags(strings.Split(c.Query("tags"), ",")),
+	}
+	if raw := c.Query("point_id"); raw != "" {
+		filter.PointID, err = strconv.ParseInt(raw, 10, 64)
+		if err != nil {
+			c.JSON(400, response.Error(400, "无效的知识点ID"))
+			return
+		}
+	}
+	items, total, err := repository.SearchExerciseItems(filter, page, pageSize)
+	if err != nil {
+		log.Errorf("search exercise items failed: %v", err)
+		c.JSON(500, response.Error(500, "检索题库失败"))
+		return
+	}
+	views, err := itemViews(items, true)
+	if err != nil {
+		log.Errorf("load exercise items failed: %v", err)
+		c.JSON(500, response.Error(500, "检索题库失败"))
+		return
+	}
+	c.JSON(200, response.Success(map[string]interface{}{
+		"total":     total,
+		"page":      page,
+		"page_size": pageSize,
+		"items":     views,
+	}))
+}
+
+// VersionView 习题的一个历史版本
+type VersionView struct {
+	models.ExerciseItemVersion
+	Options []string `json:"options,omitempty"`
+	Answer  *Answer  `json:"answer,omitempty"`
+}
+
+func versionView(v *models.ExerciseItemVersion) (VersionView, error) {
+	options, answer, err := decodeItem(&models.ExerciseItem{ID: v.ItemID, Options: v.Options, Answer: v.Answer})
+	if err != nil {
+		return VersionView{}, err
+	}
+	return VersionView{ExerciseItemVersion: *v, Options: options, Answer: &answer}, nil
+}
+
+// ListItemVersions 列出习题的修改历史
+func ListItemVersions(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Query("id"), 10, 64)
+	if err != nil {
+		c.JSON(400, response.Error(400, "无效的习题ID"))
+		return
+	}
+	versions, err := repository.ListExerciseItemVersions(id)
+	if err != nil {
+		log.Errorf("list exercise item versions failed: %v", err)
+		c.JSON(500, response.Error(500, "读取修改历史失败"))
+		return
+	}
+	views := make([]VersionView, 0, len(versions))
+	for i := range versions {
+		view, err := versionView(&versions[i])
+		if err != nil {
+			log.Errorf("decode exercise item version failed: %v", err)
+			continue
+		}
+		views = append(views, view)
+	}
+	c.JSON(200, response.Success(views))
+}
+
+type LinkPointsRequest struct {
+	ID       int64   `json:"id" binding:"required"`
+	PointIDs []int64 `json:"point_ids" binding:"required,min=1"`
+}
+
+// LinkItemPoints 把题库中的习题关联到更多知识点，代替为每个知识点重复上传同一道题
+func LinkItemPoints(c *gin.Context) {
+	var req LinkPointsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(400, response.Error(400, "参数验证失败"))
+		return
+	}
+	if _, ok := loadItem(c, req.ID); !ok {
+		return
+	}
+	missing, err := missingPoints(req.PointIDs)
+	if err != nil {
+		log.Errorf("check exercise points failed: %v", err)
+		c.JSON(500, response.Error(500, "校验知识点失败"))
+		return
+	}
+	if len(missing) > 0 {
+		c.JSON(400, response.Error(400, fmt.Sprintf("知识点不存在: %v", missing)))
+		return
+	}
+	if err := repository.LinkItemPoints(req.ID, req.PointIDs); err != nil {
+		log.Errorf("link exercise item %d failed: %v", req.ID, err)
+		c.JSON(500, response.Error(500, "关联知识点失败"))
+		return
+	}
+	c.JSON(200, response.Success(nil))
+}
+
+// UnlinkItemPoints 取消习题与知识点的关联，习题本身保留在题库中
+func UnlinkItemPoints(c *gin.Context) {
+	var req LinkPointsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(400, response.Error(400, "参数验证失败"))
+		return
+	}
+	if err := repository.UnlinkItemPoints(req.ID, req.PointIDs); err != nil {
+		log.Errorf("unlink exercise item %d failed: %v", req.ID, err)
+		c.JSON(500, response.Error(500, "取消关联失败"))
+		return
+	}
+	c.JSON(200, response.Success(nil))
+}
+
+// GetAttempt 查看一次作答，题目和答案取作答时的版本；学生只能查看自己的作答，
+// 教师只能查看自己学生组中学生的作答
+func GetAttempt(c *gin.Context) {
+	u := user.CurrentUser(c)
+	id, err := strconv.ParseInt(c.Query("id"), 10, 64)
+	if err != nil {
+		c.JSON(400, response.Error(400, "无效的作答ID"))
+		return
+	}
+	attempt, err := repository.GetExerciseAttempt(id)
+	if errors.Is(err, gorm.ErrRecordNotFound) || (err == nil && attempt.UserID != u.Id && !user.HasPermission(u.UserType, user.GroupManage)) {
+		c.JSON(404, response.Error(404, "作答记录不存在"))
+		return
+	}
+	if err != nil {
+		log.Errorf("load exercise attempt %d failed: %v", id, err)
+		c.JSON(500, response.Error(500, "读取作答记录失败"))
+		return
+	}
+	if attempt.UserID != u.Id && !user.HasPermission(u.UserType, user.UserManage) && !user.IsStudentOwner(u, attempt.UserID, c) {
+		return
+	}
+	version, err := repository.GetExerciseItemVersion(attempt.ItemID, attempt.ItemVersion)
+	if err != nil {
+		log.Errorf("load version %d of exercise item %d failed: %v", attempt.ItemVersion, attempt.ItemID, err)
+		c.JSON(500, response.Error(500, "读取作答时的习题版本失败"))
+		return
+	}
+	item, err := versionView(version)
+	if err != nil {
+		c.JSON(500, response.Error(500, err.Error()))
+		return
+	}
+	c.JSON(200, response.Success(map[string]interface{}{
+		"attempt":  attempt,
+		"response": json.RawMessage(attempt.Response),
+		"item":     item,
+	}))
+}
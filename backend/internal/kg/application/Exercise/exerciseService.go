@@ -0,0 +1,443 @@
+package exercise
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+
+	mastery "github.com/RMS_V3/internal/kg/application/Mastery"
+	progress "github.com/RMS_V3/internal/kg/application/Progress"
+	"github.com/RMS_V3/internal/kg/repository"
+	"github.com/RMS_V3/internal/kg/repository/models"
+	"github.com/RMS_V3/internal/user"
+	"github.com/RMS_V3/log"
+	"github.com/RMS_V3/middleware/neo4jUtils"
+	"github.com/RMS_V3/pkg/response"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// ItemView 返回给前端的习题，Answer 只对拥有 resource.upload 权限的账号返回
+type ItemView struct {
+	models.ExerciseItem
+	Options  []string `json:"options,omitempty"`
+	PointIDs []int64  `json:"point_ids"`
+	Tags     []string `json:"tags"`
+	Answer   *Answer  `json:"answer,omitempty"`
+}
+
+func decodeItem(item *models.ExerciseItem) ([]string, Answer, error) {
+	var options []string
+	var answer Answer
+	if item.Options != "" {
+		if err := json.Unmarshal([]byte(item.Options), &options); err != nil {
+			return nil, answer, fmt.Errorf("习题 %d 的选项损坏: %s", item.ID, err.Error())
+		}
+	}
+	if err := json.Unmarshal([]byte(item.Answer), &answer); err != nil {
+		return nil, answer, fmt.Errorf("习题 %d 的答案损坏: %s", item.ID, err.Error())
+	}
+	return options, answer, nil
+}
+
+func itemView(item *models.ExerciseItem, pointIds []int64, tags []string, withAnswer bool) (ItemView, error) {
+	options, answer, err := decodeItem(item)
+	if err != nil {
+		return ItemView{}, err
+	}
+	view := ItemView{ExerciseItem: *item, Options: options, PointIDs: pointIds, Tags: tags}
+	if view.PointIDs == nil {
+		view.PointIDs = []int64{}
+	}
+	if view.Tags == nil {
+		view.Tags = []string{}
+	}
+	if withAnswer {
+		view.Answer = &answer
+	} else {
+		// 作答前不返回解析，避免泄露答案
+		view.Explanation = ""
+	}
+	return view, nil
+}
+
+// itemViews 批量读取习题关联的知识点和标签，生成返回给前端的习题，损坏的习题被跳过
+func itemViews(items []models.ExerciseItem, withAnswer bool) ([]ItemView, error) {
+	ids := make([]int64, len(items))
+	for i, item := range items {
+		ids[i] = item.ID
+	}
+	points, err := repository.GetItemPoints(ids)
+	if err != nil {
+		return nil, err
+	}
+	tags, err := repository.GetItemTags(ids)
+	if err != nil {
+		return nil, err
+	}
+	views := make([]ItemView, 0, len(items))
+	for i := range items {
+		view, err := itemView(&items[i], points[items[i].ID], tags[items[i].ID], withAnswer)
+		if err != nil {
+			log.Errorf("decode exercise item failed: %v", err)
+			continue
+		}
+		views = append(views, view)
+	}
+	return views, nil
+}
+
+// missingPoints 返回 ids 中在图谱里不存在的知识点
+func missingPoints(ids []int64) ([]int64, error) {
+	session := neo4jUtils.GetSession()
+	if session == nil {
+		return nil, fmt.Errorf("无法获取 Neo4j 会话")
+	}
+	defer session.Close()
+	result, err := session.Run(`
+	MATCH (p:point)
+	WHERE id(p) IN $ids
+	RETURN id(p) AS id`, map[string]interface{}{"ids": ids})
+	if err != nil {
+		return nil, err
+	}
+	found := make(map[int64]bool)
+	for result.Next() {
+		id, _ := result.Record().Get("id")
+		if pid, ok := id.(int64); ok {
+			found[pid] = true
+		}
+	}
+	if err := result.Err(); err != nil {
+		return nil, err
+	}
+	var missing []int64
+	for _, id := range ids {
+		if !found[id] {
+			missing = append(missing, id)
+		}
+	}
+	return missing, nil
+}
+
+type ItemRequest struct {
+	Type        string   `json:"type" binding:"required,oneof=single_choice multiple_choice true_false fill_blank numeric"`
+	Stem        string   `json:"stem" binding:"required,max=65535"`
+	Options     []string `json:"options"`
+	Answer      Answer   `json:"answer"`
+	Explanation string   `json:"explanation" binding:"max=65535"`
+	Difficulty  string   `json:"difficulty" binding:"omitempty,oneof=easy medium hard"`
+	BloomLevel  string   `json:"bloom_level" binding:"omitempty,oneof=remember understand apply analyze evaluate create"`
+	PointIDs    []int64  `json:"point_ids" binding:"required,min=1"`
+	Tags        []string `json:"tags" binding:"max=20,dive,max=32"`
+}
+
+// buildItem 校验请求并生成习题，失败时已写入响应
+func buildItem(c *gin.Context, req *ItemRequest) (*models.ExerciseItem, bool) {
+	if err := validateItem(req.Type, req.Options, req.Answer); err != nil {
+		c.JSON(400, response.Error(400, err.Error()))
+		return nil, false
+	}
+	missing, err := missingPoints(req.PointIDs)
+	if err != nil {
+		log.Errorf("check exercise points failed: %v", err)
+		c.JSON(500, response.Error(500, "校验知识点失败"))
+		return nil, false
+	}
+	if len(missing) > 0 {
+		c.JSON(400, response.Error(400, fmt.Sprintf("知识点不存在: %v", missing)))
+		return nil, false
+	}
+	item := &models.ExerciseItem{
+		Type:        req.Type,
+		Stem:        req.Stem,
+		Explanation: req.Explanation,
+		Difficulty:  req.Difficulty,
+		BloomLevel:  req.BloomLevel,
+	}
+	if item.Difficulty == "" {
+		item.Difficulty = "medium"
+	}
+	if item.BloomLevel == "" {
+		item.BloomLevel = "understand"
+	}
+	req.Tags = normalizeTags(req.Tags)
+	// 只保存题型用到的字段
+	answer := req.Answer
+	switch req.Type {
+	case models.ItemSingleChoice, models.ItemMultipleChoice:
+		options, _ := json.Marshal(req.Options)
+		item.Options = string(options)
+		answer = Answer{Choices: req.Answer.Choices}
+	case models.ItemTrueFalse:
+		answer = Answer{Truth: req.Answer.Truth}
+	case models.ItemFillBlank:
+		answer = Answer{Blanks: req.Answer.Blanks}
+	case models.ItemNumeric:
+		answer = Answer{Value: req.Answer.Value, Tolerance: req.Answer.Tolerance}
+	}
+	encoded, _ := json.Marshal(answer)
+	item.Answer = string(encoded)
+	return item, true
+}
+
+// CreateItem 教师创建结构化习题
+func CreateItem(c *gin.Context) {
+	u := user.CurrentUser(c)
+	var req ItemRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(400, response.Error(400, "参数验证失败"))
+		return
+	}
+	item, ok := buildItem(c, &req)
+	if !ok {
+		return
+	}
+	item.CreatedBy = u.Id
+	if err := repository.CreateExerciseItem(item, req.PointIDs, req.Tags); err != nil {
+		log.Errorf("create exercise item failed: %v", err)
+		c.JSON(500, response.Error(500, "创建习题失败"))
+		return
+	}
+	view, _ := itemView(item, req.PointIDs, req.Tags, true)
+	c.JSON(200, response.Success(view))
+}
+
+// loadOwnItem 读取习题并确认当前用户是创建人或拥有 user.manage 权限，失败时已写入响应
+func loadOwnItem(c *gin.Context, u *user.User, id int64) (*models.ExerciseItem, bool) {
+	item, ok := loadItem(c, id)
+	if !ok {
+		return nil, false
+	}
+	if item.CreatedBy != u.Id && !user.HasPermission(u.UserType, user.UserManage) {
+		c.JSON(403, response.Error(403, "只能修改自己创建的习题"))
+		return nil, false
+	}
+	return item, true
+}
+
+func loadItem(c *gin.Context, id int64) (*models.ExerciseItem, bool) {
+	item, err := repository.GetExerciseItem(id)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		c.JSON(404, response.Error(404, "习题不存在"))
+		return nil, false
+	}
+	if err != nil {
+		log.Errorf("load exercise item %d failed: %v", id, err)
+		c.JSON(500, response.Error(500, "读取习题失败"))
+		return nil, false
+	}
+	return item, true
+}
+
+type UpdateItemRequest struct {
+	ID int64 `json:"id" binding:"required"`
+	ItemRequest
+}
+
+// UpdateItem 修改习题，只有创建人和管理员可以修改；修改保存为新版本，已有作答记录仍对应原版本
+func UpdateItem(c *gin.Context) {
+	u := user.CurrentUser(c)
+	var req UpdateItemRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(400, response.Error(400, "参数验证失败"))
+		return
+	}
+	existing, ok := loadOwnItem(c, u, req.ID)
+	if !ok {
+		return
+	}
+	item, ok := buildItem(c, &req.ItemRequest)
+	if !ok {
+		return
+	}
+	item.ID = existing.ID
+	item.CreatedBy = existing.CreatedBy
+	item.CreatedAt = existing.CreatedAt
+	if err := repository.UpdateExerciseItem(item, req.PointIDs, req.Tags, u.Id); err != nil {
+		log.Errorf("update exercise item %d failed: %v", req.ID, err)
+		c.JSON(500, response.Error(500, "修改习题失败"))
+		return
+	}
+	view, _ := itemView(item, req.PointIDs, req.Tags, true)
+	c.JSON(200, response.Success(view))
+}
+
+type DeleteItemRequest struct {
+	ID int64 `json:"id" binding:"required"`
+}
+
+// DeleteItem 删除习题，已有的作答记录保留
+func DeleteItem(c *gin.Context) {
+	u := user.CurrentUser(c)
+	var req DeleteItemRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(400, response.Error(400, "参数验证失败"))
+		return
+	}
+	if _, ok := loadOwnItem(c, u, req.ID); !ok {
+		return
+	}
+	if err := repository.DeleteExerciseItem(req.ID); err != nil {
+		log.Errorf("delete exercise item %d failed: %v", req.ID, err)
+		c.JSON(500, response.Error(500, "删除习题失败"))
+		return
+	}
+	c.JSON(200, response.Success(nil))
+}
+
+// GetItem 查询一道习题，学生看不到答案和解析
+func GetItem(c *gin.Context) {
+	u := user.CurrentUser(c)
+	id, err := strconv.ParseInt(c.Query("id"), 10, 64)
+	if err != nil {
+		c.JSON(400, response.Error(400, "无效的习题ID"))
+		return
+	}
+	item, ok := loadItem(c, id)
+	if !ok {
+		return
+	}
+	views, err := itemViews([]models.ExerciseItem{*item}, user.HasPermission(u.UserType, user.ResourceUpload))
+	if err != nil {
+		log.Errorf("load exercise item %d failed: %v", id, err)
+		c.JSON(500, response.Error(500, "读取习题失败"))
+		return
+	}
+	c.JSON(200, response.Success(views[0]))
+}
+
+// ListItems 列出知识点的习题，学生看不到答案和解析
+func ListItems(c *gin.Context) {
+	u := user.CurrentUser(c)
+	pointId, err := strconv.ParseInt(c.Query("point_id"), 10, 64)
+	if err != nil {
+		c.JSON(400, response.Error(400, "无效的知识点ID"))
+		return
+	}
+	items, err := repository.ListExerciseItemsByPoint(pointId)
+	if err != nil {
+		log.Errorf("list exercise items failed: %v", err)
+		c.JSON(500, response.Error(500, "读取习题失败"))
+		return
+	}
+	views, err := itemViews(items, user.HasPermission(u.UserType, user.ResourceUpload))
+	if err != nil {
+		log.Errorf("load exercise items failed: %v", err)
+		c.JSON(500, response.Error(500, "读取习题失败"))
+		return
+	}
+	c.JSON(200, response.Success(views))
+}
+
+type SubmitRequest struct {
+	ItemID   int64    `json:"item_id" binding:"required"`
+	Response Response `json:"response"`
+}
+
+// SubmitAnswer 学生提交作答，自动判分并记录作答。每个版本的第一次作答更新关联知识点的掌握度和学习进度，
+// 之后的作答只用于练习；答案和解析在掌握度记录了这道题的作答之后才返回
+func SubmitAnswer(c *gin.Context) {
+	u := user.CurrentUser(c)
+	var req SubmitRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(400, response.Error(400, "参数验证失败"))
+		return
+	}
+	item, ok := loadItem(c, req.ItemID)
+	if !ok {
+		return
+	}
+	_, answer, err := decodeItem(item)
+	if err != nil {
+		c.JSON(500, response.Error(500, err.Error()))
+		return
+	}
+	correct, score, err := grade(item.Type, answer, req.Response)
+	if err != nil {
+		c.JSON(400, response.Error(400, err.Error()))
+		return
+	}
+	encoded, _ := json.Marshal(req.Response)
+	attempt := &models.ExerciseAttempt{
+		UserID:      u.Id,
+		ItemID:      item.ID,
+		ItemVersion: item.Version,
+		Response:    string(encoded),
+		Correct:     correct,
+		Score:       score,
+	}
+	if err := repository.CreateExerciseAttempt(attempt); err != nil {
+		log.Errorf("save exercise attempt failed: %v", err)
+		c.JSON(500, response.Error(500, "保存作答记录失败"))
+		return
+	}
+
+	// 非计分作答说明之前已经计分作答过，掌握度中已有这道题的观测
+	observed := true
+	masteryUpdates := make(map[int64]float64)
+	if attempt.Scored {
+		points, err := repository.GetItemPoints([]int64{item.ID})
+		if err != nil {
+			log.Errorf("load exercise item points failed: %v", err)
+			observed = false
+		}
+		for _, pointId := range points[item.ID] {
+			updated, err := mastery.Observe(u.Id, pointId, correct)
+			if err != nil {
+				log.Errorf("update mastery of point %d failed: %v", pointId, err)
+				observed = false
+				continue
+			}
+			for id, m := range updated {
+				masteryUpdates[id] = m
+			}
+			if err := progress.RecordView(u.Id, pointId); err != nil {
+				log.Errorf("update progress of point %d failed: %v", pointId, err)
+			}
+		}
+	}
+
+	result := map[string]interface{}{
+		"attempt_id": attempt.ID,
+		"correct":    correct,
+		"score":      score,
+		"scored":     attempt.Scored,
+		"mastery":    masteryUpdates,
+	}
+	if observed {
+		result["answer"] = answer
+		result["explanation"] = item.Explanation
+	}
+	c.JSON(200, response.Success(result))
+}
+
+// ListAttempts 当前学生的作答记录，可以通过 item_id 筛选
+func ListAttempts(c *gin.Context) {
+	u := user.CurrentUser(c)
+	var itemId int64
+	if raw := c.Query("item_id"); raw != "" {
+		var err error
+		itemId, err = strconv.ParseInt(raw, 10, 64)
+		if err != nil {
+			c.JSON(400, response.Error(400, "无效的习题ID"))
+			return
+		}
+	}
+	attempts, err := repository.ListExerciseAttempts(u.Id, itemId)
+	if err != nil {
+		log.Errorf("list exercise attempts failed: %v", err)
+		c.JSON(500, response.Error(500, "读取作答记录失败"))
+		return
+	}
+	type attemptView struct {
+		models.ExerciseAttempt
+		Response json.RawMessage `json:"response"`
+	}
+	views := make([]attemptView, len(attempts))
+	for i, a := range attempts {
+		views[i] = attemptView{ExerciseAttempt: a, Response: json.RawMessage(a.Response)}
+	}
+	c.JSON(200, response.Success(views))
+}
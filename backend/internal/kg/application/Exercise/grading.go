@@ -0,0 +1,182 @@
+package exercise
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+	"unicode"
+
+	"github.com/RMS_V3/internal/kg/repository/models"
+)
+
+// Answer 习题的标准答案，按题型使用不同字段
+type Answer struct {
+	// Choices 单选、多选题正确选项的下标，从 0 开始
+	Choices []int `json:"choices,omitempty"`
+	// Truth 判断题的答案
+	Truth *bool `json:"truth,omitempty"`
+	// Blanks 填空题每个空可接受的答案
+	Blanks [][]string `json:"blanks,omitempty"`
+	// Value、Tolerance 数值题的答案和允许的绝对误差
+	Value     *float64 `json:"value,omitempty"`
+	Tolerance float64  `json:"tolerance,omitempty"`
+}
+
+// Response 学生的作答内容，字段含义与 Answer 对应
+type Response struct {
+	Choices []int    `json:"choices,omitempty"`
+	Truth   *bool    `json:"truth,omitempty"`
+	Blanks  []string `json:"blanks,omitempty"`
+	Value   *float64 `json:"value,omitempty"`
+}
+
+// multipleChoicePartialScore 多选题少选且没有错选时的得分
+const multipleChoicePartialScore = 0.5
+
+// validateItem 检查题型、选项和答案是否匹配
+func validateItem(itemType string, options []string, a Answer) error {
+	switch itemType {
+	case models.ItemSingleChoice, models.ItemMultipleChoice:
+		if len(options) < 2 {
+			return errors.New("选择题至少需要两个选项")
+		}
+		for i, o := range options {
+			if strings.TrimSpace(o) == "" {
+				return fmt.Errorf("第 %d 个选项为空", i+1)
+			}
+		}
+		if itemType == models.ItemSingleChoice && len(a.Choices) != 1 {
+			return errors.New("单选题必须有且只有一个正确选项")
+		}
+		if len(a.Choices) == 0 {
+			return errors.New("多选题至少需要一个正确选项")
+		}
+		seen := make(map[int]bool)
+		for _, c := range a.Choices {
+			if c < 0 || c >= len(options) {
+				return fmt.Errorf("正确选项下标 %d 超出范围", c)
+			}
+			if seen[c] {
+				return fmt.Errorf("正确选项下标 %d 重复", c)
+			}
+			seen[c] = true
+		}
+	case models.ItemTrueFalse:
+		if a.Truth == nil {
+			return errors.New("判断题缺少答案")
+		}
+	case models.ItemFillBlank:
+		if len(a.Blanks) == 0 {
+			return errors.New("填空题至少需要一个空")
+		}
+		for i, accepted := range a.Blanks {
+			valid := 0
+			for _, s := range accepted {
+				if normalizeBlank(s) != "" {
+					valid++
+				}
+			}
+			if valid == 0 {
+				return fmt.Errorf("第 %d 个空没有可接受的答案", i+1)
+			}
+		}
+	case models.ItemNumeric:
+		if a.Value == nil || math.IsNaN(*a.Value) || math.IsInf(*a.Value, 0) {
+			return errors.New("数值题缺少有效的答案")
+		}
+		if a.Tolerance < 0 || math.IsNaN(a.Tolerance) {
+			return errors.New("允许误差不能为负数")
+		}
+	default:
+		return fmt.Errorf("不支持的题型: %s", itemType)
+	}
+	return nil
+}
+
+// normalizeBlank 填空题答案比较前的规范化：全角转半角、忽略大小写和多余空白
+func normalizeBlank(s string) string {
+	var b strings.Builder
+	for _, r := range s {
+		switch {
+		case r == '　':
+			r = ' '
+		case r >= '！' && r <= '～':
+			r -= 0xfee0
+		}
+		b.WriteRune(unicode.ToLower(r))
+	}
+	return strings.Join(strings.Fields(b.String()), " ")
+}
+
+// grade 判分，返回是否完全正确和 0-1 之间的得分；
+// 多选题少选且没有错选得 multipleChoicePartialScore，填空题按答对的空数计分
+func grade(itemType string, a Answer, r Response) (bool, float64, error) {
+	switch itemType {
+	case models.ItemSingleChoice:
+		if len(r.Choices) == 0 {
+			return false, 0, errors.New("请选择一个选项")
+		}
+		ok := len(r.Choices) == 1 && r.Choices[0] == a.Choices[0]
+		return ok, boolScore(ok), nil
+	case models.ItemMultipleChoice:
+		if len(r.Choices) == 0 {
+			return false, 0, errors.New("请至少选择一个选项")
+		}
+		correct := make(map[int]bool, len(a.Choices))
+		for _, c := range a.Choices {
+			correct[c] = true
+		}
+		selected := make(map[int]bool, len(r.Choices))
+		for _, c := range r.Choices {
+			if !correct[c] {
+				return false, 0, nil
+			}
+			selected[c] = true
+		}
+		if len(selected) == len(correct) {
+			return true, 1, nil
+		}
+		return false, multipleChoicePartialScore, nil
+	case models.ItemTrueFalse:
+		if r.Truth == nil {
+			return false, 0, errors.New("请选择对或错")
+		}
+		ok := *r.Truth == *a.Truth
+		return ok, boolScore(ok), nil
+	case models.ItemFillBlank:
+		if len(r.Blanks) == 0 {
+			return false, 0, errors.New("请填写答案")
+		}
+		matched := 0
+		for i, accepted := range a.Blanks {
+			if i >= len(r.Blanks) {
+				break
+			}
+			given := normalizeBlank(r.Blanks[i])
+			for _, s := range accepted {
+				if given != "" && given == normalizeBlank(s) {
+					matched++
+					break
+				}
+			}
+		}
+		return matched == len(a.Blanks), float64(matched) / float64(len(a.Blanks)), nil
+	case models.ItemNumeric:
+		if r.Value == nil {
+			return false, 0, errors.New("请填写数值")
+		}
+		// 留出浮点误差的余量
+		ok := math.Abs(*r.Value-*a.Value) <= a.Tolerance+1e-9
+		return ok, boolScore(ok), nil
+	default:
+		return false, 0, fmt.Errorf("不支持的题型: %s", itemType)
+	}
+}
+
+func boolScore(ok bool) float64 {
+	if ok {
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,75 @@
+package exercise
+
+import (
+	"testing"
+
+	"github.com/RMS_V3/internal/kg/repository/models"
+)
+
+func boolPtr(b bool) *bool        { return &b }
+func floatPtr(f float64) *float64 { return &f }
+
+func TestGrade(t *testing.T) {
+	cases := []struct {
+		name        string
+		itemType    string
+		answer      Answer
+		response    Response
+		wantCorrect bool
+		wantScore   float64
+	}{
+		{"single correct", models.ItemSingleChoice, Answer{Choices: []int{2}}, Response{Choices: []int{2}}, true, 1},
+		{"single wrong", models.ItemSingleChoice, Answer{Choices: []int{2}}, Response{Choices: []int{1}}, false, 0},
+		{"multiple exact", models.ItemMultipleChoice, Answer{Choices: []int{0, 2}}, Response{Choices: []int{2, 0}}, true, 1},
+		{"multiple partial", models.ItemMultipleChoice, Answer{Choices: []int{0, 2}}, Response{Choices: []int{0}}, false, 0.5},
+		{"multiple wrong option", models.ItemMultipleChoice, Answer{Choices: []int{0, 2}}, Response{Choices: []int{0, 1}}, false, 0},
+		{"true false", models.ItemTrueFalse, Answer{Truth: boolPtr(false)}, Response{Truth: boolPtr(false)}, true, 1},
+		{"fill blank normalized", models.ItemFillBlank,
+			Answer{Blanks: [][]string{{"O(n log n)", "nlogn"}, {"栈"}}},
+			Response{Blanks: []string{"  ｏ(N  LOG N) ", "栈"}}, true, 1},
+		{"fill blank half", models.ItemFillBlank,
+			Answer{Blanks: [][]string{{"队列"}, {"栈"}}},
+			Response{Blanks: []string{"队列", "堆"}}, false, 0.5},
+		{"numeric within tolerance", models.ItemNumeric, Answer{Value: floatPtr(3.14), Tolerance: 0.01}, Response{Value: floatPtr(3.15)}, true, 1},
+		{"numeric outside tolerance", models.ItemNumeric, Answer{Value: floatPtr(3.14), Tolerance: 0.01}, Response{Value: floatPtr(3.2)}, false, 0},
+	}
+	for _, tc := range cases {
+		correct, score, err := grade(tc.itemType, tc.answer, tc.response)
+		if err != nil {
+			t.Errorf("%s: unexpected error: %v", tc.name, err)
+			continue
+		}
+		if correct != tc.wantCorrect || score != tc.wantScore {
+			t.Errorf("%s: got (%v, %v), want (%v, %v)", tc.name, correct, score, tc.wantCorrect, tc.wantScore)
+		}
+	}
+	if _, _, err := grade(models.ItemNumeric, Answer{Value: floatPtr(1)}, Response{}); err == nil {
+		t.Errorf("expected error for empty response")
+	}
+}
+
+func TestValidateItem(t *testing.T) {
+	options := []string{"A", "B", "C"}
+	if err := validateItem(models.ItemSingleChoice, options, Answer{Choices: []int{0, 1}}); err == nil {
+		t.Errorf("single choice with two answers should be rejected")
+	}
+	if err := validateItem(models.ItemMultipleChoice, options, Answer{Choices: []int{3}}); err == nil {
+		t.Errorf("out of range choice should be rejected")
+	}
+	if err := validateItem(models.ItemFillBlank, nil, Answer{Blanks: [][]string{{" "}}}); err == nil {
+		t.Errorf("blank without accepted answers should be rejected")
+	}
+	if err := validateItem(models.ItemNumeric, nil, Answer{Value: floatPtr(1), Tolerance: -1}); err == nil {
+		t.Errorf("negative tolerance should be rejected")
+	}
+	if err := validateItem(models.ItemTrueFalse, nil, Answer{Truth: boolPtr(true)}); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+func TestNormalizeTags(t *testing.T) {
+	got := normalizeTags([]string{" 排序 ", "", "排序", "递归", "  "})
+	if len(got) != 2 || got[0] != "排序" || got[1] != "递归" {
+		t.Errorf("normalizeTags = %v", got)
+	}
+}
@@ -0,0 +1,317 @@
+package exercise
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"math/rand"
+	"sort"
+
+	"github.com/RMS_V3/internal/kg/repository/models"
+)
+
+// 覆盖权重的来源
+const (
+	WeightNone       = "none"
+	WeightCentrality = "centrality"
+	WeightDifficulty = "difficulty"
+)
+
+// difficultyLevels 难度由低到高
+var difficultyLevels = []string{"easy", "medium", "hard"}
+
+// Blueprint 组卷蓝图
+type Blueprint struct {
+	// PerPoint 每个知识点的题目数，TotalQuestions 为 0 时总题数为 PerPoint × 知识点数
+	PerPoint       int `json:"per_point"`
+	TotalQuestions int `json:"total_questions"`
+	// DifficultyMix 各难度题目所占比例，为空时不限制难度
+	DifficultyMix map[string]float64 `json:"difficulty_mix"`
+	// TimeLimit 限时（分钟），0 表示不限时；超出时从题目最多的知识点中去掉用时最长的题
+	TimeLimit int `json:"time_limit"`
+	// WeightBy 总题数按知识点的中心度或难度分配，none 时平均分配
+	WeightBy string `json:"weight_by"`
+}
+
+func (b *Blueprint) validate() error {
+	if b.PerPoint < 0 || b.TotalQuestions < 0 || b.TimeLimit < 0 {
+		return errors.New("题目数和限时不能为负数")
+	}
+	if b.PerPoint == 0 && b.TotalQuestions == 0 {
+		return errors.New("per_point 和 total_questions 至少设置一个")
+	}
+	if b.WeightBy == "" {
+		b.WeightBy = WeightNone
+	}
+	if b.WeightBy != WeightNone && b.WeightBy != WeightCentrality && b.WeightBy != WeightDifficulty {
+		return fmt.Errorf("weight_by 只能是 %s, %s 或 %s", WeightNone, WeightCentrality, WeightDifficulty)
+	}
+	sum := 0.0
+	for level, share := range b.DifficultyMix {
+		if levelIndex(level) < 0 {
+			return fmt.Errorf("无效的难度: %s", level)
+		}
+		if share < 0 || math.IsNaN(share) {
+			return errors.New("难度比例不能为负数")
+		}
+		sum += share
+	}
+	if len(b.DifficultyMix) > 0 && sum == 0 {
+		return errors.New("难度比例之和不能为 0")
+	}
+	return nil
+}
+
+func levelIndex(level string) int {
+	for i, l := range difficultyLevels {
+		if l == level {
+			return i
+		}
+	}
+	return -1
+}
+
+// QuizQuestion 测验中的一道题
+type QuizQuestion struct {
+	ItemID           int64   `json:"item_id"`
+	Version          int     `json:"version"`
+	PointID          int64   `json:"point_id"`
+	Difficulty       string  `json:"difficulty"`
+	EstimatedMinutes float64 `json:"estimated_minutes"`
+}
+
+// 各题型的基础用时（分钟），按难度乘以 difficultyTimeFactor
+var (
+	baseMinutes = map[string]float64{
+		models.ItemTrueFalse:      0.5,
+		models.ItemSingleChoice:   1,
+		models.ItemMultipleChoice: 2,
+		models.ItemFillBlank:      2,
+		models.ItemNumeric:        3,
+	}
+	difficultyTimeFactor = map[string]float64{"easy": 1, "medium": 1.5, "hard": 2}
+)
+
+func estimateMinutes(item *models.ExerciseItem) float64 {
+	base, ok := baseMinutes[item.Type]
+	if !ok {
+		base = 1
+	}
+	factor, ok := difficultyTimeFactor[item.Difficulty]
+	if !ok {
+		factor = 1
+	}
+	return base * factor
+}
+
+// allocateQuestions 把 total 道题分配给各知识点：题目足够时每个知识点至少一道，
+// 其余按权重用最高平均数法分配，不超过每个知识点可用的题数
+func allocateQuestions(points []int64, weights map[int64]float64, total int, available map[int64]int) map[int64]int {
+	counts := make(map[int64]int, len(points))
+	eligible := 0
+	for _, p := range points {
+		if available[p] > 0 {
+			eligible++
+		}
+	}
+	if total >= eligible {
+		for _, p := range points {
+			if available[p] > 0 {
+				counts[p] = 1
+				total--
+			}
+		}
+	}
+	for ; total > 0; total-- {
+		best, bestQuotient := int64(-1), -1.0
+		for _, p := range points {
+			if counts[p] >= available[p] {
+				continue
+			}
+			q := weights[p] / float64(counts[p]+1)
+			if q > bestQuotient {
+				best, bestQuotient = p, q
+			}
+		}
+		if best < 0 {
+			break
+		}
+		counts[best]++
+	}
+	return counts
+}
+
+// splitByDifficulty 按难度比例拆分一个知识点的题数，使用最大余数法
+func splitByDifficulty(count int, mix map[string]float64) map[string]int {
+	quotas := make(map[string]int)
+	sum := 0.0
+	for _, share := range mix {
+		sum += share
+	}
+	if count == 0 || sum == 0 {
+		return quotas
+	}
+	assigned := 0
+	remainders := make([]float64, len(difficultyLevels))
+	for i, level := range difficultyLevels {
+		exact := float64(count) * mix[level] / sum
+		quotas[level] = int(exact)
+		assigned += quotas[level]
+		remainders[i] = exact - float64(quotas[level])
+	}
+	for ; assigned < count; assigned++ {
+		best := 0
+		for i := range remainders {
+			if remainders[i] > remainders[best] {
+				best = i
+			}
+		}
+		quotas[difficultyLevels[best]]++
+		remainders[best] = -1
+	}
+	return quotas
+}
+
+// fallbackLevels 目标难度的题不够时依次尝试的难度，level 为空表示不限难度
+func fallbackLevels(level string) []string {
+	switch level {
+	case "":
+		return []string{""}
+	case "easy":
+		return []string{"easy", "medium", "hard"}
+	case "hard":
+		return []string{"hard", "medium", "easy"}
+	default:
+		return []string{"medium", "easy", "hard"}
+	}
+}
+
+// assembleQuiz 按蓝图组卷。知识点和候选题先按 ID 排序再用 seed 打乱，相同输入和种子得到相同的测验；
+// 关联多个知识点的题只会出现一次
+func assembleQuiz(b Blueprint, points []int64, items []models.ExerciseItem, links map[int64][]int64,
+	weights map[int64]float64, seed int64) []QuizQuestion {
+	rng := rand.New(rand.NewSource(seed))
+	points = append([]int64(nil), points...)
+	sort.Slice(points, func(i, j int) bool { return points[i] < points[j] })
+	sorted := append([]models.ExerciseItem(nil), items...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].ID < sorted[j].ID })
+
+	// 每个知识点按难度分组的候选题，键为空字符串的组包含全部难度
+	byPoint := make(map[int64]map[string][]*models.ExerciseItem, len(points))
+	available := make(map[int64]int, len(points))
+	for _, p := range points {
+		byPoint[p] = make(map[string][]*models.ExerciseItem)
+	}
+	for i := range sorted {
+		item := &sorted[i]
+		for _, p := range links[item.ID] {
+			if groups, ok := byPoint[p]; ok {
+				groups[item.Difficulty] = append(groups[item.Difficulty], item)
+				groups[""] = append(groups[""], item)
+				available[p]++
+			}
+		}
+	}
+	for _, p := range points {
+		for _, level := range append([]string{""}, difficultyLevels...) {
+			candidates := byPoint[p][level]
+			rng.Shuffle(len(candidates), func(i, j int) { candidates[i], candidates[j] = candidates[j], candidates[i] })
+		}
+	}
+
+	total := b.TotalQuestions
+	if total == 0 {
+		total = b.PerPoint * len(points)
+	}
+	if b.WeightBy == WeightNone || len(weights) == 0 {
+		weights = make(map[int64]float64, len(points))
+		for _, p := range points {
+			weights[p] = 1
+		}
+	}
+	counts := allocateQuestions(points, weights, total, available)
+
+	used := make(map[int64]bool)
+	take := func(p int64, level string) *models.ExerciseItem {
+		for _, l := range fallbackLevels(level) {
+			for _, item := range byPoint[p][l] {
+				if !used[item.ID] {
+					used[item.ID] = true
+					return item
+				}
+			}
+		}
+		return nil
+	}
+
+	var questions []QuizQuestion
+	for _, p := range points {
+		var levels []string
+		if len(b.DifficultyMix) > 0 {
+			quotas := splitByDifficulty(counts[p], b.DifficultyMix)
+			for _, level := range difficultyLevels {
+				for i := 0; i < quotas[level]; i++ {
+					levels = append(levels, level)
+				}
+			}
+		} else {
+			// 不限制难度时从全部候选题中抽取
+			for i := 0; i < counts[p]; i++ {
+				levels = append(levels, "")
+			}
+		}
+		for _, level := range levels {
+			item := take(p, level)
+			if item == nil {
+				break
+			}
+			questions = append(questions, QuizQuestion{
+				ItemID:           item.ID,
+				Version:          item.Version,
+				PointID:          p,
+				Difficulty:       item.Difficulty,
+				EstimatedMinutes: estimateMinutes(item),
+			})
+		}
+	}
+	if b.TimeLimit > 0 {
+		questions = fitTimeLimit(questions, float64(b.TimeLimit))
+	}
+	return questions
+}
+
+// fitTimeLimit 预计用时超过限时时，反复从题目最多的知识点中去掉用时最长的题
+func fitTimeLimit(questions []QuizQuestion, limit float64) []QuizQuestion {
+	total := 0.0
+	perPoint := make(map[int64]int)
+	for _, q := range questions {
+		total += q.EstimatedMinutes
+		perPoint[q.PointID]++
+	}
+	for total > limit+1e-9 && len(questions) > 0 {
+		drop := -1
+		for i, q := range questions {
+			if drop < 0 {
+				drop = i
+				continue
+			}
+			d := questions[drop]
+			if perPoint[q.PointID] > perPoint[d.PointID] ||
+				(perPoint[q.PointID] == perPoint[d.PointID] && q.EstimatedMinutes > d.EstimatedMinutes) {
+				drop = i
+			}
+		}
+		total -= questions[drop].EstimatedMinutes
+		perPoint[questions[drop].PointID]--
+		questions = append(questions[:drop], questions[drop+1:]...)
+	}
+	return questions
+}
+
+func totalMinutes(questions []QuizQuestion) float64 {
+	total := 0.0
+	for _, q := range questions {
+		total += q.EstimatedMinutes
+	}
+	return total
+}
@@ -0,0 +1,127 @@
+package exercise
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/RMS_V3/internal/kg/repository/models"
+)
+
+func TestAllocateQuestions(t *testing.T) {
+	points := []int64{1, 2, 3}
+	available := map[int64]int{1: 10, 2: 10, 3: 1}
+
+	counts := allocateQuestions(points, map[int64]float64{1: 3, 2: 1, 3: 1}, 6, available)
+	if counts[1]+counts[2]+counts[3] != 6 {
+		t.Fatalf("total = %v, want 6", counts)
+	}
+	if counts[3] != 1 || counts[1] <= counts[2] {
+		t.Errorf("counts = %v, want every point covered and point 1 weighted highest", counts)
+	}
+
+	// 可用题数不够时不超过可用数
+	counts = allocateQuestions(points, map[int64]float64{1: 1, 2: 1, 3: 100}, 30, available)
+	if !reflect.DeepEqual(counts, map[int64]int{1: 10, 2: 10, 3: 1}) {
+		t.Errorf("counts = %v, want capped by availability", counts)
+	}
+
+	// 总题数少于知识点数时按权重挑选知识点
+	counts = allocateQuestions(points, map[int64]float64{1: 1, 2: 5, 3: 1}, 1, available)
+	if counts[2] != 1 || counts[1] != 0 || counts[3] != 0 {
+		t.Errorf("counts = %v, want the single question on point 2", counts)
+	}
+}
+
+func TestSplitByDifficulty(t *testing.T) {
+	got := splitByDifficulty(5, map[string]float64{"easy": 0.3, "medium": 0.5, "hard": 0.2})
+	want := map[string]int{"easy": 2, "medium": 2, "hard": 1}
+	if got["easy"]+got["medium"]+got["hard"] != 5 {
+		t.Fatalf("split = %v, want 5 questions", got)
+	}
+	// 1.5、2.5、1.0 的余数相同时按难度从低到高补齐
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("split = %v, want %v", got, want)
+	}
+	if got := splitByDifficulty(3, map[string]float64{"hard": 1}); got["hard"] != 3 || got["easy"] != 0 {
+		t.Errorf("split = %v, want all hard", got)
+	}
+}
+
+func quizFixture() ([]int64, []models.ExerciseItem, map[int64][]int64) {
+	points := []int64{10, 20}
+	var items []models.ExerciseItem
+	links := make(map[int64][]int64)
+	levels := []string{"easy", "medium", "hard"}
+	for i := int64(1); i <= 12; i++ {
+		items = append(items, models.ExerciseItem{
+			ID:         i,
+			Type:       models.ItemSingleChoice,
+			Difficulty: levels[i%3],
+			Version:    1,
+		})
+		links[i] = []int64{points[i%2]}
+	}
+	// 同时关联两个知识点的题
+	links[12] = []int64{10, 20}
+	return points, items, links
+}
+
+func TestAssembleQuizDeterministic(t *testing.T) {
+	points, items, links := quizFixture()
+	b := Blueprint{PerPoint: 3, DifficultyMix: map[string]float64{"easy": 1, "hard": 2}, WeightBy: WeightNone}
+
+	first := assembleQuiz(b, points, items, links, nil, 42)
+	// 输入顺序不影响结果
+	reversed := make([]models.ExerciseItem, len(items))
+	for i := range items {
+		reversed[len(items)-1-i] = items[i]
+	}
+	second := assembleQuiz(b, []int64{20, 10}, reversed, links, nil, 42)
+	if !reflect.DeepEqual(first, second) {
+		t.Fatalf("same seed produced different quizzes:\n%v\n%v", first, second)
+	}
+	if len(first) != 6 {
+		t.Fatalf("got %d questions, want 6", len(first))
+	}
+	seen := make(map[int64]bool)
+	perPoint := make(map[int64]int)
+	for _, q := range first {
+		if seen[q.ItemID] {
+			t.Errorf("item %d selected twice", q.ItemID)
+		}
+		seen[q.ItemID] = true
+		perPoint[q.PointID]++
+	}
+	if perPoint[10] != 3 || perPoint[20] != 3 {
+		t.Errorf("per point = %v, want 3 each", perPoint)
+	}
+
+	differs := false
+	for seed := int64(0); seed < 10 && !differs; seed++ {
+		differs = !reflect.DeepEqual(first, assembleQuiz(b, points, items, links, nil, seed))
+	}
+	if !differs {
+		t.Error("different seeds always produced the same quiz")
+	}
+}
+
+func TestFitTimeLimit(t *testing.T) {
+	questions := []QuizQuestion{
+		{ItemID: 1, PointID: 1, EstimatedMinutes: 1},
+		{ItemID: 2, PointID: 1, EstimatedMinutes: 3},
+		{ItemID: 3, PointID: 1, EstimatedMinutes: 2},
+		{ItemID: 4, PointID: 2, EstimatedMinutes: 4},
+	}
+	got := fitTimeLimit(questions, 7)
+	// 先从题目最多的知识点 1 中去掉用时最长的第 2 题
+	var ids []int64
+	for _, q := range got {
+		ids = append(ids, q.ItemID)
+	}
+	if !reflect.DeepEqual(ids, []int64{1, 3, 4}) {
+		t.Errorf("kept items %v, want [1 3 4]", ids)
+	}
+	if totalMinutes(got) > 7 {
+		t.Errorf("total minutes %v exceeds limit", totalMinutes(got))
+	}
+}
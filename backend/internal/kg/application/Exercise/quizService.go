@@ -0,0 +1,431 @@
+package exercise
+
+import (
+	"encoding/json"
+	"errors"
+	"strconv"
+	"time"
+
+	analysis "github.com/RMS_V3/internal/kg/application/Analysis"
+	mastery "github.com/RMS_V3/internal/kg/application/Mastery"
+	progress "github.com/RMS_V3/internal/kg/application/Progress"
+	"github.com/RMS_V3/internal/kg/repository"
+	"github.com/RMS_V3/internal/kg/repository/models"
+	"github.com/RMS_V3/internal/user"
+	"github.com/RMS_V3/log"
+	"github.com/RMS_V3/middleware/neo4jUtils"
+	"github.com/RMS_V3/pkg/response"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// quizPassRatio 测验中某个知识点的得分率达到该值时视为掌握，进度标记为已完成
+const quizPassRatio = 0.6
+
+// loadScopePoints 读取章节或小节下的全部知识点，返回范围类型（chapter 或 section）
+func loadScopePoints(scopeId int64) (string, []int64, error) {
+	session := neo4jUtils.GetSession()
+	if session == nil {
+		return "", nil, errors.New("无法获取 Neo4j 会话")
+	}
+	defer session.Close()
+	result, err := session.Run(`
+	MATCH (s)
+	WHERE id(s) = $id AND (s:chapter OR s:section)
+	OPTIONAL MATCH (s)-[:包含*1..2]->(p:point)
+	RETURN CASE WHEN s:chapter THEN 'chapter' ELSE 'section' END AS scope, collect(DISTINCT id(p)) AS points`,
+		map[string]interface{}{"id": scopeId})
+	if err != nil {
+		return "", nil, err
+	}
+	if !result.Next() {
+		return "", nil, result.Err()
+	}
+	record := result.Record()
+	scope, _ := record.Get("scope")
+	raw, _ := record.Get("points")
+	var points []int64
+	if list, ok := raw.([]interface{}); ok {
+		for _, v := range list {
+			if id, ok := v.(int64); ok {
+				points = append(points, id)
+			}
+		}
+	}
+	scopeType, _ := scope.(string)
+	return scopeType, points, nil
+}
+
+// pointWeights 按蓝图读取知识点的覆盖权重
+func pointWeights(weightBy string, points []int64) (map[int64]float64, error) {
+	if weightBy == WeightNone {
+		return nil, nil
+	}
+	session := neo4jUtils.GetSession()
+	if session == nil {
+		return nil, errors.New("无法获取 Neo4j 会话")
+	}
+	defer session.Close()
+	if weightBy == WeightCentrality {
+		return analysis.PointCentralityScores(session, points)
+	}
+	return analysis.PointDifficultyScores(session, points)
+}
+
+type GenerateQuizRequest struct {
+	ScopeID int64 `json:"scope_id" binding:"required"`
+	Blueprint
+	// Seed 随机种子，为空时使用当前时间；相同的范围、蓝图、题库和种子生成相同的测验
+	Seed *int64 `json:"seed"`
+}
+
+// QuizView 返回给前端的测验，Questions 中的习题取组卷时的版本
+type QuizView struct {
+	models.Quiz
+	Blueprint Blueprint          `json:"blueprint"`
+	Questions []QuizQuestionView `json:"questions"`
+}
+
+type QuizQuestionView struct {
+	QuizQuestion
+	Type    string   `json:"type"`
+	Stem    string   `json:"stem"`
+	Options []string `json:"options,omitempty"`
+	Answer  *Answer  `json:"answer,omitempty"`
+}
+
+func decodeQuiz(quiz *models.Quiz) (Blueprint, []QuizQuestion, error) {
+	var b Blueprint
+	var questions []QuizQuestion
+	if err := json.Unmarshal([]byte(quiz.Blueprint), &b); err != nil {
+		return b, nil, errors.New("测验蓝图损坏: " + err.Error())
+	}
+	if err := json.Unmarshal([]byte(quiz.Questions), &questions); err != nil {
+		return b, nil, errors.New("测验题目损坏: " + err.Error())
+	}
+	return b, questions, nil
+}
+
+// loadQuestionVersions 读取测验中每道题组卷时的版本
+func loadQuestionVersions(questions []QuizQuestion) (map[[2]int64]models.ExerciseItemVersion, error) {
+	keys := make([][2]int64, len(questions))
+	for i, q := range questions {
+		keys[i] = [2]int64{q.ItemID, int64(q.Version)}
+	}
+	return repository.GetExerciseItemVersions(keys)
+}
+
+// quizView 生成返回给前端的测验，Answer 只对拥有 resource.upload 权限的账号返回
+func quizView(quiz *models.Quiz, withAnswer bool) (*QuizView, error) {
+	b, questions, err := decodeQuiz(quiz)
+	if err != nil {
+		return nil, err
+	}
+	versions, err := loadQuestionVersions(questions)
+	if err != nil {
+		return nil, err
+	}
+	view := &QuizView{Quiz: *quiz, Blueprint: b, Questions: make([]QuizQuestionView, 0, len(questions))}
+	for _, q := range questions {
+		v, ok := versions[[2]int64{q.ItemID, int64(q.Version)}]
+		if !ok {
+			log.Errorf("version %d of exercise item %d in quiz %d not found", q.Version, q.ItemID, quiz.ID)
+			continue
+		}
+		options, answer, err := decodeItem(&models.ExerciseItem{ID: v.ItemID, Options: v.Options, Answer: v.Answer})
+		if err != nil {
+			return nil, err
+		}
+		qv := QuizQuestionView{QuizQuestion: q, Type: v.Type, Stem: v.Stem, Options: options}
+		if withAnswer {
+			qv.Answer = &answer
+		}
+		view.Questions = append(view.Questions, qv)
+	}
+	return view, nil
+}
+
+// GenerateQuiz 按蓝图从章节或小节下知识点的题库中组卷
+func GenerateQuiz(c *gin.Context) {
+	u := user.CurrentUser(c)
+	var req GenerateQuizRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(400, response.Error(400, "参数验证失败"))
+		return
+	}
+	if err := req.Blueprint.validate(); err != nil {
+		c.JSON(400, response.Error(400, err.Error()))
+		return
+	}
+	scopeType, points, err := loadScopePoints(req.ScopeID)
+	if err != nil {
+		log.Errorf("load points of scope %d failed: %v", req.ScopeID, err)
+		c.JSON(500, response.Error(500, "读取知识点失败"))
+		return
+	}
+	if scopeType == "" {
+		c.JSON(404, response.Error(404, "章节或小节不存在"))
+		return
+	}
+	if len(points) == 0 {
+		c.JSON(400, response.Error(400, "该范围下没有知识点"))
+		return
+	}
+	items, links, err := repository.ListExerciseItemsForPoints(points)
+	if err != nil {
+		log.Errorf("load exercise items of scope %d failed: %v", req.ScopeID, err)
+		c.JSON(500, response.Error(500, "读取题库失败"))
+		return
+	}
+	weights, err := pointWeights(req.WeightBy, points)
+	if err != nil {
+		log.Errorf("load point weights failed: %v", err)
+		c.JSON(500, response.Error(500, "读取知识点权重失败"))
+		return
+	}
+	seed := time.Now().UnixNano()
+	if req.Seed != nil {
+		seed = *req.Seed
+	}
+	questions := assembleQuiz(req.Blueprint, points, items, links, weights, seed)
+	if len(questions) == 0 {
+		c.JSON(400, response.Error(400, "题库中没有符合蓝图的习题"))
+		return
+	}
+
+	blueprint, _ := json.Marshal(req.Blueprint)
+	encoded, _ := json.Marshal(questions)
+	quiz := &models.Quiz{
+		CreatedBy:        u.Id,
+		ScopeType:        scopeType,
+		ScopeID:          req.ScopeID,
+		Seed:             seed,
+		Blueprint:        string(blueprint),
+		Questions:        string(encoded),
+		TimeLimit:        req.TimeLimit,
+		EstimatedMinutes: totalMinutes(questions),
+	}
+	if err := repository.CreateQuiz(quiz); err != nil {
+		log.Errorf("save quiz failed: %v", err)
+		c.JSON(500, response.Error(500, "保存测验失败"))
+		return
+	}
+	view, err := quizView(quiz, user.HasPermission(u.UserType, user.ResourceUpload))
+	if err != nil {
+		log.Errorf("load quiz %d failed: %v", quiz.ID, err)
+		c.JSON(500, response.Error(500, "读取测验失败"))
+		return
+	}
+	c.JSON(200, response.Success(view))
+}
+
+// loadQuiz 读取测验，不存在时返回 404
+func loadQuiz(c *gin.Context, id int64) (*models.Quiz, bool) {
+	quiz, err := repository.GetQuiz(id)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		c.JSON(404, response.Error(404, "测验不存在"))
+		return nil, false
+	}
+	if err != nil {
+		log.Errorf("load quiz %d failed: %v", id, err)
+		c.JSON(500, response.Error(500, "读取测验失败"))
+		return nil, false
+	}
+	return quiz, true
+}
+
+// GetQuiz 查看测验，学生看不到答案
+func GetQuiz(c *gin.Context) {
+	u := user.CurrentUser(c)
+	id, err := strconv.ParseInt(c.Query("id"), 10, 64)
+	if err != nil {
+		c.JSON(400, response.Error(400, "无效的测验ID"))
+		return
+	}
+	quiz, ok := loadQuiz(c, id)
+	if !ok {
+		return
+	}
+	view, err := quizView(quiz, user.HasPermission(u.UserType, user.ResourceUpload))
+	if err != nil {
+		log.Errorf("load quiz %d failed: %v", id, err)
+		c.JSON(500, response.Error(500, "读取测验失败"))
+		return
+	}
+	c.JSON(200, response.Success(view))
+}
+
+type QuizAnswer struct {
+	ItemID   int64    `json:"item_id" binding:"required"`
+	Response Response `json:"response"`
+}
+
+type SubmitQuizRequest struct {
+	QuizID  int64        `json:"quiz_id" binding:"required"`
+	Answers []QuizAnswer `json:"answers" binding:"dive"`
+}
+
+// QuestionResult 测验中一道题的判分结果
+type QuestionResult struct {
+	ItemID  int64   `json:"item_id"`
+	PointID int64   `json:"point_id"`
+	Correct bool    `json:"correct"`
+	Score   float64 `json:"score"`
+	Answer  Answer  `json:"answer"`
+}
+
+// PointResult 测验中一个知识点的汇总结果
+type PointResult struct {
+	PointID   int64   `json:"point_id"`
+	Score     float64 `json:"score"`
+	MaxScore  float64 `json:"max_score"`
+	Passed    bool    `json:"passed"`
+	Mastery   float64 `json:"mastery"`
+	Questions int     `json:"questions"`
+}
+
+// SubmitQuiz 提交测验，按组卷时的题目版本判分，未作答的题计 0 分；
+// 每道题计入作答记录。只有第一次提交计入掌握度，每个知识点的得分率决定学习进度，之后的提交只作为练习
+func SubmitQuiz(c *gin.Context) {
+	u := user.CurrentUser(c)
+	var req SubmitQuizRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(400, response.Error(400, "参数验证失败"))
+		return
+	}
+	quiz, ok := loadQuiz(c, req.QuizID)
+	if !ok {
+		return
+	}
+	_, questions, err := decodeQuiz(quiz)
+	if err != nil {
+		c.JSON(500, response.Error(500, err.Error()))
+		return
+	}
+	versions, err := loadQuestionVersions(questions)
+	if err != nil {
+		log.Errorf("load versions of quiz %d failed: %v", quiz.ID, err)
+		c.JSON(500, response.Error(500, "读取测验题目失败"))
+		return
+	}
+	answers := make(map[int64]Response, len(req.Answers))
+	for _, a := range req.Answers {
+		answers[a.ItemID] = a.Response
+	}
+
+	results := make([]QuestionResult, 0, len(questions))
+	attempts := make([]models.ExerciseAttempt, 0, len(questions))
+	var pointOrder []int64
+	pointResults := make(map[int64]*PointResult)
+	total := 0.0
+	for _, q := range questions {
+		v, ok := versions[[2]int64{q.ItemID, int64(q.Version)}]
+		if !ok {
+			log.Errorf("version %d of exercise item %d in quiz %d not found", q.Version, q.ItemID, quiz.ID)
+			continue
+		}
+		_, answer, err := decodeItem(&models.ExerciseItem{ID: v.ItemID, Options: v.Options, Answer: v.Answer})
+		if err != nil {
+			c.JSON(500, response.Error(500, err.Error()))
+			return
+		}
+		result := QuestionResult{ItemID: q.ItemID, PointID: q.PointID, Answer: answer}
+		if r, answered := answers[q.ItemID]; answered {
+			// 作答格式不符（如未选择选项）按答错处理
+			result.Correct, result.Score, _ = grade(v.Type, answer, r)
+			encoded, _ := json.Marshal(r)
+			attempts = append(attempts, models.ExerciseAttempt{
+				UserID:      u.Id,
+				ItemID:      q.ItemID,
+				ItemVersion: q.Version,
+				Response:    string(encoded),
+				Correct:     result.Correct,
+				Score:       result.Score,
+			})
+		}
+		results = append(results, result)
+		total += result.Score
+
+		pr, exists := pointResults[q.PointID]
+		if !exists {
+			pr = &PointResult{PointID: q.PointID}
+			pointResults[q.PointID] = pr
+			pointOrder = append(pointOrder, q.PointID)
+		}
+		pr.Score += result.Score
+		pr.MaxScore++
+		pr.Questions++
+	}
+
+	encoded, _ := json.Marshal(results)
+	submission := &models.QuizSubmission{
+		QuizID:   quiz.ID,
+		UserID:   u.Id,
+		Score:    total,
+		MaxScore: float64(len(results)),
+		Results:  string(encoded),
+	}
+	if err := repository.CreateQuizSubmission(submission, attempts); err != nil {
+		log.Errorf("save quiz submission failed: %v", err)
+		c.JSON(500, response.Error(500, "保存测验结果失败"))
+		return
+	}
+
+	if submission.Scored {
+		for _, r := range results {
+			updated, err := mastery.Observe(u.Id, r.PointID, r.Correct)
+			if err != nil {
+				log.Errorf("update mastery of point %d failed: %v", r.PointID, err)
+				continue
+			}
+			if m, ok := updated[r.PointID]; ok {
+				pointResults[r.PointID].Mastery = m
+			}
+		}
+	}
+	points := make([]PointResult, 0, len(pointOrder))
+	for _, id := range pointOrder {
+		pr := pointResults[id]
+		pr.Passed = pr.Score >= quizPassRatio*pr.MaxScore
+		if submission.Scored {
+			if err := progress.RecordQuizResult(u.Id, id, pr.Passed); err != nil {
+				log.Errorf("update progress of point %d failed: %v", id, err)
+			}
+		}
+		points = append(points, *pr)
+	}
+
+	c.JSON(200, response.Success(map[string]interface{}{
+		"submission_id": submission.ID,
+		"score":         submission.Score,
+		"max_score":     submission.MaxScore,
+		"scored":        submission.Scored,
+		"questions":     results,
+		"points":        points,
+	}))
+}
+
+// ListQuizSubmissions 当前学生对某个测验的提交记录
+func ListQuizSubmissions(c *gin.Context) {
+	u := user.CurrentUser(c)
+	id, err := strconv.ParseInt(c.Query("quiz_id"), 10, 64)
+	if err != nil {
+		c.JSON(400, response.Error(400, "无效的测验ID"))
+		return
+	}
+	submissions, err := repository.ListQuizSubmissions(id, u.Id)
+	if err != nil {
+		log.Errorf("list quiz submissions failed: %v", err)
+		c.JSON(500, response.Error(500, "读取测验记录失败"))
+		return
+	}
+	type submissionView struct {
+		models.QuizSubmission
+		Results json.RawMessage `json:"results"`
+	}
+	views := make([]submissionView, len(submissions))
+	for i, s := range submissions {
+		views[i] = submissionView{QuizSubmission: s, Results: json.RawMessage(s.Results)}
+	}
+	c.JSON(200, response.Success(views))
+}
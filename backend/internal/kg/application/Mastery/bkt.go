@@ -0,0 +1,156 @@
+package mastery
+
+import (
+	"errors"
+	"math"
+	"sync"
+
+	"github.com/RMS_V3/config"
+	"github.com/RMS_V3/internal/kg/repository/models"
+	"github.com/RMS_V3/log"
+)
+
+// Params 贝叶斯知识追踪参数
+type Params struct {
+	PInit    float64 `json:"p_init"`
+	PTransit float64 `json:"p_transit"`
+	PSlip    float64 `json:"p_slip"`
+	PGuess   float64 `json:"p_guess"`
+}
+
+var defaultParams = Params{PInit: 0.2, PTransit: 0.15, PSlip: 0.1, PGuess: 0.2}
+
+// propagation 答对时向前置知识点传播证据的方式
+type propagation struct {
+	Decay float64
+	Depth int
+}
+
+var defaultPropagation = propagation{Decay: 0.5, Depth: 2}
+
+// 配置无效时只记录一次日志
+var invalidParamsOnce, invalidPropagationOnce sync.Once
+
+// paramsFromConfig 未配置的字段使用默认值，配置的取值无效时整体使用默认参数
+func paramsFromConfig(cfg *config.BKTConfig) (Params, error) {
+	if cfg == nil {
+		return defaultParams, nil
+	}
+	p := defaultParams
+	for _, f := range []struct {
+		configured float64
+		field      *float64
+	}{{cfg.PInit, &p.PInit}, {cfg.PTransit, &p.PTransit}, {cfg.PSlip, &p.PSlip}, {cfg.PGuess, &p.PGuess}} {
+		if f.configured != 0 {
+			*f.field = f.configured
+		}
+	}
+	if err := p.validate(); err != nil {
+		return defaultParams, err
+	}
+	return p, nil
+}
+
+// propagationFromConfig 未配置的字段使用默认值，衰减系数须在 (0, 1] 之间、层数不能为负
+func propagationFromConfig(cfg *config.BKTConfig) (propagation, error) {
+	if cfg == nil {
+		return defaultPropagation, nil
+	}
+	p := defaultPropagation
+	if cfg.PropagationDecay != 0 {
+		p.Decay = cfg.PropagationDecay
+	}
+	if cfg.PropagationDepth != 0 {
+		p.Depth = cfg.PropagationDepth
+	}
+	if math.IsNaN(p.Decay) || p.Decay <= 0 || p.Decay > 1 || p.Depth < 0 {
+		return defaultPropagation, errors.New("propagation_decay 必须在 (0, 1] 之间，propagation_depth 不能为负")
+	}
+	return p, nil
+}
+
+func configuredParams() Params {
+	p, err := paramsFromConfig(config.GetGlobalConfig().BKTConfig)
+	if err != nil {
+		invalidParamsOnce.Do(func() { log.Errorf("invalid bkt config, using defaults: %v", err) })
+	}
+	return p
+}
+
+func configuredPropagation() propagation {
+	p, err := propagationFromConfig(config.GetGlobalConfig().BKTConfig)
+	if err != nil {
+		invalidPropagationOnce.Do(func() { log.Errorf("invalid bkt config, using defaults: %v", err) })
+	}
+	return p
+}
+
+// paramsFor 返回知识点的参数，没有单独设置时使用默认值
+func paramsFor(id int64, custom map[int64]models.BKTParams, fallback Params) Params {
+	p, ok := custom[id]
+	if !ok {
+		return fallback
+	}
+	return Params{PInit: p.PInit, PTransit: p.PTransit, PSlip: p.PSlip, PGuess: p.PGuess}
+}
+
+// validate 检查参数取值，失误和猜测概率不小于 0.5 时答对反而说明未掌握，模型失去意义
+func (p Params) validate() error {
+	for _, v := range []float64{p.PInit, p.PTransit, p.PSlip, p.PGuess} {
+		if math.IsNaN(v) || v <= 0 || v >= 1 {
+			return errors.New("参数必须在 (0, 1) 之间")
+		}
+	}
+	if p.PSlip >= 0.5 || p.PGuess >= 0.5 {
+		return errors.New("p_slip 和 p_guess 必须小于 0.5")
+	}
+	return nil
+}
+
+// posterior 根据一次作答结果计算掌握概率的后验
+func (p Params) posterior(prior float64, correct bool) float64 {
+	if correct {
+		known := prior * (1 - p.PSlip)
+		return known / (known + (1-prior)*p.PGuess)
+	}
+	known := prior * p.PSlip
+	return known / (known + (1-prior)*(1-p.PGuess))
+}
+
+// update 一次作答后的掌握概率：先根据结果求后验，再考虑练习带来的学习
+func (p Params) update(prior float64, correct bool) float64 {
+	post := p.posterior(prior, correct)
+	return post + (1-post)*p.PTransit
+}
+
+// propagateToPrerequisite 目标知识点答对后，按前置距离衰减地提升前置知识点的掌握概率；
+// 只取后验而不计学习转移，因为学生并没有直接练习前置知识点
+func (p Params) propagateToPrerequisite(prior float64, distance int, decay float64) float64 {
+	weight := math.Pow(decay, float64(distance))
+	return prior + weight*(p.posterior(prior, true)-prior)
+}
+
+// observe 计算一次作答后目标知识点及其前置知识点的新掌握概率，
+// current 中没有的知识点以其 PInit 为先验，答错时不向前置知识点传播
+func observe(target int64, correct bool, prerequisites map[int64]int, current map[int64]float64,
+	custom map[int64]models.BKTParams, fallback Params, prop propagation) map[int64]float64 {
+	prior := func(id int64) float64 {
+		if m, ok := current[id]; ok {
+			return m
+		}
+		return paramsFor(id, custom, fallback).PInit
+	}
+	updated := map[int64]float64{
+		target: paramsFor(target, custom, fallback).update(prior(target), correct),
+	}
+	if !correct {
+		return updated
+	}
+	for id, distance := range prerequisites {
+		if id == target || distance > prop.Depth {
+			continue
+		}
+		updated[id] = paramsFor(id, custom, fallback).propagateToPrerequisite(prior(id), distance, prop.Decay)
+	}
+	return updated
+}
@@ -0,0 +1,91 @@
+package mastery
+
+import (
+	"math"
+	"testing"
+
+	"github.com/RMS_V3/config"
+	"github.com/RMS_V3/internal/kg/repository/models"
+)
+
+func TestUpdate(t *testing.T) {
+	p := Params{PInit: 0.2, PTransit: 0.1, PSlip: 0.1, PGuess: 0.2}
+	// 答对：后验 0.2*0.9/(0.18+0.16)，再加学习转移
+	post := 0.18 / 0.34
+	if got, want := p.update(0.2, true), post+(1-post)*0.1; math.Abs(got-want) > 1e-9 {
+		t.Errorf("update after correct = %v, want %v", got, want)
+	}
+	if p.update(0.5, false) >= 0.5 {
+		t.Errorf("mastery should drop after an incorrect answer")
+	}
+	m := 0.2
+	for i := 0; i < 10; i++ {
+		m = p.update(m, true)
+	}
+	if m < 0.95 {
+		t.Errorf("mastery after 10 correct answers = %v, expected close to 1", m)
+	}
+}
+
+func TestObservePropagation(t *testing.T) {
+	fallback := Params{PInit: 0.2, PTransit: 0.1, PSlip: 0.1, PGuess: 0.2}
+	prop := propagation{Decay: 0.5, Depth: 2}
+	// 1 是 3 的直接前置，2 距离为 2，4 超出传播层数
+	prerequisites := map[int64]int{1: 1, 2: 2, 4: 3}
+	current := map[int64]float64{1: 0.4, 3: 0.5}
+	custom := map[int64]models.BKTParams{2: {PInit: 0.6, PTransit: 0.1, PSlip: 0.1, PGuess: 0.2}}
+
+	got := observe(3, true, prerequisites, current, custom, fallback, prop)
+	if _, ok := got[4]; ok {
+		t.Errorf("point beyond propagation depth should not be updated")
+	}
+	if got[3] <= 0.5 {
+		t.Errorf("target mastery should rise, got %v", got[3])
+	}
+	direct := got[1] - 0.4
+	if direct <= 0 {
+		t.Fatalf("direct prerequisite should rise, got %v", got[1])
+	}
+	// 直接前置的提升幅度为一次完整答对后验的一半
+	if want := 0.5 * (fallback.posterior(0.4, true) - 0.4); math.Abs(direct-want) > 1e-9 {
+		t.Errorf("direct prerequisite gain = %v, want %v", direct, want)
+	}
+	// 距离为 2 的前置使用自己的先验 0.6
+	if want := 0.6 + 0.25*(paramsFor(2, custom, fallback).posterior(0.6, true)-0.6); math.Abs(got[2]-want) > 1e-9 {
+		t.Errorf("second-level prerequisite = %v, want %v", got[2], want)
+	}
+
+	wrong := observe(3, false, prerequisites, current, custom, fallback, prop)
+	if len(wrong) != 1 || wrong[3] >= 0.5 {
+		t.Errorf("incorrect answer should only lower the target, got %v", wrong)
+	}
+}
+
+func TestValidate(t *testing.T) {
+	if err := (Params{PInit: 0.2, PTransit: 0.1, PSlip: 0.1, PGuess: 0.2}).validate(); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+	if err := (Params{PInit: 0.2, PTransit: 0.1, PSlip: 0.6, PGuess: 0.2}).validate(); err == nil {
+		t.Errorf("expected error for slip >= 0.5")
+	}
+	if err := (Params{PInit: 0, PTransit: 0.1, PSlip: 0.1, PGuess: 0.2}).validate(); err == nil {
+		t.Errorf("expected error for p_init = 0")
+	}
+}
+
+func TestParamsFromConfig(t *testing.T) {
+	p, err := paramsFromConfig(&config.BKTConfig{PTransit: 0.3})
+	if err != nil || p != (Params{PInit: defaultParams.PInit, PTransit: 0.3, PSlip: defaultParams.PSlip, PGuess: defaultParams.PGuess}) {
+		t.Errorf("partial config gave %+v, %v", p, err)
+	}
+	if p, err := paramsFromConfig(&config.BKTConfig{PSlip: 0.7}); err == nil || p != defaultParams {
+		t.Errorf("invalid config gave %+v, %v", p, err)
+	}
+	prop, err := propagationFromConfig(&config.BKTConfig{PInit: 0.3})
+	if err != nil || prop != defaultPropagation {
+		t.Errorf("missing propagation gave %+v, %v", prop, err)
+	}
+	if prop, err := propagationFromConfig(&config.BKTConfig{PropagationDecay: 1.5}); err == nil || prop != defaultPropagation {
+		t.Errorf("invalid decay gave %+v, %v", prop, err)
+	}
+}
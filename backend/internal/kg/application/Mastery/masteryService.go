@@ -0,0 +1,240 @@
+package mastery
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/RMS_V3/internal/kg/repository"
+	"github.com/RMS_V3/internal/kg/repository/models"
+	"github.com/RMS_V3/internal/user"
+	"github.com/RMS_V3/log"
+	"github.com/RMS_V3/middleware/neo4jUtils"
+	"github.com/RMS_V3/pkg/response"
+	"github.com/gin-gonic/gin"
+)
+
+// maxQueryPoints 一次最多查询的知识点数量
+const maxQueryPoints = 500
+
+// loadPrerequisiteDistances 读取知识点在 depth 层以内的前置知识点及其最短距离
+func loadPrerequisiteDistances(pointId int64, depth int) (map[int64]int, error) {
+	distances := make(map[int64]int)
+	if depth <= 0 {
+		return distances, nil
+	}
+	session := neo4jUtils.GetSession()
+	if session == nil {
+		return nil, fmt.Errorf("无法获取 Neo4j 会话")
+	}
+	defer session.Close()
+	result, err := session.Run(fmt.Sprintf(`
+	MATCH path = (p:point)-[:前置*1..%d]->(x:point)
+	WHERE id(x) = $id
+	RETURN id(p) AS id, min(length(path)) AS distance`, depth),
+		map[string]interface{}{"id": pointId})
+	if err != nil {
+		return nil, err
+	}
+	for result.Next() {
+		record := result.Record()
+		id, _ := record.Get("id")
+		distance, _ := record.Get("distance")
+		pid, ok1 := id.(int64)
+		d, ok2 := distance.(int64)
+		if ok1 && ok2 {
+			distances[pid] = int(d)
+		}
+	}
+	return distances, result.Err()
+}
+
+// Observe 根据学生在知识点上的一次作答结果更新掌握度，答对时同时提升其前置知识点的掌握度，
+// 返回更新后的掌握度
+func Observe(userId string, pointId int64, correct bool) (map[int64]float64, error) {
+	prop := configuredPropagation()
+	prerequisites, err := loadPrerequisiteDistances(pointId, prop.Depth)
+	if err != nil {
+		return nil, err
+	}
+	ids := []int64{pointId}
+	for id := range prerequisites {
+		ids = append(ids, id)
+	}
+	custom, err := repository.GetBKTParams(ids)
+	if err != nil {
+		return nil, err
+	}
+	fallback := configuredParams()
+	var updated map[int64]float64
+	err = repository.UpdateLearnerMastery(userId, ids, func(current map[int64]float64) map[int64]float64 {
+		updated = observe(pointId, correct, prerequisites, current, custom, fallback, prop)
+		return updated
+	})
+	return updated, err
+}
+
+// parsePointIds 解析逗号分隔的知识点ID
+func parsePointIds(raw string) ([]int64, error) {
+	var ids []int64
+	seen := make(map[int64]bool)
+	for _, part := range strings.Split(raw, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		id, err := strconv.ParseInt(part, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("无效的知识点ID: %s", part)
+		}
+		if !seen[id] {
+			seen[id] = true
+			ids = append(ids, id)
+		}
+	}
+	if len(ids) == 0 {
+		return nil, fmt.Errorf("point_ids 不能为空")
+	}
+	if len(ids) > maxQueryPoints {
+		return nil, fmt.Errorf("一次最多查询 %d 个知识点", maxQueryPoints)
+	}
+	return ids, nil
+}
+
+// PointMastery 学生对一个知识点的掌握度，Observed 为 false 时是没有作答记录的先验值
+type PointMastery struct {
+	PointID  int64   `json:"point_id"`
+	Mastery  float64 `json:"mastery"`
+	Observed bool    `json:"observed"`
+}
+
+// PriorMastery 没有作答记录时知识点的掌握度，即知识点使用的 BKT 参数中的 PInit
+func PriorMastery(pointIds []int64) (map[int64]float64, error) {
+	custom, err := repository.GetBKTParams(pointIds)
+	if err != nil {
+		return nil, err
+	}
+	fallback := configuredParams()
+	priors := make(map[int64]float64, len(pointIds))
+	for _, id := range pointIds {
+		priors[id] = paramsFor(id, custom, fallback).PInit
+	}
+	return priors, nil
+}
+
+// GetMastery 查询掌握度，参数 point_ids 为逗号分隔的知识点ID；
+// 学生只能查询自己的掌握度，拥有 group.manage 权限的账号可以通过 user_id 查询自己学生组中的学生，管理员不受限制
+func GetMastery(c *gin.Context) {
+	u := user.CurrentUser(c)
+	userId := u.Id
+	if target := c.Query("user_id"); target != "" && target != u.Id {
+		if !user.HasPermission(u.UserType, user.GroupManage) {
+			c.JSON(403, response.Error(403, "只能查询自己的掌握度"))
+			return
+		}
+		if !user.HasPermission(u.UserType, user.UserManage) && !user.IsStudentOwner(u, target, c) {
+			return
+		}
+		userId = target
+	}
+	ids, err := parsePointIds(c.Query("point_ids"))
+	if err != nil {
+		c.JSON(400, response.Error(400, err.Error()))
+		return
+	}
+	observed, err := repository.GetLearnerMastery(userId, ids)
+	if err != nil {
+		log.Errorf("load mastery of user %s failed: %v", userId, err)
+		c.JSON(500, response.Error(500, "读取掌握度失败"))
+		return
+	}
+	priors, err := PriorMastery(ids)
+	if err != nil {
+		log.Errorf("load bkt params failed: %v", err)
+		c.JSON(500, response.Error(500, "读取掌握度失败"))
+		return
+	}
+	result := make([]PointMastery, len(ids))
+	for i, id := range ids {
+		m, ok := observed[id]
+		if !ok {
+			m = priors[id]
+		}
+		result[i] = PointMastery{PointID: id, Mastery: m, Observed: ok}
+	}
+	c.JSON(200, response.Success(map[string]interface{}{
+		"user_id": userId,
+		"points":  result,
+	}))
+}
+
+// GetPointParams 查询知识点当前使用的 BKT 参数
+func GetPointParams(c *gin.Context) {
+	pointId, err := strconv.ParseInt(c.Query("point_id"), 10, 64)
+	if err != nil {
+		c.JSON(400, response.Error(400, "无效的知识点ID"))
+		return
+	}
+	custom, err := repository.GetBKTParams([]int64{pointId})
+	if err != nil {
+		log.Errorf("load bkt params failed: %v", err)
+		c.JSON(500, response.Error(500, "读取参数失败"))
+		return
+	}
+	_, customized := custom[pointId]
+	c.JSON(200, response.Success(map[string]interface{}{
+		"point_id":   pointId,
+		"params":     paramsFor(pointId, custom, configuredParams()),
+		"customized": customized,
+	}))
+}
+
+type SetParamsRequest struct {
+	PointID int64 `json:"point_id" binding:"required"`
+	Params
+}
+
+// SetPointParams 为知识点单独设置 BKT 参数
+func SetPointParams(c *gin.Context) {
+	var req SetParamsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(400, response.Error(400, "参数验证失败"))
+		return
+	}
+	if err := req.Params.validate(); err != nil {
+		c.JSON(400, response.Error(400, err.Error()))
+		return
+	}
+	params := models.BKTParams{
+		KnowledgePointID: req.PointID,
+		PInit:            req.PInit,
+		PTransit:         req.PTransit,
+		PSlip:            req.PSlip,
+		PGuess:           req.PGuess,
+	}
+	if err := repository.SaveBKTParams(&params); err != nil {
+		log.Errorf("save bkt params failed: %v", err)
+		c.JSON(500, response.Error(500, "保存参数失败"))
+		return
+	}
+	c.JSON(200, response.Success(params))
+}
+
+type ResetParamsRequest struct {
+	PointID int64 `json:"point_id" binding:"required"`
+}
+
+// ResetPointParams 删除知识点单独设置的参数，恢复使用默认参数
+func ResetPointParams(c *gin.Context) {
+	var req ResetParamsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(400, response.Error(400, "参数验证失败"))
+		return
+	}
+	if err := repository.DeleteBKTParams(req.PointID); err != nil {
+		log.Errorf("delete bkt params failed: %v", err)
+		c.JSON(500, response.Error(500, "重置参数失败"))
+		return
+	}
+	c.JSON(200, response.Success(nil))
+}
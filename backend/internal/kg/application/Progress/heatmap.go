@@ -0,0 +1,368 @@
+package progress
+
+import (
+	"bytes"
+	"encoding/csv"
+	"fmt"
+	"sort"
+	"strconv"
+	"time"
+
+	mastery "github.com/RMS_V3/internal/kg/application/Mastery"
+	"github.com/RMS_V3/internal/kg/repository"
+	"github.com/RMS_V3/internal/kg/repository/models"
+	"github.com/RMS_V3/internal/user"
+	"github.com/RMS_V3/log"
+	"github.com/RMS_V3/pkg/response"
+	"github.com/gin-gonic/gin"
+)
+
+// 热力图的取值
+const (
+	MetricMastery    = "mastery"    // BKT 掌握度
+	MetricCompletion = "completion" // 学习进度：已完成 1，学习中 0.5，未开始 0
+)
+
+const (
+	defaultWeakest = 5
+	maxWeakest     = 50
+	// weakThreshold 学生在知识点上的取值低于该值时计入 WeakPoint.Below
+	weakThreshold = 0.6
+)
+
+func completionValue(status string) float64 {
+	switch status {
+	case models.ProgressCompleted:
+		return 1
+	case models.ProgressInProgress:
+		return 0.5
+	default:
+		return 0
+	}
+}
+
+// HeatmapPoint 热力图的一列，不属于任何小节的知识点 SectionID 和 ChapterID 为 0
+type HeatmapPoint struct {
+	ID        int64  `json:"id"`
+	Name      string `json:"name"`
+	SectionID int64  `json:"section_id"`
+	ChapterID int64  `json:"chapter_id"`
+}
+
+// HeatmapGroup 按小节或章节汇总的一列，取值为其下知识点的平均值
+type HeatmapGroup struct {
+	ID       int64   `json:"id"`
+	Name     string  `json:"name"`
+	PointIDs []int64 `json:"point_ids"`
+}
+
+// HeatmapRow 一名学生的取值，Points、Sections、Chapters 与 ClassHeatmap 中的列一一对应
+type HeatmapRow struct {
+	UserID   string    `json:"user_id"`
+	Nickname string    `json:"nickname"`
+	Points   []float64 `json:"points"`
+	Sections []float64 `json:"sections"`
+	Chapters []float64 `json:"chapters"`
+	Average  float64   `json:"average"`
+}
+
+// WeakPoint 班级平均值较低的知识点
+type WeakPoint struct {
+	PointID int64   `json:"point_id"`
+	Name    string  `json:"name"`
+	Average float64 `json:"average"`
+	// Below 取值低于 weakThreshold 的学生人数
+	Below int `json:"below"`
+}
+
+// ClassHeatmap 学生 × 知识点的掌握度或完成度矩阵，以及按小节、章节的汇总和班级平均
+type ClassHeatmap struct {
+	Metric   string         `json:"metric"`
+	Points   []HeatmapPoint `json:"points"`
+	Sections []HeatmapGroup `json:"sections"`
+	Chapters []HeatmapGroup `json:"chapters"`
+	Students []HeatmapRow   `json:"students"`
+	// Average 班级平均值所在的行
+	Average HeatmapRow  `json:"average"`
+	Weakest []WeakPoint `json:"weakest"`
+}
+
+// heatmapColumns 按课程结构列出范围内的知识点、小节和章节；scope 为 0 时包含全部知识点，
+// 否则只包含该章节或小节下的知识点。没有知识点的小节和章节不出现
+func heatmapColumns(h *hierarchy, scope int64) ([]HeatmapPoint, []HeatmapGroup, []HeatmapGroup) {
+	points := []HeatmapPoint{}
+	sections := []HeatmapGroup{}
+	chapters := []HeatmapGroup{}
+	for _, chapter := range h.Chapters {
+		cg := HeatmapGroup{ID: chapter.ID, Name: chapter.Name, PointIDs: []int64{}}
+		for _, sid := range chapter.Children {
+			if scope != 0 && scope != chapter.ID && scope != sid {
+				continue
+			}
+			section := h.Sections[sid]
+			sg := HeatmapGroup{ID: section.ID, Name: section.Name, PointIDs: []int64{}}
+			for _, pid := range section.Children {
+				points = append(points, HeatmapPoint{ID: pid, Name: h.Points[pid], SectionID: sid, ChapterID: chapter.ID})
+				sg.PointIDs = append(sg.PointIDs, pid)
+			}
+			if len(sg.PointIDs) > 0 {
+				sections = append(sections, sg)
+				cg.PointIDs = append(cg.PointIDs, sg.PointIDs...)
+			}
+		}
+		if len(cg.PointIDs) > 0 {
+			chapters = append(chapters, cg)
+		}
+	}
+	if scope == 0 {
+		for _, pid := range h.Orphans {
+			points = append(points, HeatmapPoint{ID: pid, Name: h.Points[pid]})
+		}
+	}
+	return points, sections, chapters
+}
+
+func mean(values []float64) float64 {
+	if len(values) == 0 {
+		return 0
+	}
+	sum := 0.0
+	for _, v := range values {
+		sum += v
+	}
+	return sum / float64(len(values))
+}
+
+// heatmapRow 由学生在每个知识点上的取值计算小节、章节和总体平均
+func heatmapRow(values map[int64]float64, points []HeatmapPoint, sections, chapters []HeatmapGroup) HeatmapRow {
+	row := HeatmapRow{
+		Points:   make([]float64, len(points)),
+		Sections: make([]float64, len(sections)),
+		Chapters: make([]float64, len(chapters)),
+	}
+	for i, p := range points {
+		row.Points[i] = values[p.ID]
+	}
+	groupMean := func(g HeatmapGroup) float64 {
+		vs := make([]float64, len(g.PointIDs))
+		for i, id := range g.PointIDs {
+			vs[i] = values[id]
+		}
+		return mean(vs)
+	}
+	for i, g := range sections {
+		row.Sections[i] = groupMean(g)
+	}
+	for i, g := range chapters {
+		row.Chapters[i] = groupMean(g)
+	}
+	row.Average = mean(row.Points)
+	return row
+}
+
+// buildHeatmap 生成热力图，values 为每名学生在各知识点上的取值，没有记录的知识点取 0；
+// 班级平均最低的 weakest 个知识点按平均值从低到高列出
+func buildHeatmap(metric string, points []HeatmapPoint, sections, chapters []HeatmapGroup,
+	students []user.UserIdNick, values map[string]map[int64]float64, weakest int) ClassHeatmap {
+	heatmap := ClassHeatmap{
+		Metric:   metric,
+		Points:   points,
+		Sections: sections,
+		Chapters: chapters,
+		Students: make([]HeatmapRow, 0, len(students)),
+		Weakest:  []WeakPoint{},
+	}
+	averages := make(map[int64]float64, len(points))
+	below := make(map[int64]int, len(points))
+	for _, s := range students {
+		row := heatmapRow(values[s.User_id], points, sections, chapters)
+		row.UserID, row.Nickname = s.User_id, s.Nickname
+		heatmap.Students = append(heatmap.Students, row)
+		for _, p := range points {
+			v := values[s.User_id][p.ID]
+			averages[p.ID] += v
+			if v < weakThreshold {
+				below[p.ID]++
+			}
+		}
+	}
+	if len(students) > 0 {
+		for id := range averages {
+			averages[id] /= float64(len(students))
+		}
+	}
+	heatmap.Average = heatmapRow(averages, points, sections, chapters)
+	heatmap.Average.Nickname = "班级平均"
+
+	if len(students) == 0 {
+		return heatmap
+	}
+	weak := make([]WeakPoint, len(points))
+	for i, p := range points {
+		weak[i] = WeakPoint{PointID: p.ID, Name: p.Name, Average: averages[p.ID], Below: below[p.ID]}
+	}
+	sort.SliceStable(weak, func(i, j int) bool { return weak[i].Average < weak[j].Average })
+	if len(weak) > weakest {
+		weak = weak[:weakest]
+	}
+	heatmap.Weakest = weak
+	return heatmap
+}
+
+// writeHeatmapCSV 导出热力图：每名学生一行，依次为知识点、小节、章节和总体平均，最后一行为班级平均
+func writeHeatmapCSV(heatmap ClassHeatmap) ([]byte, error) {
+	var buf bytes.Buffer
+	// 写入 BOM，便于 Excel 正确识别中文
+	buf.WriteString("\xef\xbb\xbf")
+	w := csv.NewWriter(&buf)
+	header := []string{"学生ID", "昵称"}
+	for _, p := range heatmap.Points {
+		header = append(header, p.Name)
+	}
+	for _, s := range heatmap.Sections {
+		header = append(header, "小节:"+s.Name)
+	}
+	for _, c := range heatmap.Chapters {
+		header = append(header, "章节:"+c.Name)
+	}
+	header = append(header, "平均")
+	if err := w.Write(header); err != nil {
+		return nil, err
+	}
+	rows := append(append([]HeatmapRow(nil), heatmap.Students...), heatmap.Average)
+	for _, row := range rows {
+		record := []string{row.UserID, row.Nickname}
+		for _, values := range [][]float64{row.Points, row.Sections, row.Chapters, {row.Average}} {
+			for _, v := range values {
+				record = append(record, strconv.FormatFloat(v, 'f', 2, 64))
+			}
+		}
+		if err := w.Write(record); err != nil {
+			return nil, err
+		}
+	}
+	w.Flush()
+	return buf.Bytes(), w.Error()
+}
+
+// classValues 读取学生在知识点上的取值，没有作答记录的知识点取掌握度先验值，与 GetMastery 一致
+func classValues(metric string, userIds []string, pointIds []int64) (map[string]map[int64]float64, error) {
+	if metric == MetricMastery {
+		observed, err := repository.GetUsersMastery(userIds, pointIds)
+		if err != nil {
+			return nil, err
+		}
+		priors, err := mastery.PriorMastery(pointIds)
+		if err != nil {
+			return nil, err
+		}
+		return fillPriors(observed, userIds, priors), nil
+	}
+	progress, err := repository.GetUsersProgress(userIds, pointIds)
+	if err != nil {
+		return nil, err
+	}
+	values := make(map[string]map[int64]float64, len(progress))
+	for userId, records := range progress {
+		values[userId] = make(map[int64]float64, len(records))
+		for pointId, record := range records {
+			values[userId][pointId] = completionValue(record.Status)
+		}
+	}
+	return values, nil
+}
+
+// fillPriors 为没有记录的学生和知识点补上先验值
+func fillPriors(observed map[string]map[int64]float64, userIds []string, priors map[int64]float64) map[string]map[int64]float64 {
+	values := make(map[string]map[int64]float64, len(userIds))
+	for _, userId := range userIds {
+		values[userId] = make(map[int64]float64, len(priors))
+		for pointId, prior := range priors {
+			values[userId][pointId] = prior
+		}
+		for pointId, v := range observed[userId] {
+			values[userId][pointId] = v
+		}
+	}
+	return values
+}
+
+// GetClassHeatmap 教师查看学生组的掌握度或完成度热力图，可以限定到章节或小节，
+// format=csv 时导出为 CSV 文件；只有组的创建者和拥有 user.manage 权限的账号可以查看
+func GetClassHeatmap(c *gin.Context) {
+	u := user.CurrentUser(c)
+	groupId, err := strconv.Atoi(c.Query("group_id"))
+	if err != nil {
+		c.JSON(400, response.Error(400, "无效的学生组ID"))
+		return
+	}
+	if !user.HasPermission(u.UserType, user.UserManage) && !user.IsGroupOwner(u, groupId, c) {
+		return
+	}
+	metric := c.DefaultQuery("metric", MetricMastery)
+	if metric != MetricMastery && metric != MetricCompletion {
+		c.JSON(400, response.Error(400, fmt.Sprintf("metric 只能是 %s 或 %s", MetricMastery, MetricCompletion)))
+		return
+	}
+	var scope int64
+	if raw := c.Query("scope_id"); raw != "" {
+		if scope, err = strconv.ParseInt(raw, 10, 64); err != nil {
+			c.JSON(400, response.Error(400, "无效的章节或小节ID"))
+			return
+		}
+	}
+	weakest := defaultWeakest
+	if raw := c.Query("weakest"); raw != "" {
+		if weakest, err = strconv.Atoi(raw); err != nil || weakest < 1 || weakest > maxWeakest {
+			c.JSON(400, response.Error(400, fmt.Sprintf("weakest 必须在 1 到 %d 之间", maxWeakest)))
+			return
+		}
+	}
+
+	group, err := user.GetGroupDB(groupId)
+	if err != nil {
+		log.Errorf("load group %d failed: %v", groupId, err)
+		c.JSON(500, response.Error(500, "读取学生组失败"))
+		return
+	}
+	h, err := loadHierarchy()
+	if err != nil {
+		log.Errorf("load course hierarchy failed: %v", err)
+		c.JSON(500, response.Error(500, "读取课程结构失败"))
+		return
+	}
+	points, sections, chapters := heatmapColumns(h, scope)
+	if scope != 0 && len(points) == 0 {
+		c.JSON(404, response.Error(404, "章节或小节不存在或没有知识点"))
+		return
+	}
+	userIds := make([]string, len(group.Users))
+	for i, s := range group.Users {
+		userIds[i] = s.User_id
+	}
+	pointIds := make([]int64, len(points))
+	for i, p := range points {
+		pointIds[i] = p.ID
+	}
+	values, err := classValues(metric, userIds, pointIds)
+	if err != nil {
+		log.Errorf("load %s of group %d failed: %v", metric, groupId, err)
+		c.JSON(500, response.Error(500, "读取学生数据失败"))
+		return
+	}
+	heatmap := buildHeatmap(metric, points, sections, chapters, group.Users, values, weakest)
+
+	if c.Query("format") != "csv" {
+		c.JSON(200, response.Success(heatmap))
+		return
+	}
+	data, err := writeHeatmapCSV(heatmap)
+	if err != nil {
+		log.Errorf("write heatmap csv failed: %v", err)
+		c.JSON(500, response.Error(500, "导出失败"))
+		return
+	}
+	filename := fmt.Sprintf("group_%d_%s_%s.csv", groupId, metric, time.Now().Format("20060102"))
+	c.Header("Content-Disposition", "attachment; filename="+filename)
+	c.Data(200, "text/csv; charset=utf-8", data)
+}
@@ -0,0 +1,89 @@
+package progress
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/RMS_V3/internal/user"
+)
+
+func heatmapHierarchy() *hierarchy {
+	return &hierarchy{
+		Chapters: []hierarchyNode{{ID: 100, Name: "第一章", Children: []int64{10, 11}}, {ID: 101, Name: "第二章"}},
+		Sections: map[int64]hierarchyNode{
+			10: {ID: 10, Name: "1.1", Children: []int64{1, 2}},
+			11: {ID: 11, Name: "1.2", Children: []int64{3}},
+		},
+		Points:  map[int64]string{1: "a", 2: "b", 3: "c", 5: "e"},
+		Orphans: []int64{5},
+	}
+}
+
+func TestHeatmapColumns(t *testing.T) {
+	h := heatmapHierarchy()
+	points, sections, chapters := heatmapColumns(h, 0)
+	if len(points) != 4 || len(sections) != 2 || len(chapters) != 1 {
+		t.Fatalf("got %d points, %d sections, %d chapters", len(points), len(sections), len(chapters))
+	}
+	if points[3].ID != 5 || points[3].SectionID != 0 {
+		t.Errorf("orphan column = %+v", points[3])
+	}
+	points, sections, chapters = heatmapColumns(h, 11)
+	if len(points) != 1 || points[0].ID != 3 || len(sections) != 1 || len(chapters) != 1 || len(chapters[0].PointIDs) != 1 {
+		t.Errorf("section scope gave %v %v %v", points, sections, chapters)
+	}
+	if points, _, _ := heatmapColumns(h, 999); len(points) != 0 {
+		t.Errorf("unknown scope gave %v", points)
+	}
+}
+
+func TestBuildHeatmap(t *testing.T) {
+	points, sections, chapters := heatmapColumns(heatmapHierarchy(), 100)
+	students := []user.UserIdNick{{User_id: "s1", Nickname: "甲"}, {User_id: "s2", Nickname: "乙"}}
+	values := map[string]map[int64]float64{
+		"s1": {1: 1, 2: 0.5, 3: 0.2},
+		"s2": {1: 0.8, 3: 0.4},
+	}
+	hm := buildHeatmap(MetricMastery, points, sections, chapters, students, values, 2)
+
+	s1 := hm.Students[0]
+	if s1.Sections[0] != 0.75 || s1.Sections[1] != 0.2 {
+		t.Errorf("s1 sections = %v", s1.Sections)
+	}
+	if got := hm.Average.Points; got[0] != 0.9 || got[1] != 0.25 || !near(got[2], 0.3) {
+		t.Errorf("class averages = %v", got)
+	}
+	if len(hm.Weakest) != 2 || hm.Weakest[0].PointID != 2 || hm.Weakest[1].PointID != 3 || hm.Weakest[1].Below != 2 {
+		t.Errorf("weakest = %+v", hm.Weakest)
+	}
+
+	data, err := writeHeatmapCSV(hm)
+	if err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSpace(strings.TrimPrefix(string(data), "\xef\xbb\xbf")), "\n")
+	if len(lines) != 4 {
+		t.Fatalf("csv has %d lines, want header, 2 students and average", len(lines))
+	}
+	if lines[0] != "学生ID,昵称,a,b,c,小节:1.1,小节:1.2,章节:第一章,平均" {
+		t.Errorf("header = %q", lines[0])
+	}
+	if lines[1] != "s1,甲,1.00,0.50,0.20,0.75,0.20,0.57,0.57" {
+		t.Errorf("row = %q", lines[1])
+	}
+}
+
+func near(a, b float64) bool {
+	return a-b < 1e-9 && b-a < 1e-9
+}
+
+func TestFillPriors(t *testing.T) {
+	observed := map[string]map[int64]float64{"s1": {1: 0.9}}
+	values := fillPriors(observed, []string{"s1", "s2"}, map[int64]float64{1: 0.1, 2: 0.3})
+	if values["s1"][1] != 0.9 || values["s1"][2] != 0.3 {
+		t.Errorf("s1 = %v", values["s1"])
+	}
+	if values["s2"][1] != 0.1 || values["s2"][2] != 0.3 {
+		t.Errorf("student without records = %v", values["s2"])
+	}
+}
@@ -0,0 +1,271 @@
+package progress
+
+import (
+	"errors"
+	"time"
+
+	"github.com/RMS_V3/internal/kg/domain"
+	"github.com/RMS_V3/internal/kg/repository/models"
+	"github.com/RMS_V3/middleware/neo4jUtils"
+)
+
+type hierarchyNode struct {
+	ID       int64
+	Name     string
+	Children []int64
+}
+
+// hierarchy 课程的章节-小节-知识点结构，不属于任何小节的知识点放在 Orphans 中；
+// 被多个章节（小节）包含的小节（知识点）只归入第一个
+type hierarchy struct {
+	Chapters []hierarchyNode
+	Sections map[int64]hierarchyNode
+	Points   map[int64]string
+	Orphans  []int64
+}
+
+// loadHierarchy 从 Neo4j 读取全部章节、小节和知识点及其包含关系
+func loadHierarchy() (*hierarchy, error) {
+	session := neo4jUtils.GetSession()
+	if session == nil {
+		return nil, errors.New("无法获取 Neo4j 会话")
+	}
+	defer session.Close()
+
+	h := &hierarchy{Sections: make(map[int64]hierarchyNode), Points: make(map[int64]string)}
+	result, err := session.Run(`
+	MATCH (c:chapter)
+	OPTIONAL MATCH (c)-[:包含]->(s:section)
+	RETURN id(c) AS chapterId, c.name AS chapterName, id(s) AS sectionId, s.name AS sectionName
+	ORDER BY id(c), id(s)`, nil)
+	if err != nil {
+		return nil, err
+	}
+	chapterIndex := make(map[int64]int)
+	for result.Next() {
+		record := result.Record()
+		chapterId, _ := record.Get("chapterId")
+		chapterName, _ := record.Get("chapterName")
+		sectionId, _ := record.Get("sectionId")
+		sectionName, _ := record.Get("sectionName")
+		cid, ok := chapterId.(int64)
+		if !ok {
+			continue
+		}
+		i, exists := chapterIndex[cid]
+		if !exists {
+			name, _ := chapterName.(string)
+			i = len(h.Chapters)
+			chapterIndex[cid] = i
+			h.Chapters = append(h.Chapters, hierarchyNode{ID: cid, Name: name})
+		}
+		if sid, ok := sectionId.(int64); ok {
+			if _, seen := h.Sections[sid]; !seen {
+				name, _ := sectionName.(string)
+				h.Sections[sid] = hierarchyNode{ID: sid, Name: name}
+				h.Chapters[i].Children = append(h.Chapters[i].Children, sid)
+			}
+		}
+	}
+	if err := result.Err(); err != nil {
+		return nil, err
+	}
+
+	result, err = session.Run(`
+	MATCH (p:point)
+	OPTIONAL MATCH (s:section)-[:包含]->(p)
+	RETURN id(p) AS pointId, p.name AS pointName, id(s) AS sectionId
+	ORDER BY id(p)`, nil)
+	if err != nil {
+		return nil, err
+	}
+	for result.Next() {
+		record := result.Record()
+		pointId, _ := record.Get("pointId")
+		pointName, _ := record.Get("pointName")
+		sectionId, _ := record.Get("sectionId")
+		pid, ok := pointId.(int64)
+		if !ok {
+			continue
+		}
+		if _, seen := h.Points[pid]; seen {
+			continue
+		}
+		h.Points[pid], _ = pointName.(string)
+		sid, ok := sectionId.(int64)
+		section, exists := h.Sections[sid]
+		if !ok || !exists {
+			h.Orphans = append(h.Orphans, pid)
+			continue
+		}
+		section.Children = append(section.Children, pid)
+		h.Sections[sid] = section
+	}
+	return h, result.Err()
+}
+
+// ProgressCount 一组知识点的进度统计
+type ProgressCount struct {
+	Total      int     `json:"total"`
+	Completed  int     `json:"completed"`
+	InProgress int     `json:"in_progress"`
+	NotStarted int     `json:"not_started"`
+	Percent    float64 `json:"percent"` // 已完成知识点所占百分比
+}
+
+func (pc *ProgressCount) add(status string) {
+	pc.Total++
+	switch status {
+	case models.ProgressCompleted:
+		pc.Completed++
+	case models.ProgressInProgress:
+		pc.InProgress++
+	default:
+		pc.NotStarted++
+	}
+}
+
+func (pc *ProgressCount) merge(other ProgressCount) {
+	pc.Total += other.Total
+	pc.Completed += other.Completed
+	pc.InProgress += other.InProgress
+	pc.NotStarted += other.NotStarted
+}
+
+func (pc *ProgressCount) finish() {
+	if pc.Total > 0 {
+		pc.Percent = float64(pc.Completed) * 100 / float64(pc.Total)
+	}
+}
+
+// PointProgress 单个知识点的进度
+type PointProgress struct {
+	ID          int64      `json:"id"`
+	Name        string     `json:"name"`
+	Status      string     `json:"status"`
+	StartedAt   *time.Time `json:"started_at,omitempty"`
+	CompletedAt *time.Time `json:"completed_at,omitempty"`
+}
+
+type SectionProgress struct {
+	ID   int64  `json:"id"`
+	Name string `json:"name"`
+	ProgressCount
+	Points []PointProgress `json:"points"`
+}
+
+type ChapterProgress struct {
+	ID   int64  `json:"id"`
+	Name string `json:"name"`
+	ProgressCount
+	Sections []SectionProgress `json:"sections"`
+}
+
+type ProgressSummary struct {
+	Overall  ProgressCount     `json:"overall"`
+	Chapters []ChapterProgress `json:"chapters"`
+	// Ungrouped 不属于任何小节的知识点
+	Ungrouped []PointProgress `json:"ungrouped"`
+}
+
+func pointProgress(id int64, name string, progress map[int64]models.LearnerProgress) PointProgress {
+	p := PointProgress{ID: id, Name: name, Status: models.ProgressNotStarted}
+	if record, ok := progress[id]; ok {
+		p.Status = record.Status
+		p.StartedAt = record.StartedAt
+		p.CompletedAt = record.CompletedAt
+	}
+	return p
+}
+
+// summarize 按章节和小节汇总进度，章节的统计包含其下全部小节的知识点
+func summarize(h *hierarchy, progress map[int64]models.LearnerProgress) ProgressSummary {
+	summary := ProgressSummary{Chapters: []ChapterProgress{}, Ungrouped: []PointProgress{}}
+	for _, chapter := range h.Chapters {
+		cp := ChapterProgress{ID: chapter.ID, Name: chapter.Name, Sections: []SectionProgress{}}
+		for _, sid := range chapter.Children {
+			section := h.Sections[sid]
+			sp := SectionProgress{ID: section.ID, Name: section.Name, Points: []PointProgress{}}
+			for _, pid := range section.Children {
+				p := pointProgress(pid, h.Points[pid], progress)
+				sp.add(p.Status)
+				sp.Points = append(sp.Points, p)
+			}
+			sp.finish()
+			cp.merge(sp.ProgressCount)
+			cp.Sections = append(cp.Sections, sp)
+		}
+		cp.finish()
+		summary.Overall.merge(cp.ProgressCount)
+		summary.Chapters = append(summary.Chapters, cp)
+	}
+	for _, pid := range h.Orphans {
+		p := pointProgress(pid, h.Points[pid], progress)
+		summary.Overall.add(p.Status)
+		summary.Ungrouped = append(summary.Ungrouped, p)
+	}
+	summary.Overall.finish()
+	return summary
+}
+
+// ProgressNode 带进度信息的图谱节点，章节和小节的进度为其下知识点的汇总
+type ProgressNode struct {
+	domain.Node
+	Status   string         `json:"status"`
+	Progress *ProgressCount `json:"progress,omitempty"`
+}
+
+// ProgressGraph 叠加了学习进度的课程结构图
+type ProgressGraph struct {
+	Nodes []ProgressNode `json:"nodes"`
+	Links []domain.Link  `json:"links"`
+}
+
+// aggregateStatus 由汇总统计得出章节或小节的状态
+func aggregateStatus(pc ProgressCount) string {
+	switch {
+	case pc.Total > 0 && pc.Completed == pc.Total:
+		return models.ProgressCompleted
+	case pc.Completed > 0 || pc.InProgress > 0:
+		return models.ProgressInProgress
+	default:
+		return models.ProgressNotStarted
+	}
+}
+
+// progressGraph 生成章节-小节-知识点的包含关系图，并在每个节点上标注进度
+func progressGraph(h *hierarchy, progress map[int64]models.LearnerProgress) ProgressGraph {
+	summary := summarize(h, progress)
+	graph := ProgressGraph{Nodes: []ProgressNode{}, Links: []domain.Link{}}
+	addPoint := func(p PointProgress) {
+		graph.Nodes = append(graph.Nodes, ProgressNode{
+			Node:   domain.Node{ID: p.ID, Name: p.Name, Type: "point"},
+			Status: p.Status,
+		})
+	}
+	for _, chapter := range summary.Chapters {
+		count := chapter.ProgressCount
+		graph.Nodes = append(graph.Nodes, ProgressNode{
+			Node:     domain.Node{ID: chapter.ID, Name: chapter.Name, Type: "chapter"},
+			Status:   aggregateStatus(count),
+			Progress: &count,
+		})
+		for _, section := range chapter.Sections {
+			count := section.ProgressCount
+			graph.Nodes = append(graph.Nodes, ProgressNode{
+				Node:     domain.Node{ID: section.ID, Name: section.Name, Type: "section"},
+				Status:   aggregateStatus(count),
+				Progress: &count,
+			})
+			graph.Links = append(graph.Links, domain.Link{Source: int(chapter.ID), Target: int(section.ID), Type: "包含"})
+			for _, p := range section.Points {
+				addPoint(p)
+				graph.Links = append(graph.Links, domain.Link{Source: int(section.ID), Target: int(p.ID), Type: "包含"})
+			}
+		}
+	}
+	for _, p := range summary.Ungrouped {
+		addPoint(p)
+	}
+	return graph
+}
@@ -0,0 +1,146 @@
+package progress
+
+import (
+	"errors"
+	"time"
+
+	"github.com/RMS_V3/internal/kg/repository"
+	"github.com/RMS_V3/internal/kg/repository/models"
+	"github.com/RMS_V3/internal/user"
+	"github.com/RMS_V3/log"
+	"github.com/RMS_V3/pkg/response"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// 学习事件类型
+const (
+	EventView          = "view"           // 查看视频、课件或习题
+	EventVideoFinished = "video_finished" // 看完视频，只由观看心跳在服务端产生
+)
+
+// pointCompleted 判断知识点是否已完成：看完全部视频，且题库中有习题时至少作答过一道；
+// 既没有视频也没有习题的知识点查看过资源即视为完成
+func pointCompleted(pc repository.PointCompletion) bool {
+	if pc.Videos == 0 && pc.Exercises == 0 {
+		return true
+	}
+	if pc.FinishedVideos < pc.Videos {
+		return false
+	}
+	return pc.Exercises == 0 || pc.SubmittedExercises > 0
+}
+
+// RecordEvent 根据学习事件更新学生的资源完成情况和知识点进度，返回资源所属的知识点
+func RecordEvent(userId string, event string, resourceType string, resourceId int64) (int64, error) {
+	pointId, err := repository.ResourcePointID(resourceType, resourceId)
+	if err != nil {
+		return 0, err
+	}
+	if event == EventVideoFinished {
+		if err := repository.SaveResourceFeedback(userId, resourceType, resourceId, nil, true); err != nil {
+			return 0, err
+		}
+	}
+	return pointId, updatePointProgress(userId, pointId)
+}
+
+// RecordView 记录学生查看了知识点的资源
+func RecordView(userId string, pointId int64) error {
+	return updatePointProgress(userId, pointId)
+}
+
+func updatePointProgress(userId string, pointId int64) error {
+	now := time.Now()
+	pc, err := repository.GetPointCompletion(userId, pointId)
+	if err != nil {
+		return err
+	}
+	if pointCompleted(pc) {
+		return repository.MarkPointCompleted(userId, pointId, now)
+	}
+	return repository.MarkPointInProgress(userId, pointId, now)
+}
+
+// RecordViewFromRequest 请求携带学生登录信息时记录资源查看，未登录或记录失败不影响原请求
+func RecordViewFromRequest(c *gin.Context, pointId int64) {
+	u := user.CurrentUser(c)
+	if u == nil || u.UserType != user.Student {
+		return
+	}
+	if err := RecordView(u.Id, pointId); err != nil {
+		log.Errorf("record view of point %d by user %s failed: %v", pointId, u.Id, err)
+	}
+}
+
+type ProgressEventRequest struct {
+	Event        string `json:"event" binding:"required,oneof=view"`
+	ResourceType string `json:"resource_type" binding:"required,oneof=video courseware exercise"`
+	ResourceID   int64  `json:"resource_id" binding:"required"`
+}
+
+// ReportProgressEvent 学生上报查看资源。看完视频和作答习题由观看心跳和判分接口在服务端记录，
+// 掌握度也只根据服务端判分的作答更新
+func ReportProgressEvent(c *gin.Context) {
+	u := user.CurrentUser(c)
+	var req ProgressEventRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(400, response.Error(400, "参数验证失败"))
+		return
+	}
+	if _, err := RecordEvent(u.Id, req.Event, req.ResourceType, req.ResourceID); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(404, response.Error(404, "资源不存在"))
+			return
+		}
+		log.Errorf("record progress event failed: %v", err)
+		c.JSON(500, response.Error(500, "记录学习进度失败"))
+		return
+	}
+	c.JSON(200, response.Success(nil))
+}
+
+// loadOwnProgress 读取当前学生的课程结构和进度，失败时已写入响应
+func loadOwnProgress(c *gin.Context) (*hierarchy, map[int64]models.LearnerProgress, bool) {
+	u := user.CurrentUser(c)
+	h, err := loadHierarchy()
+	if err != nil {
+		log.Errorf("load course hierarchy failed: %v", err)
+		c.JSON(500, response.Error(500, "读取课程结构失败"))
+		return nil, nil, false
+	}
+	progress, err := repository.GetLearnerProgress(u.Id)
+	if err != nil {
+		log.Errorf("load progress of user %s failed: %v", u.Id, err)
+		c.JSON(500, response.Error(500, "读取学习进度失败"))
+		return nil, nil, false
+	}
+	return h, progress, true
+}
+
+// GetProgressGraph 以章节-小节-知识点图的形式返回当前学生的学习进度
+func GetProgressGraph(c *gin.Context) {
+	h, progress, ok := loadOwnProgress(c)
+	if !ok {
+		return
+	}
+	c.JSON(200, response.Success(progressGraph(h, progress)))
+}
+
+// GetProgressSummary 按章节和小节汇总当前学生的学习进度
+func GetProgressSummary(c *gin.Context) {
+	h, progress, ok := loadOwnProgress(c)
+	if !ok {
+		return
+	}
+	c.JSON(200, response.Success(summarize(h, progress)))
+}
+
+// RecordQuizResult 根据测验中某个知识点的结果更新进度，通过时标记为已完成，否则标记为学习中
+func RecordQuizResult(userId string, pointId int64, passed bool) error {
+	now := time.Now()
+	if passed {
+		return repository.MarkPointCompleted(userId, pointId, now)
+	}
+	return repository.MarkPointInProgress(userId, pointId, now)
+}
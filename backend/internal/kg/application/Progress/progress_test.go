@@ -0,0 +1,81 @@
+package progress
+
+import (
+	"testing"
+
+	"github.com/RMS_V3/internal/kg/repository"
+	"github.com/RMS_V3/internal/kg/repository/models"
+)
+
+func TestPointCompleted(t *testing.T) {
+	cases := []struct {
+		pc   repository.PointCompletion
+		want bool
+	}{
+		{repository.PointCompletion{}, true},
+		{repository.PointCompletion{Videos: 2, FinishedVideos: 1}, false},
+		{repository.PointCompletion{Videos: 2, FinishedVideos: 2}, true},
+		{repository.PointCompletion{Videos: 1, FinishedVideos: 1, Exercises: 3}, false},
+		{repository.PointCompletion{Videos: 1, FinishedVideos: 1, Exercises: 3, SubmittedExercises: 1}, true},
+		{repository.PointCompletion{Exercises: 2, SubmittedExercises: 1}, true},
+	}
+	for i, tc := range cases {
+		if got := pointCompleted(tc.pc); got != tc.want {
+			t.Errorf("case %d: pointCompleted(%+v) = %v, want %v", i, tc.pc, got, tc.want)
+		}
+	}
+}
+
+func TestSummarize(t *testing.T) {
+	// 第一章包含两个小节，第二章没有小节，知识点 5 不属于任何小节
+	h := &hierarchy{
+		Chapters: []hierarchyNode{{ID: 100, Name: "第一章", Children: []int64{10, 11}}, {ID: 101, Name: "第二章"}},
+		Sections: map[int64]hierarchyNode{
+			10: {ID: 10, Name: "1.1", Children: []int64{1, 2}},
+			11: {ID: 11, Name: "1.2", Children: []int64{3, 4}},
+		},
+		Points:  map[int64]string{1: "a", 2: "b", 3: "c", 4: "d", 5: "e"},
+		Orphans: []int64{5},
+	}
+	progress := map[int64]models.LearnerProgress{
+		1: {Status: models.ProgressCompleted},
+		2: {Status: models.ProgressCompleted},
+		3: {Status: models.ProgressInProgress},
+		5: {Status: models.ProgressCompleted},
+	}
+	s := summarize(h, progress)
+	if len(s.Chapters) != 2 || len(s.Chapters[0].Sections) != 2 {
+		t.Fatalf("unexpected structure: %+v", s)
+	}
+	first := s.Chapters[0]
+	if first.Total != 4 || first.Completed != 2 || first.InProgress != 1 || first.NotStarted != 1 || first.Percent != 50 {
+		t.Errorf("unexpected chapter progress: %+v", first.ProgressCount)
+	}
+	if sec := first.Sections[0]; sec.Completed != 2 || sec.Percent != 100 {
+		t.Errorf("unexpected section progress: %+v", sec.ProgressCount)
+	}
+	if s.Overall.Total != 5 || s.Overall.Completed != 3 {
+		t.Errorf("unexpected overall progress: %+v", s.Overall)
+	}
+
+	g := progressGraph(h, progress)
+	if len(g.Nodes) != 9 || len(g.Links) != 6 {
+		t.Fatalf("expected 9 nodes and 6 links, got %d and %d", len(g.Nodes), len(g.Links))
+	}
+	status := make(map[int64]string)
+	for _, n := range g.Nodes {
+		status[n.ID] = n.Status
+	}
+	want := map[int64]string{
+		100: models.ProgressInProgress,
+		101: models.ProgressNotStarted,
+		10:  models.ProgressCompleted,
+		11:  models.ProgressInProgress,
+		4:   models.ProgressNotStarted,
+	}
+	for id, w := range want {
+		if status[id] != w {
+			t.Errorf("node %d status = %s, want %s", id, status[id], w)
+		}
+	}
+}
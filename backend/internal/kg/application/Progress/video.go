@@ -0,0 +1,270 @@
+package progress
+
+import (
+	"encoding/json"
+	"errors"
+	"math"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/RMS_V3/config"
+	"github.com/RMS_V3/internal/kg/repository"
+	"github.com/RMS_V3/internal/kg/repository/models"
+	"github.com/RMS_V3/internal/user"
+	"github.com/RMS_V3/log"
+	"github.com/RMS_V3/pkg/response"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// watchedInterval 已观看的区间 [起点, 终点)，单位秒
+type watchedInterval [2]float64
+
+// restartTail 上次停在视频最后这一比例内时，下次从头播放
+const restartTail = 0.05
+
+// videoSettings 判定视频观看进度的参数
+type videoSettings struct {
+	CompletionThreshold float64
+	MaxPlaybackRate     float64
+	HeartbeatSlack      float64
+	MaxHeartbeatGap     float64
+}
+
+var defaultVideoSettings = videoSettings{CompletionThreshold: 0.9, MaxPlaybackRate: 2, HeartbeatSlack: 5, MaxHeartbeatGap: 60}
+
+// videoSettingsFromConfig 逐项取配置值，未配置或取值无效的项使用默认值
+func videoSettingsFromConfig(cfg *config.VideoConfig) videoSettings {
+	settings := defaultVideoSettings
+	if cfg == nil {
+		return settings
+	}
+	if cfg.CompletionThreshold > 0 && cfg.CompletionThreshold <= 1 {
+		settings.CompletionThreshold = cfg.CompletionThreshold
+	}
+	if cfg.MaxPlaybackRate > 0 {
+		settings.MaxPlaybackRate = cfg.MaxPlaybackRate
+	}
+	if cfg.HeartbeatSlack > 0 {
+		settings.HeartbeatSlack = cfg.HeartbeatSlack
+	}
+	if cfg.MaxHeartbeatGap > 0 {
+		settings.MaxHeartbeatGap = cfg.MaxHeartbeatGap
+	}
+	return settings
+}
+
+func configuredVideoSettings() videoSettings {
+	return videoSettingsFromConfig(config.GetGlobalConfig().VideoConfig)
+}
+
+// mergeInterval 把 [start, end) 并入已排序且互不重叠的区间，相交或相接的区间合并为一个
+func mergeInterval(intervals []watchedInterval, start, end float64) []watchedInterval {
+	if end <= start {
+		return intervals
+	}
+	all := append(append([]watchedInterval(nil), intervals...), watchedInterval{start, end})
+	sort.Slice(all, func(i, j int) bool { return all[i][0] < all[j][0] })
+	merged := all[:1]
+	for _, iv := range all[1:] {
+		last := &merged[len(merged)-1]
+		if iv[0] <= last[1] {
+			last[1] = math.Max(last[1], iv[1])
+			continue
+		}
+		merged = append(merged, iv)
+	}
+	return merged
+}
+
+// coveredSeconds 区间在 [0, duration) 内覆盖的总时长
+func coveredSeconds(intervals []watchedInterval, duration float64) float64 {
+	total := 0.0
+	for _, iv := range intervals {
+		start, end := math.Max(iv[0], 0), math.Min(iv[1], duration)
+		if end > start {
+			total += end - start
+		}
+	}
+	return total
+}
+
+// durationTolerance 同一视频不同播放器上报的时长允许的误差（秒）
+const durationTolerance = 1
+
+var errDurationChanged = errors.New("视频时长与之前上报的不一致")
+
+// heartbeatDuration 确定计算覆盖率使用的视频时长：视频记录中有时长时以记录为准，忽略上报的时长；
+// 否则在第一次心跳时确定，之后上报的时长可以变长（播放器加载完元数据后修正），但不能变短
+func heartbeatDuration(w *models.VideoWatch, known, reported float64) (float64, error) {
+	if known > 0 {
+		return known, nil
+	}
+	if w.Duration > 0 && reported < w.Duration-durationTolerance {
+		return 0, errDurationChanged
+	}
+	return math.Max(w.Duration, reported), nil
+}
+
+// applyHeartbeat 根据心跳更新观看记录，返回本次心跳是否使视频变为看完。
+// 上次心跳到本次心跳之间播放位置的前进不超过经过时间 × 最大倍速（加上延迟余量）时才计入观看区间，
+// 倒退、拖动进度条和间隔过久的心跳只更新播放位置
+func applyHeartbeat(w *models.VideoWatch, intervals []watchedInterval, position, duration float64,
+	now time.Time, s videoSettings) ([]watchedInterval, bool) {
+	position = math.Min(position, duration)
+	if !w.UpdatedAt.IsZero() {
+		elapsed := now.Sub(w.UpdatedAt).Seconds()
+		advance := position - w.LastPosition
+		if elapsed <= s.MaxHeartbeatGap && advance > 0 && advance <= elapsed*s.MaxPlaybackRate+s.HeartbeatSlack {
+			intervals = mergeInterval(intervals, w.LastPosition, position)
+		}
+	}
+	w.Duration = duration
+	w.LastPosition = position
+	w.WatchedSeconds = coveredSeconds(intervals, duration)
+	w.UpdatedAt = now
+	if w.Completed || w.WatchedSeconds < s.CompletionThreshold*duration {
+		return intervals, false
+	}
+	w.Completed = true
+	w.CompletedAt = &now
+	return intervals, true
+}
+
+// VideoState 学生观看视频的进度
+type VideoState struct {
+	VideoID        int64   `json:"video_id"`
+	Duration       float64 `json:"duration"`
+	WatchedSeconds float64 `json:"watched_seconds"`
+	Coverage       float64 `json:"coverage"`
+	Completed      bool    `json:"completed"`
+	ResumePosition float64 `json:"resume_position"`
+}
+
+func videoState(w *models.VideoWatch) VideoState {
+	state := VideoState{
+		VideoID:        w.VideoID,
+		Duration:       w.Duration,
+		WatchedSeconds: w.WatchedSeconds,
+		Completed:      w.Completed,
+		ResumePosition: w.LastPosition,
+	}
+	if w.Duration > 0 {
+		state.Coverage = math.Min(w.WatchedSeconds/w.Duration, 1)
+		if w.LastPosition >= w.Duration*(1-restartTail) {
+			state.ResumePosition = 0
+		}
+	}
+	return state
+}
+
+func decodeIntervals(w *models.VideoWatch) ([]watchedInterval, error) {
+	intervals := []watchedInterval{}
+	if w.Intervals == "" {
+		return intervals, nil
+	}
+	if err := json.Unmarshal([]byte(w.Intervals), &intervals); err != nil {
+		return nil, err
+	}
+	return intervals, nil
+}
+
+type VideoHeartbeatRequest struct {
+	VideoID  int64    `json:"video_id" binding:"required"`
+	Position *float64 `json:"position" binding:"required,min=0"`
+	// Duration 播放器上报的时长，只在视频记录没有时长时使用
+	Duration float64 `json:"duration" binding:"required,gt=0"`
+}
+
+// VideoHeartbeat 播放器定时上报当前播放位置，记录已观看区间；覆盖率达到阈值时视频记为看完并更新知识点进度
+func VideoHeartbeat(c *gin.Context) {
+	u := user.CurrentUser(c)
+	var req VideoHeartbeatRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(400, response.Error(400, "参数验证失败"))
+		return
+	}
+	known, err := repository.GetVideoDuration(req.VideoID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(404, response.Error(404, "视频不存在"))
+			return
+		}
+		log.Errorf("load video %d failed: %v", req.VideoID, err)
+		c.JSON(500, response.Error(500, "读取视频失败"))
+		return
+	}
+	settings := configuredVideoSettings()
+	finished := false
+	watch, err := repository.UpdateVideoWatch(u.Id, req.VideoID, func(w *models.VideoWatch) error {
+		intervals, err := decodeIntervals(w)
+		if err != nil {
+			// 区间损坏时从头记录
+			log.Errorf("decode watched intervals of video %d failed: %v", w.VideoID, err)
+			intervals = []watchedInterval{}
+		}
+		duration, err := heartbeatDuration(w, known, req.Duration)
+		if err != nil {
+			return err
+		}
+		intervals, finished = applyHeartbeat(w, intervals, *req.Position, duration, time.Now(), settings)
+		encoded, _ := json.Marshal(intervals)
+		w.Intervals = string(encoded)
+		return nil
+	})
+	if errors.Is(err, errDurationChanged) {
+		c.JSON(400, response.Error(400, err.Error()))
+		return
+	}
+	if err != nil {
+		log.Errorf("save video watch failed: %v", err)
+		c.JSON(500, response.Error(500, "记录观看进度失败"))
+		return
+	}
+	if finished {
+		if _, err := RecordEvent(u.Id, EventVideoFinished, "video", req.VideoID); err != nil {
+			log.Errorf("record video %d finished failed: %v", req.VideoID, err)
+		}
+	}
+	c.JSON(200, response.Success(videoState(watch)))
+}
+
+// GetVideoProgress 查看当前学生观看视频的进度和续播位置，没有观看过时进度为 0
+func GetVideoProgress(c *gin.Context) {
+	u := user.CurrentUser(c)
+	id, err := strconv.ParseInt(c.Query("video_id"), 10, 64)
+	if err != nil {
+		c.JSON(400, response.Error(400, "无效的视频ID"))
+		return
+	}
+	watches, err := repository.GetVideoWatches(u.Id, []int64{id})
+	if err != nil {
+		log.Errorf("load video watch failed: %v", err)
+		c.JSON(500, response.Error(500, "读取观看进度失败"))
+		return
+	}
+	watch, ok := watches[id]
+	if !ok {
+		watch = models.VideoWatch{VideoID: id}
+	}
+	c.JSON(200, response.Success(videoState(&watch)))
+}
+
+// VideoStatesFromRequest 请求携带学生登录信息时返回这些视频的观看进度，未登录或读取失败时返回 nil
+func VideoStatesFromRequest(c *gin.Context, videoIds []int64) map[int64]VideoState {
+	u := user.CurrentUser(c)
+	if u == nil || u.UserType != user.Student {
+		return nil
+	}
+	watches, err := repository.GetVideoWatches(u.Id, videoIds)
+	if err != nil {
+		log.Errorf("load video watches of user %s failed: %v", u.Id, err)
+		return nil
+	}
+	states := make(map[int64]VideoState, len(watches))
+	for id, w := range watches {
+		states[id] = videoState(&w)
+	}
+	return states
+}
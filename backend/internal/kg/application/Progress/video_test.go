@@ -0,0 +1,120 @@
+package progress
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/RMS_V3/config"
+	"github.com/RMS_V3/internal/kg/repository/models"
+)
+
+func TestMergeInterval(t *testing.T) {
+	var intervals []watchedInterval
+	intervals = mergeInterval(intervals, 10, 20)
+	intervals = mergeInterval(intervals, 30, 40)
+	intervals = mergeInterval(intervals, 0, 5)
+	want := []watchedInterval{{0, 5}, {10, 20}, {30, 40}}
+	if !reflect.DeepEqual(intervals, want) {
+		t.Fatalf("intervals = %v, want %v", intervals, want)
+	}
+	// 相接和跨越多个区间时合并
+	intervals = mergeInterval(intervals, 5, 10)
+	intervals = mergeInterval(intervals, 15, 35)
+	if want := []watchedInterval{{0, 40}}; !reflect.DeepEqual(intervals, want) {
+		t.Errorf("intervals = %v, want %v", intervals, want)
+	}
+	if got := coveredSeconds([]watchedInterval{{0, 10}, {50, 70}}, 60); got != 20 {
+		t.Errorf("coveredSeconds = %v, want 20 (clipped to duration)", got)
+	}
+}
+
+func TestApplyHeartbeat(t *testing.T) {
+	s := videoSettings{CompletionThreshold: 0.9, MaxPlaybackRate: 2, HeartbeatSlack: 1, MaxHeartbeatGap: 60}
+	start := time.Date(2026, 10, 19, 9, 0, 0, 0, time.UTC)
+	w := &models.VideoWatch{VideoID: 1}
+	var intervals []watchedInterval
+
+	// 第一次心跳只记录位置
+	intervals, done := applyHeartbeat(w, intervals, 0, 100, start, s)
+	if done || len(intervals) != 0 || w.LastPosition != 0 {
+		t.Fatalf("first heartbeat recorded %v", intervals)
+	}
+	now := start
+	for pos := 10.0; pos <= 50; pos += 10 {
+		now = now.Add(10 * time.Second)
+		intervals, done = applyHeartbeat(w, intervals, pos, 100, now, s)
+	}
+	if done || w.WatchedSeconds != 50 {
+		t.Fatalf("watched %v after normal playback, want 50", w.WatchedSeconds)
+	}
+
+	// 拖动进度条跳过的部分不计入
+	now = now.Add(10 * time.Second)
+	intervals, _ = applyHeartbeat(w, intervals, 90, 100, now, s)
+	if w.WatchedSeconds != 50 || w.LastPosition != 90 {
+		t.Fatalf("seek counted as watched: %v", intervals)
+	}
+	// 间隔过久的心跳视为重新打开
+	now = now.Add(10 * time.Minute)
+	intervals, _ = applyHeartbeat(w, intervals, 95, 100, now, s)
+	if w.WatchedSeconds != 50 {
+		t.Fatalf("stale heartbeat counted as watched: %v", intervals)
+	}
+	if state := videoState(w); state.ResumePosition != 0 || state.Coverage != 0.5 {
+		t.Errorf("state = %+v, want restart from 0 near the end", state)
+	}
+
+	// 回到 50 秒继续看，覆盖率达到 90% 时看完且只报告一次
+	now = now.Add(time.Second)
+	intervals, _ = applyHeartbeat(w, intervals, 50, 100, now, s)
+	for pos := 60.0; pos <= 90; pos += 10 {
+		now = now.Add(10 * time.Second)
+		intervals, done = applyHeartbeat(w, intervals, pos, 100, now, s)
+		if done != (pos == 90) {
+			t.Errorf("position %v: done = %v", pos, done)
+		}
+	}
+	if !w.Completed || w.CompletedAt == nil || !w.CompletedAt.Equal(now) {
+		t.Errorf("watch = %+v, want completed at %v", w, now)
+	}
+	now = now.Add(10 * time.Second)
+	if _, done = applyHeartbeat(w, intervals, 100, 100, now, s); done {
+		t.Error("completion reported twice")
+	}
+}
+
+func TestVideoSettingsFromConfig(t *testing.T) {
+	got := videoSettingsFromConfig(&config.VideoConfig{MaxPlaybackRate: 3})
+	want := defaultVideoSettings
+	want.MaxPlaybackRate = 3
+	if got != want {
+		t.Errorf("partial config gave %+v", got)
+	}
+	if got := videoSettingsFromConfig(&config.VideoConfig{CompletionThreshold: 1.5, HeartbeatSlack: -1}); got != defaultVideoSettings {
+		t.Errorf("invalid values should fall back to defaults, got %+v", got)
+	}
+}
+
+func TestHeartbeatDuration(t *testing.T) {
+	tests := []struct {
+		name            string
+		pinned          float64
+		known, reported float64
+		want            float64
+		wantErr         bool
+	}{
+		{name: "known duration ignores report", pinned: 0, known: 600, reported: 1, want: 600},
+		{name: "known duration overrides pinned", pinned: 1, known: 600, reported: 1, want: 600},
+		{name: "first heartbeat pins report", pinned: 0, known: 0, reported: 300, want: 300},
+		{name: "small jitter keeps pinned", pinned: 300, known: 0, reported: 299.5, want: 300},
+		{name: "longer report extends", pinned: 300, known: 0, reported: 320, want: 320},
+		{name: "shorter report rejected", pinned: 300, known: 0, reported: 1, wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := heartbeatDuration(&models.VideoWatch{Duration: tt.pinned}, tt.known, tt.reported)
+		if (err != nil) != tt.wantErr || got != tt.want {
+			t.Errorf("%s: heartbeatDuration() = %v, %v; want %v, error %v", tt.name, got, err, tt.want, tt.wantErr)
+		}
+	}
+}
@@ -0,0 +1,454 @@
+package recommend
+
+import (
+	"container/heap"
+	"math"
+	"sort"
+	"strconv"
+
+	analysis "github.com/RMS_V3/internal/kg/application/Analysis"
+	"github.com/RMS_V3/internal/kg/repository"
+	"github.com/RMS_V3/log"
+	"github.com/RMS_V3/middleware/neo4jUtils"
+	"github.com/RMS_V3/pkg/response"
+	"github.com/gin-gonic/gin"
+	"github.com/neo4j/neo4j-go-driver/v4/neo4j"
+)
+
+// 关系没有 strength 属性时使用的默认强度，强度越高代价越低
+var defaultRelationStrength = map[string]float64{
+	"前置": 1.0,
+	"扩展": 0.7,
+	"相关": 0.5,
+}
+
+// pathCostWeights 路径代价中各因素的权重：
+// 每条边的代价 = Link/强度 + Difficulty*终点难度 + Time*终点预计学习小时数
+var pathCostWeights = struct {
+	Link       float64
+	Difficulty float64
+	Time       float64
+}{Link: 1, Difficulty: 0.2, Time: 0.5}
+
+const (
+	defaultAlternativePaths = 3
+	maxAlternativePaths     = 10
+)
+
+// estimateStudyMinutes 根据关联资源数量估计知识点的学习时间
+func estimateStudyMinutes(c repository.ResourceCount) float64 {
+	return 20 + 15*float64(c.Videos) + 10*float64(c.Exercises) + 10*float64(c.Coursewares)
+}
+
+type weightedEdge struct {
+	to   int
+	cost float64
+	kind string
+}
+
+// weightedGraph 知识点之间的带权有向图，相关 关系按双向处理
+type weightedGraph struct {
+	ids   []int64
+	index map[int64]int
+	adj   [][]weightedEdge
+}
+
+type rawRelation struct {
+	Source, Target int64
+	Type           string
+	Strength       float64
+}
+
+// newWeightedGraph nodeCost 为进入某个知识点的代价（难度和学习时间）
+func newWeightedGraph(relations []rawRelation, nodeCost func(int64) float64) *weightedGraph {
+	g := &weightedGraph{index: make(map[int64]int)}
+	node := func(id int64) int {
+		if i, ok := g.index[id]; ok {
+			return i
+		}
+		g.index[id] = len(g.ids)
+		g.ids = append(g.ids, id)
+		g.adj = append(g.adj, nil)
+		return len(g.ids) - 1
+	}
+	addEdge := func(from, to int64, strength float64, kind string) {
+		f, t := node(from), node(to)
+		if f == t {
+			return
+		}
+		cost := pathCostWeights.Link/strength + nodeCost(to)
+		for k, e := range g.adj[f] {
+			if e.to == t {
+				// 同一对知识点之间有多条关系时保留代价最低的一条
+				if cost < e.cost {
+					g.adj[f][k] = weightedEdge{to: t, cost: cost, kind: kind}
+				}
+				return
+			}
+		}
+		g.adj[f] = append(g.adj[f], weightedEdge{to: t, cost: cost, kind: kind})
+	}
+	for _, r := range relations {
+		strength := r.Strength
+		if strength <= 0 || strength > 1 {
+			strength = defaultRelationStrength[r.Type]
+		}
+		if strength <= 0 {
+			continue
+		}
+		addEdge(r.Source, r.Target, strength, r.Type)
+		if r.Type == "相关" {
+			addEdge(r.Target, r.Source, strength, r.Type)
+		}
+	}
+	return g
+}
+
+type weightedPath struct {
+	nodes []int
+	cost  float64
+}
+
+type dijkstraItem struct {
+	node int
+	dist float64
+}
+
+type dijkstraQueue []dijkstraItem
+
+func (q dijkstraQueue) Len() int            { return len(q) }
+func (q dijkstraQueue) Less(i, j int) bool  { return q[i].dist < q[j].dist }
+func (q dijkstraQueue) Swap(i, j int)       { q[i], q[j] = q[j], q[i] }
+func (q *dijkstraQueue) Push(x interface{}) { *q = append(*q, x.(dijkstraItem)) }
+func (q *dijkstraQueue) Pop() interface{} {
+	old := *q
+	item := old[len(old)-1]
+	*q = old[:len(old)-1]
+	return item
+}
+
+// shortestPath Dijkstra 最短路径，removedNodes 和 removedEdges 中的节点和边不可经过
+func (g *weightedGraph) shortestPath(src, dst int, removedNodes map[int]bool, removedEdges map[[2]int]bool) (weightedPath, bool) {
+	n := len(g.ids)
+	dist := make([]float64, n)
+	prev := make([]int, n)
+	for i := range dist {
+		dist[i] = math.Inf(1)
+		prev[i] = -1
+	}
+	dist[src] = 0
+	q := &dijkstraQueue{{node: src}}
+	for q.Len() > 0 {
+		item := heap.Pop(q).(dijkstraItem)
+		if item.dist > dist[item.node] {
+			continue
+		}
+		if item.node == dst {
+			break
+		}
+		for _, e := range g.adj[item.node] {
+			if removedNodes[e.to] || removedEdges[[2]int{item.node, e.to}] {
+				continue
+			}
+			if d := item.dist + e.cost; d < dist[e.to] {
+				dist[e.to] = d
+				prev[e.to] = item.node
+				heap.Push(q, dijkstraItem{node: e.to, dist: d})
+			}
+		}
+	}
+	if math.IsInf(dist[dst], 1) {
+		return weightedPath{}, false
+	}
+	var nodes []int
+	for v := dst; v != -1; v = prev[v] {
+		nodes = append(nodes, v)
+	}
+	for i, j := 0, len(nodes)-1; i < j; i, j = i+1, j-1 {
+		nodes[i], nodes[j] = nodes[j], nodes[i]
+	}
+	return weightedPath{nodes: nodes, cost: dist[dst]}, true
+}
+
+func (g *weightedGraph) edgeCost(from, to int) float64 {
+	for _, e := range g.adj[from] {
+		if e.to == to {
+			return e.cost
+		}
+	}
+	return math.Inf(1)
+}
+
+func (g *weightedGraph) pathCost(nodes []int) float64 {
+	var cost float64
+	for i := 0; i+1 < len(nodes); i++ {
+		cost += g.edgeCost(nodes[i], nodes[i+1])
+	}
+	return cost
+}
+
+func samePath(a, b []int) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// kShortestPaths Yen 算法求前 k 条无环最短路径
+func (g *weightedGraph) kShortestPaths(src, dst int, k int) []weightedPath {
+	first, ok := g.shortestPath(src, dst, nil, nil)
+	if !ok {
+		return nil
+	}
+	paths := []weightedPath{first}
+	var candidates []weightedPath
+	for len(paths) < k {
+		last := paths[len(paths)-1].nodes
+		for i := 0; i < len(last)-1; i++ {
+			spur := last[i]
+			root := last[:i+1]
+			removedEdges := make(map[[2]int]bool)
+			for _, p := range paths {
+				if len(p.nodes) > i+1 && samePath(p.nodes[:i+1], root) {
+					removedEdges[[2]int{p.nodes[i], p.nodes[i+1]}] = true
+				}
+			}
+			removedNodes := make(map[int]bool, i)
+			for _, v := range root[:i] {
+				removedNodes[v] = true
+			}
+			spurPath, ok := g.shortestPath(spur, dst, removedNodes, removedEdges)
+			if !ok {
+				continue
+			}
+			nodes := append(append([]int{}, root[:i]...), spurPath.nodes...)
+			duplicate := false
+			for _, c := range candidates {
+				if samePath(c.nodes, nodes) {
+					duplicate = true
+					break
+				}
+			}
+			if !duplicate {
+				candidates = append(candidates, weightedPath{nodes: nodes, cost: g.pathCost(nodes)})
+			}
+		}
+		if len(candidates) == 0 {
+			break
+		}
+		sort.SliceStable(candidates, func(i, j int) bool { return candidates[i].cost < candidates[j].cost })
+		paths = append(paths, candidates[0])
+		candidates = candidates[1:]
+	}
+	return paths
+}
+
+// SharedSteps 与另一条备选路径共同经过的中间知识点
+type SharedSteps struct {
+	Path   int      `json:"path"`
+	Points []string `json:"points"`
+}
+
+// PathStep 备选路径中的一步
+type PathStep struct {
+	PointID  int64   `json:"point_id"`
+	Name     string  `json:"name"`
+	Relation string  `json:"relation,omitempty"` // 从上一步到达该步经过的关系
+	Cost     float64 `json:"cost"`
+}
+
+// AlternativePath 一条备选学习路径
+type AlternativePath struct {
+	Rank       int           `json:"rank"`
+	TotalCost  float64       `json:"total_cost"`
+	StepCount  int           `json:"step_count"`
+	Steps      []PathStep    `json:"steps"`
+	SharedWith []SharedSteps `json:"shared_with"`
+}
+
+// annotatePaths 生成路径步骤，并标出每条路径与其他路径共同经过的中间知识点
+func annotatePaths(g *weightedGraph, paths []weightedPath, names map[int64]string) []AlternativePath {
+	result := make([]AlternativePath, len(paths))
+	for i, p := range paths {
+		steps := make([]PathStep, len(p.nodes))
+		for k, v := range p.nodes {
+			id := g.ids[v]
+			steps[k] = PathStep{PointID: id, Name: names[id]}
+			if k > 0 {
+				for _, e := range g.adj[p.nodes[k-1]] {
+					if e.to == v {
+						steps[k].Relation = e.kind
+						steps[k].Cost = e.cost
+					}
+				}
+			}
+		}
+		result[i] = AlternativePath{
+			Rank:       i + 1,
+			TotalCost:  p.cost,
+			StepCount:  len(p.nodes) - 1,
+			Steps:      steps,
+			SharedWith: []SharedSteps{},
+		}
+	}
+	for i, a := range paths {
+		inner := make(map[int]bool)
+		for _, v := range a.nodes[1 : len(a.nodes)-1] {
+			inner[v] = true
+		}
+		for j, b := range paths {
+			if i == j {
+				continue
+			}
+			var shared []string
+			for _, v := range b.nodes[1 : len(b.nodes)-1] {
+				if inner[v] {
+					shared = append(shared, names[g.ids[v]])
+				}
+			}
+			if len(shared) > 0 {
+				result[i].SharedWith = append(result[i].SharedWith, SharedSteps{Path: j + 1, Points: shared})
+			}
+		}
+	}
+	return result
+}
+
+// loadPointRelations 读取知识点之间的 前置/相关/扩展 关系及其 strength 属性
+func loadPointRelations(session neo4j.Session) ([]rawRelation, error) {
+	result, err := session.Run(`
+	MATCH (a:point)-[r]->(b:point)
+	WHERE type(r) IN ['前置', '相关', '扩展']
+	RETURN id(a) AS source, id(b) AS target, type(r) AS type, r.strength AS strength`, nil)
+	if err != nil {
+		return nil, err
+	}
+	var relations []rawRelation
+	for result.Next() {
+		record := result.Record()
+		source, _ := record.Get("source")
+		target, _ := record.Get("target")
+		relType, _ := record.Get("type")
+		strength, _ := record.Get("strength")
+		r := rawRelation{}
+		var ok1, ok2 bool
+		r.Source, ok1 = source.(int64)
+		r.Target, ok2 = target.(int64)
+		if !ok1 || !ok2 {
+			continue
+		}
+		r.Type, _ = relType.(string)
+		switch v := strength.(type) {
+		case float64:
+			r.Strength = v
+		case int64:
+			r.Strength = float64(v)
+		}
+		relations = append(relations, r)
+	}
+	return relations, result.Err()
+}
+
+// GenerateAlternativePaths 返回两个知识点之间代价最低的 k 条备选学习路径。
+// 边的代价由关系强度、终点难度和预计学习时间决定，学生可以根据已掌握的内容选择更短的路线
+func GenerateAlternativePaths(c *gin.Context) {
+	k := defaultAlternativePaths
+	if kStr := c.Query("k"); kStr != "" {
+		var err error
+		if k, err = strconv.Atoi(kStr); err != nil || k < 1 || k > maxAlternativePaths {
+			c.JSON(400, response.Error(400, "k 必须在 1 到 10 之间"))
+			return
+		}
+	}
+
+	session := neo4jUtils.GetSession()
+	if session == nil {
+		c.JSON(500, response.Error(500, "无法获取 Neo4j 会话"))
+		return
+	}
+	defer session.Close()
+
+	start, ok := resolvePoint(c, session, "start_point")
+	if !ok {
+		return
+	}
+	end, ok := resolvePoint(c, session, "end_point")
+	if !ok {
+		return
+	}
+
+	relations, err := loadPointRelations(session)
+	if err != nil {
+		log.Errorf("load point relations failed: %v", err)
+		c.JSON(500, response.Error(500, "路径生成失败"))
+		return
+	}
+	idSet := map[int64]bool{start: true, end: true}
+	for _, r := range relations {
+		idSet[r.Source] = true
+		idSet[r.Target] = true
+	}
+	ids := make([]int64, 0, len(idSet))
+	for id := range idSet {
+		ids = append(ids, id)
+	}
+	difficulty, err := analysis.PointDifficultyScores(session, ids)
+	if err != nil {
+		log.Errorf("score difficulty failed: %v", err)
+		c.JSON(500, response.Error(500, "路径生成失败"))
+		return
+	}
+	resources, err := repository.CountResourcesByPoint(ids)
+	if err != nil {
+		log.Errorf("count resources failed: %v", err)
+		c.JSON(500, response.Error(500, "路径生成失败"))
+		return
+	}
+
+	graph := newWeightedGraph(relations, func(id int64) float64 {
+		return pathCostWeights.Difficulty*difficulty[id] + pathCostWeights.Time*estimateStudyMinutes(resources[id])/60
+	})
+	src, ok1 := graph.index[start]
+	dst, ok2 := graph.index[end]
+	if start == end || !ok1 || !ok2 {
+		c.JSON(404, response.Error(404, "未找到从起点到终点的路径"))
+		return
+	}
+	paths := graph.kShortestPaths(src, dst, k)
+	if len(paths) == 0 {
+		c.JSON(404, response.Error(404, "未找到从起点到终点的路径"))
+		return
+	}
+
+	pathIds := make([]int64, 0)
+	seen := make(map[int64]bool)
+	for _, p := range paths {
+		for _, v := range p.nodes {
+			if id := graph.ids[v]; !seen[id] {
+				seen[id] = true
+				pathIds = append(pathIds, id)
+			}
+		}
+	}
+	info, err := loadPointInfo(session, pathIds)
+	if err != nil {
+		log.Errorf("load point info failed: %v", err)
+		c.JSON(500, response.Error(500, "路径生成失败"))
+		return
+	}
+	names := make(map[int64]string, len(info))
+	for id, p := range info {
+		names[id] = p.Name
+	}
+
+	c.JSON(200, response.Success(map[string]interface{}{
+		"start": map[string]interface{}{"id": start, "name": names[start]},
+		"end":   map[string]interface{}{"id": end, "name": names[end]},
+		"paths": annotatePaths(graph, paths, names),
+	}))
+}
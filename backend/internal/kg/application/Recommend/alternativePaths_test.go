@@ -0,0 +1,39 @@
+package recommend
+
+import (
+	"math"
+	"testing"
+)
+
+func TestKShortestPaths(t *testing.T) {
+	// 1 -> 2 -> 4 和 1 -> 3 -> 4 两条路线，3 更难；1 -> 4 的 相关 关系最弱
+	relations := []rawRelation{
+		{Source: 1, Target: 2, Type: "前置"},
+		{Source: 2, Target: 4, Type: "前置"},
+		{Source: 1, Target: 3, Type: "前置"},
+		{Source: 3, Target: 4, Type: "前置"},
+		{Source: 4, Target: 1, Type: "相关", Strength: 0.25},
+	}
+	cost := map[int64]float64{3: 0.5}
+	g := newWeightedGraph(relations, func(id int64) float64 { return cost[id] })
+
+	paths := g.kShortestPaths(g.index[1], g.index[4], 5)
+	if len(paths) != 3 {
+		t.Fatalf("expected 3 paths, got %d", len(paths))
+	}
+	want := []float64{2, 2.5, 4}
+	for i, p := range paths {
+		if math.Abs(p.cost-want[i]) > 1e-9 {
+			t.Errorf("path %d cost = %v, want %v", i, p.cost, want[i])
+		}
+	}
+
+	names := map[int64]string{1: "a", 2: "b", 3: "c", 4: "d"}
+	annotated := annotatePaths(g, paths, names)
+	if annotated[0].StepCount != 2 || annotated[0].Steps[1].Name != "b" {
+		t.Errorf("unexpected first path: %+v", annotated[0])
+	}
+	if len(annotated[0].SharedWith) != 0 {
+		t.Errorf("disjoint routes should not share steps: %+v", annotated[0].SharedWith)
+	}
+}
@@ -0,0 +1,350 @@
+package recommend
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+
+	"github.com/RMS_V3/internal/kg/repository"
+	"github.com/RMS_V3/internal/kg/repository/models"
+	"github.com/RMS_V3/internal/user"
+	"github.com/RMS_V3/log"
+	"github.com/RMS_V3/middleware/neo4jUtils"
+	"github.com/RMS_V3/pkg/response"
+	"github.com/gin-gonic/gin"
+	"github.com/neo4j/neo4j-go-driver/v4/neo4j"
+)
+
+// 推荐来源
+const (
+	SourceCollaborative = "collaborative"  // 学习过相同资源的学生也觉得有用
+	SourceGraphNeighbor = "graph_neighbor" // 冷启动时取图谱中相邻知识点的资源
+)
+
+const (
+	defaultRecommendLimit = 10
+	maxRecommendLimit     = 50
+	// recentPoints 为学生做冷启动推荐时使用的最近在学知识点数
+	recentPoints = 5
+)
+
+// RecommendedResource 推荐的资源
+type RecommendedResource struct {
+	Type string `json:"type"`
+	RankedResource
+	PointID int64  `json:"point_id"`
+	Source  string `json:"source"`
+	// Because 协同过滤推荐时，贡献最大的依据资源
+	Because *repository.ResourceKey `json:"because,omitempty"`
+}
+
+// parseLimit 解析可选的 limit 参数，失败时已写入响应
+func parseLimit(c *gin.Context) (int, bool) {
+	limit := defaultRecommendLimit
+	if raw := c.Query("limit"); raw != "" {
+		var err error
+		if limit, err = strconv.Atoi(raw); err != nil || limit < 1 || limit > maxRecommendLimit {
+			c.JSON(400, response.Error(400, fmt.Sprintf("limit 必须在 1 到 %d 之间", maxRecommendLimit)))
+			return 0, false
+		}
+	}
+	return limit, true
+}
+
+// userInteractions 学生对资源的交互强度
+func userInteractions(userId string) (map[repository.ResourceKey]float64, error) {
+	feedback, err := repository.ListUserFeedback(userId)
+	if err != nil {
+		return nil, err
+	}
+	watches, err := repository.ListUserVideoWatches(userId)
+	if err != nil {
+		return nil, err
+	}
+	interactions := buildInteractions(feedback, watches)[userId]
+	if interactions == nil {
+		interactions = make(map[repository.ResourceKey]float64)
+	}
+	return interactions, nil
+}
+
+// describeResources 读取资源的标题、地址、所属知识点和反馈统计，已删除的资源不出现在结果中
+func describeResources(keys []repository.ResourceKey) (map[repository.ResourceKey]RecommendedResource, error) {
+	ids := make(map[string][]int64)
+	for _, k := range keys {
+		ids[k.Type] = append(ids[k.Type], k.ID)
+	}
+	videos, exercises, coursewares, err := repository.GetResourcesByIds(ids["video"], ids["exercise"], ids["courseware"])
+	if err != nil {
+		return nil, err
+	}
+	stats := make(map[string]map[int64]repository.ResourceStats)
+	for resourceType, list := range ids {
+		if stats[resourceType], err = repository.GetResourceStats(resourceType, list); err != nil {
+			return nil, err
+		}
+	}
+	described := make(map[repository.ResourceKey]RecommendedResource, len(keys))
+	add := func(resourceType string, pointId int64, r RankedResource) {
+		described[repository.ResourceKey{Type: resourceType, ID: r.ID}] = RecommendedResource{Type: resourceType, RankedResource: r, PointID: pointId}
+	}
+	for _, v := range videos {
+		add("video", v.KnowledgePointID, rankResource(v.ID, v.Title, v.PlayURL, "", stats["video"][v.ID]))
+	}
+	for _, e := range exercises {
+		add("exercise", e.KnowledgePointID, rankResource(e.ID, e.Title, e.ExerciseURL, e.Difficulty, stats["exercise"][e.ID]))
+	}
+	for _, cw := range coursewares {
+		add("courseware", cw.KnowledgePointID, rankResource(cw.ID, cw.Title, cw.CoursewareURL, "", stats["courseware"][cw.ID]))
+	}
+	return described, nil
+}
+
+// collaborativeRecommendations 以 seeds 为依据做协同过滤推荐，Score 为协同过滤得分
+func collaborativeRecommendations(seeds map[repository.ResourceKey]float64, exclude map[repository.ResourceKey]bool,
+	limit int) ([]RecommendedResource, error) {
+	keys := make([]repository.ResourceKey, 0, len(seeds))
+	for k := range seeds {
+		keys = append(keys, k)
+	}
+	similar, err := repository.GetSimilarResources(keys)
+	if err != nil {
+		return nil, err
+	}
+	candidates := scoreCandidates(seeds, similar, exclude)
+	candidateKeys := make([]repository.ResourceKey, len(candidates))
+	for i, c := range candidates {
+		candidateKeys[i] = c.Key
+	}
+	described, err := describeResources(candidateKeys)
+	if err != nil {
+		return nil, err
+	}
+	result := []RecommendedResource{}
+	for _, c := range candidates {
+		r, ok := described[c.Key]
+		if !ok {
+			continue
+		}
+		because := c.Because
+		r.Score = c.Score
+		r.Source = SourceCollaborative
+		r.Because = &because
+		result = append(result, r)
+		if len(result) == limit {
+			break
+		}
+	}
+	return result, nil
+}
+
+// neighborPoints 图谱中与给定知识点直接相连的其他知识点
+func neighborPoints(session neo4j.Session, pointIds []int64) ([]int64, error) {
+	result, err := session.Run(`
+	MATCH (p:point)-[]-(n:point)
+	WHERE id(p) IN $ids AND NOT id(n) IN $ids
+	RETURN DISTINCT id(n) AS id
+	ORDER BY id`, map[string]interface{}{"ids": pointIds})
+	if err != nil {
+		return nil, err
+	}
+	var ids []int64
+	for result.Next() {
+		id, _ := result.Record().Get("id")
+		if pid, ok := id.(int64); ok {
+			ids = append(ids, pid)
+		}
+	}
+	return ids, result.Err()
+}
+
+// graphNeighborRecommendations 冷启动推荐：取知识点资源，按评分和完成率排序
+func graphNeighborRecommendations(pointIds []int64, exclude map[repository.ResourceKey]bool, limit int) ([]RecommendedResource, error) {
+	ranked, err := loadRankedResources(pointIds)
+	if err != nil {
+		return nil, err
+	}
+	result := []RecommendedResource{}
+	collect := func(resourceType string, byPoint map[int64][]RankedResource) {
+		for pointId, list := range byPoint {
+			for _, r := range list {
+				if exclude[repository.ResourceKey{Type: resourceType, ID: r.ID}] {
+					continue
+				}
+				result = append(result, RecommendedResource{Type: resourceType, RankedResource: r, PointID: pointId, Source: SourceGraphNeighbor})
+			}
+		}
+	}
+	collect("video", ranked.videos)
+	collect("courseware", ranked.coursewares)
+	collect("exercise", ranked.exercises)
+	sort.SliceStable(result, func(i, j int) bool {
+		if result[i].Score != result[j].Score {
+			return result[i].Score > result[j].Score
+		}
+		if result[i].Type != result[j].Type {
+			return result[i].Type < result[j].Type
+		}
+		return result[i].ID > result[j].ID
+	})
+	if len(result) > limit {
+		result = result[:limit]
+	}
+	return result, nil
+}
+
+// interactedSet 学生交互过的资源，推荐时排除
+func interactedSet(interactions map[repository.ResourceKey]float64) map[repository.ResourceKey]bool {
+	set := make(map[repository.ResourceKey]bool, len(interactions))
+	for k := range interactions {
+		set[k] = true
+	}
+	return set
+}
+
+// RecommendForPoint 知识点的“学过这些资源的学生也觉得有用”推荐：以知识点自身的资源为依据做协同过滤，
+// 没有协同过滤结果时取图谱中相邻知识点的资源；学生登录时排除其已学习过的资源
+func RecommendForPoint(c *gin.Context) {
+	limit, ok := parseLimit(c)
+	if !ok {
+		return
+	}
+	session := neo4jUtils.GetSession()
+	if session == nil {
+		c.JSON(500, response.Error(500, "无法获取 Neo4j 会话"))
+		return
+	}
+	defer session.Close()
+	pointId, ok := resolvePoint(c, session, "point")
+	if !ok {
+		return
+	}
+
+	exclude := map[repository.ResourceKey]bool{}
+	if u := user.CurrentUser(c); u != nil {
+		interactions, err := userInteractions(u.Id)
+		if err != nil {
+			log.Errorf("load interactions of user %s failed: %v", u.Id, err)
+		} else {
+			exclude = interactedSet(interactions)
+		}
+	}
+
+	videos, exercises, coursewares, err := repository.GetResourcesByPoints([]int64{pointId})
+	if err != nil {
+		log.Errorf("load resources of point %d failed: %v", pointId, err)
+		c.JSON(500, response.Error(500, "读取学习资源失败"))
+		return
+	}
+	seeds := make(map[repository.ResourceKey]float64)
+	for _, v := range videos {
+		seeds[repository.ResourceKey{Type: "video", ID: v.ID}] = 1
+	}
+	for _, e := range exercises {
+		seeds[repository.ResourceKey{Type: "exercise", ID: e.ID}] = 1
+	}
+	for _, cw := range coursewares {
+		seeds[repository.ResourceKey{Type: "courseware", ID: cw.ID}] = 1
+	}
+	result, err := collaborativeRecommendations(seeds, exclude, limit)
+	if err != nil {
+		log.Errorf("collaborative recommendation for point %d failed: %v", pointId, err)
+		c.JSON(500, response.Error(500, "推荐失败"))
+		return
+	}
+	if len(result) == 0 {
+		neighbors, err := neighborPoints(session, []int64{pointId})
+		if err == nil {
+			result, err = graphNeighborRecommendations(neighbors, exclude, limit)
+		}
+		if err != nil {
+			log.Errorf("graph neighbor recommendation for point %d failed: %v", pointId, err)
+			c.JSON(500, response.Error(500, "推荐失败"))
+			return
+		}
+	}
+	c.JSON(200, response.Success(map[string]interface{}{
+		"point_id":  pointId,
+		"resources": result,
+	}))
+}
+
+// recentInProgressPoints 学生最近在学的知识点，最近学习的在前
+func recentInProgressPoints(progress map[int64]models.LearnerProgress, limit int) []int64 {
+	var records []models.LearnerProgress
+	for _, p := range progress {
+		if p.Status == models.ProgressInProgress {
+			records = append(records, p)
+		}
+	}
+	sort.Slice(records, func(i, j int) bool {
+		if !records[i].LastActivityAt.Equal(records[j].LastActivityAt) {
+			return records[i].LastActivityAt.After(records[j].LastActivityAt)
+		}
+		return records[i].KnowledgePointID < records[j].KnowledgePointID
+	})
+	if len(records) > limit {
+		records = records[:limit]
+	}
+	ids := make([]int64, len(records))
+	for i, r := range records {
+		ids[i] = r.KnowledgePointID
+	}
+	return ids
+}
+
+// RecommendForUser 为当前学生推荐资源：以其评价高或已完成的资源为依据做协同过滤，
+// 没有依据或结果时取最近在学知识点及其相邻知识点的资源
+func RecommendForUser(c *gin.Context) {
+	u := user.CurrentUser(c)
+	limit, ok := parseLimit(c)
+	if !ok {
+		return
+	}
+	interactions, err := userInteractions(u.Id)
+	if err != nil {
+		log.Errorf("load interactions of user %s failed: %v", u.Id, err)
+		c.JSON(500, response.Error(500, "读取学习记录失败"))
+		return
+	}
+	seeds := make(map[repository.ResourceKey]float64)
+	for k, strength := range interactions {
+		if strength >= seedStrength {
+			seeds[k] = strength
+		}
+	}
+	exclude := interactedSet(interactions)
+	result, err := collaborativeRecommendations(seeds, exclude, limit)
+	if err != nil {
+		log.Errorf("collaborative recommendation for user %s failed: %v", u.Id, err)
+		c.JSON(500, response.Error(500, "推荐失败"))
+		return
+	}
+	if len(result) == 0 {
+		progress, err := repository.GetLearnerProgress(u.Id)
+		if err != nil {
+			log.Errorf("load progress of user %s failed: %v", u.Id, err)
+			c.JSON(500, response.Error(500, "读取学习进度失败"))
+			return
+		}
+		points := recentInProgressPoints(progress, recentPoints)
+		if len(points) > 0 {
+			session := neo4jUtils.GetSession()
+			if session == nil {
+				c.JSON(500, response.Error(500, "无法获取 Neo4j 会话"))
+				return
+			}
+			defer session.Close()
+			neighbors, err := neighborPoints(session, points)
+			if err == nil {
+				result, err = graphNeighborRecommendations(append(points, neighbors...), exclude, limit)
+			}
+			if err != nil {
+				log.Errorf("graph neighbor recommendation for user %s failed: %v", u.Id, err)
+				c.JSON(500, response.Error(500, "推荐失败"))
+				return
+			}
+		}
+	}
+	c.JSON(200, response.Success(result))
+}
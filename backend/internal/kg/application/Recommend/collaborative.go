@@ -0,0 +1,187 @@
+package recommend
+
+import (
+	"math"
+	"sort"
+
+	"github.com/RMS_V3/internal/kg/repository"
+	"github.com/RMS_V3/internal/kg/repository/models"
+)
+
+const (
+	// maxItemsPerUser 计算相似度时每名学生最多使用的资源数，取交互强度最高的，避免个别学生的记录主导计算量
+	maxItemsPerUser = 200
+	// seedStrength 学生对资源的交互强度达到该值时，作为为其推荐的依据
+	seedStrength = 0.6
+)
+
+// similaritySettings 相似度计算参数
+type similaritySettings struct {
+	Neighbors      int
+	MinCommonUsers int
+	Shrinkage      float64
+}
+
+// interactionStrength 学生对资源的交互强度：有评分时为评分/5，否则看完（提交）为 1，仅有记录为 0.5
+func interactionStrength(f models.ResourceFeedback) float64 {
+	switch {
+	case f.Rating != nil:
+		return float64(*f.Rating) / 5
+	case f.Completed:
+		return 1
+	default:
+		return 0.5
+	}
+}
+
+// buildInteractions 汇总评分、完成和视频观看记录，得到每名学生对每个资源的交互强度，
+// 视频取评分（完成）强度与观看覆盖率中较大的一个
+func buildInteractions(feedback []models.ResourceFeedback, watches []models.VideoWatch) map[string]map[repository.ResourceKey]float64 {
+	interactions := make(map[string]map[repository.ResourceKey]float64)
+	add := func(userId string, key repository.ResourceKey, strength float64) {
+		if interactions[userId] == nil {
+			interactions[userId] = make(map[repository.ResourceKey]float64)
+		}
+		if strength > interactions[userId][key] {
+			interactions[userId][key] = strength
+		}
+	}
+	for _, f := range feedback {
+		add(f.UserID, repository.ResourceKey{Type: f.ResourceType, ID: f.ResourceID}, interactionStrength(f))
+	}
+	for _, w := range watches {
+		if w.Duration > 0 && w.WatchedSeconds > 0 {
+			add(w.UserID, repository.ResourceKey{Type: "video", ID: w.VideoID}, math.Min(w.WatchedSeconds/w.Duration, 1))
+		}
+	}
+	return interactions
+}
+
+func keyLess(a, b repository.ResourceKey) bool {
+	if a.Type != b.Type {
+		return a.Type < b.Type
+	}
+	return a.ID < b.ID
+}
+
+// topItems 按交互强度从高到低取学生的前 limit 个资源
+func topItems(items map[repository.ResourceKey]float64, limit int) []repository.ResourceKey {
+	keys := make([]repository.ResourceKey, 0, len(items))
+	for k := range items {
+		keys = append(keys, k)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if items[keys[i]] != items[keys[j]] {
+			return items[keys[i]] > items[keys[j]]
+		}
+		return keyLess(keys[i], keys[j])
+	})
+	if len(keys) > limit {
+		keys = keys[:limit]
+	}
+	return keys
+}
+
+// Neighbor 一个相似资源
+type Neighbor struct {
+	Key         repository.ResourceKey
+	Score       float64
+	CommonUsers int
+}
+
+// itemSimilarities 基于物品的协同过滤：两个资源的相似度为学生交互强度向量的余弦相似度，
+// 乘以 n / (n + Shrinkage) 以降低共同学习者少时的置信度；每个资源保留最相似的 Neighbors 个
+func itemSimilarities(interactions map[string]map[repository.ResourceKey]float64, s similaritySettings) map[repository.ResourceKey][]Neighbor {
+	norms := make(map[repository.ResourceKey]float64)
+	dots := make(map[[2]repository.ResourceKey]float64)
+	common := make(map[[2]repository.ResourceKey]int)
+	for _, items := range interactions {
+		keys := topItems(items, maxItemsPerUser)
+		sort.Slice(keys, func(i, j int) bool { return keyLess(keys[i], keys[j]) })
+		for i, a := range keys {
+			norms[a] += items[a] * items[a]
+			for _, b := range keys[i+1:] {
+				pair := [2]repository.ResourceKey{a, b}
+				dots[pair] += items[a] * items[b]
+				common[pair]++
+			}
+		}
+	}
+
+	neighbors := make(map[repository.ResourceKey][]Neighbor)
+	for pair, dot := range dots {
+		n := common[pair]
+		if n < s.MinCommonUsers {
+			continue
+		}
+		denominator := math.Sqrt(norms[pair[0]]) * math.Sqrt(norms[pair[1]])
+		if denominator == 0 {
+			continue
+		}
+		score := dot / denominator * float64(n) / (float64(n) + s.Shrinkage)
+		neighbors[pair[0]] = append(neighbors[pair[0]], Neighbor{Key: pair[1], Score: score, CommonUsers: n})
+		neighbors[pair[1]] = append(neighbors[pair[1]], Neighbor{Key: pair[0], Score: score, CommonUsers: n})
+	}
+	for key, list := range neighbors {
+		sort.Slice(list, func(i, j int) bool {
+			if list[i].Score != list[j].Score {
+				return list[i].Score > list[j].Score
+			}
+			return keyLess(list[i].Key, list[j].Key)
+		})
+		if len(list) > s.Neighbors {
+			list = list[:s.Neighbors]
+		}
+		neighbors[key] = list
+	}
+	return neighbors
+}
+
+// scoredResource 协同过滤得到的候选资源
+type scoredResource struct {
+	Key   repository.ResourceKey
+	Score float64
+	// Because 贡献最大的依据资源
+	Because repository.ResourceKey
+}
+
+// scoreCandidates 以 seeds 中的资源为依据，按 Σ 相似度 × 依据权重 为候选资源打分，
+// seeds 和 exclude 中的资源不会被推荐
+func scoreCandidates(seeds map[repository.ResourceKey]float64, similar []models.ResourceSimilarity,
+	exclude map[repository.ResourceKey]bool) []scoredResource {
+	scores := make(map[repository.ResourceKey]*scoredResource)
+	best := make(map[repository.ResourceKey]float64)
+	for _, s := range similar {
+		seed := repository.ResourceKey{Type: s.ResourceType, ID: s.ResourceID}
+		weight, ok := seeds[seed]
+		if !ok {
+			continue
+		}
+		key := repository.ResourceKey{Type: s.SimilarType, ID: s.SimilarID}
+		if _, isSeed := seeds[key]; isSeed || exclude[key] {
+			continue
+		}
+		contribution := s.Score * weight
+		r, ok := scores[key]
+		if !ok {
+			r = &scoredResource{Key: key}
+			scores[key] = r
+		}
+		r.Score += contribution
+		if contribution > best[key] || (contribution == best[key] && keyLess(seed, r.Because)) {
+			best[key] = contribution
+			r.Because = seed
+		}
+	}
+	result := make([]scoredResource, 0, len(scores))
+	for _, r := range scores {
+		result = append(result, *r)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Score != result[j].Score {
+			return result[i].Score > result[j].Score
+		}
+		return keyLess(result[i].Key, result[j].Key)
+	})
+	return result
+}
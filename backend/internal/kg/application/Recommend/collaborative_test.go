@@ -0,0 +1,105 @@
+package recommend
+
+import (
+	"testing"
+
+	"github.com/RMS_V3/config"
+	"github.com/RMS_V3/internal/kg/repository"
+	"github.com/RMS_V3/internal/kg/repository/models"
+)
+
+func rating(r int) *int { return &r }
+
+func video(id int64) repository.ResourceKey { return repository.ResourceKey{Type: "video", ID: id} }
+
+func TestBuildInteractions(t *testing.T) {
+	feedback := []models.ResourceFeedback{
+		{UserID: "u1", ResourceType: "video", ResourceID: 1, Rating: rating(2)},
+		{UserID: "u1", ResourceType: "exercise", ResourceID: 1, Completed: true},
+		{UserID: "u2", ResourceType: "courseware", ResourceID: 3},
+	}
+	watches := []models.VideoWatch{
+		{UserID: "u1", VideoID: 1, Duration: 100, WatchedSeconds: 80},
+		{UserID: "u2", VideoID: 2, Duration: 100, WatchedSeconds: 150},
+		{UserID: "u2", VideoID: 4, Duration: 0, WatchedSeconds: 10},
+	}
+	got := buildInteractions(feedback, watches)
+	if s := got["u1"][video(1)]; s != 0.8 {
+		t.Errorf("video coverage should beat low rating, got %v", s)
+	}
+	if s := got["u1"][repository.ResourceKey{Type: "exercise", ID: 1}]; s != 1 {
+		t.Errorf("completed exercise strength = %v", s)
+	}
+	if s := got["u2"][repository.ResourceKey{Type: "courseware", ID: 3}]; s != 0.5 {
+		t.Errorf("plain record strength = %v", s)
+	}
+	if s := got["u2"][video(2)]; s != 1 {
+		t.Errorf("coverage should be capped at 1, got %v", s)
+	}
+	if _, ok := got["u2"][video(4)]; ok {
+		t.Error("video without duration should be ignored")
+	}
+}
+
+func TestItemSimilarities(t *testing.T) {
+	interactions := map[string]map[repository.ResourceKey]float64{
+		"u1": {video(1): 1, video(2): 1, video(3): 1},
+		"u2": {video(1): 1, video(2): 1},
+		"u3": {video(1): 1, video(4): 1},
+	}
+	neighbors := itemSimilarities(interactions, similaritySettings{Neighbors: 5, MinCommonUsers: 2, Shrinkage: 0})
+	if list := neighbors[video(1)]; len(list) != 1 || list[0].Key != video(2) || list[0].CommonUsers != 2 {
+		t.Fatalf("neighbors of video 1 = %+v", list)
+	}
+	if !near(neighbors[video(1)][0].Score, 2/(1.7320508075688772*1.4142135623730951)) {
+		t.Errorf("cosine = %v", neighbors[video(1)][0].Score)
+	}
+	if _, ok := neighbors[video(3)]; ok {
+		t.Error("pairs below MinCommonUsers should be dropped")
+	}
+
+	shrunk := itemSimilarities(interactions, similaritySettings{Neighbors: 5, MinCommonUsers: 2, Shrinkage: 2})
+	if got, want := shrunk[video(1)][0].Score, neighbors[video(1)][0].Score/2; !near(got, want) {
+		t.Errorf("shrunk score = %v, want %v", got, want)
+	}
+
+	top := itemSimilarities(interactions, similaritySettings{Neighbors: 1, MinCommonUsers: 1})
+	if len(top[video(1)]) != 1 || top[video(1)][0].Key != video(2) {
+		t.Errorf("top-1 neighbors of video 1 = %+v", top[video(1)])
+	}
+}
+
+func TestScoreCandidates(t *testing.T) {
+	similar := []models.ResourceSimilarity{
+		{ResourceType: "video", ResourceID: 1, SimilarType: "video", SimilarID: 3, Score: 0.5},
+		{ResourceType: "video", ResourceID: 2, SimilarType: "video", SimilarID: 3, Score: 0.4},
+		{ResourceType: "video", ResourceID: 1, SimilarType: "video", SimilarID: 2, Score: 0.9},
+		{ResourceType: "video", ResourceID: 1, SimilarType: "video", SimilarID: 4, Score: 0.6},
+		{ResourceType: "video", ResourceID: 1, SimilarType: "video", SimilarID: 5, Score: 0.8},
+		{ResourceType: "video", ResourceID: 9, SimilarType: "video", SimilarID: 6, Score: 1},
+	}
+	seeds := map[repository.ResourceKey]float64{video(1): 1, video(2): 0.5}
+	got := scoreCandidates(seeds, similar, map[repository.ResourceKey]bool{video(5): true})
+	if len(got) != 2 {
+		t.Fatalf("candidates = %+v", got)
+	}
+	if got[0].Key != video(3) || !near(got[0].Score, 0.7) || got[0].Because != video(1) {
+		t.Errorf("first candidate = %+v", got[0])
+	}
+	if got[1].Key != video(4) || got[1].Score != 0.6 {
+		t.Errorf("second candidate = %+v", got[1])
+	}
+}
+
+func near(a, b float64) bool {
+	return a-b < 1e-9 && b-a < 1e-9
+}
+
+func TestSimilaritySettingsFromConfig(t *testing.T) {
+	got := similaritySettingsFromConfig(&config.RecommendConfig{RefreshInterval: 60, Shrinkage: 5})
+	want := defaultSimilaritySettings
+	want.Shrinkage = 5
+	if got != want {
+		t.Errorf("partial config gave %+v, want %+v", got, want)
+	}
+}
@@ -0,0 +1,392 @@
+package recommend
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/RMS_V3/log"
+	"github.com/RMS_V3/middleware/neo4jUtils"
+	"github.com/RMS_V3/pkg/response"
+	"github.com/gin-gonic/gin"
+	"github.com/neo4j/neo4j-go-driver/v4/neo4j"
+)
+
+// pointInfo 知识点及其所在的小节和章节
+type pointInfo struct {
+	ID          int64
+	Name        string
+	SectionID   int64
+	SectionName string
+	ChapterID   int64
+	ChapterName string
+}
+
+// Step 学习顺序中的一步，Depth 为距目标知识点的最短前置距离，目标本身为 0
+type Step struct {
+	Order   int    `json:"order"`
+	PointID int64  `json:"point_id"`
+	Name    string `json:"name"`
+	Depth   int    `json:"depth"`
+	Chapter string `json:"chapter"`
+	Section string `json:"section"`
+}
+
+// StepGroup 连续属于同一小节的步骤
+type StepGroup struct {
+	ChapterID int64  `json:"chapter_id"`
+	Chapter   string `json:"chapter"`
+	SectionID int64  `json:"section_id"`
+	Section   string `json:"section"`
+	Steps     []Step `json:"steps"`
+}
+
+// prerequisiteGraph 由 前置 关系构成的图，preds[p] 为 p 的直接前置知识点
+type prerequisiteGraph struct {
+	preds map[int64][]int64
+	succs map[int64][]int64
+}
+
+func newPrerequisiteGraph(edges [][2]int64) *prerequisiteGraph {
+	g := &prerequisiteGraph{
+		preds: make(map[int64][]int64),
+		succs: make(map[int64][]int64),
+	}
+	for _, e := range edges {
+		g.preds[e[1]] = append(g.preds[e[1]], e[0])
+		g.succs[e[0]] = append(g.succs[e[0]], e[1])
+	}
+	return g
+}
+
+// collectPrerequisites 从目标出发沿 前置 关系反向广度优先搜索，返回每个知识点到目标的最短距离。
+// maxDepth 为 0 时不限制深度；skip 不为空时，返回 true 的知识点不会被收集，也不再追溯它的前置知识点
+func (g *prerequisiteGraph) collectPrerequisites(target int64, maxDepth int, skip func(int64) bool) map[int64]int {
+	depth := map[int64]int{target: 0}
+	queue := []int64{target}
+	for len(queue) > 0 {
+		cur := queue[0]
+		queue = queue[1:]
+		if maxDepth > 0 && depth[cur] >= maxDepth {
+			continue
+		}
+		for _, p := range g.preds[cur] {
+			if _, ok := depth[p]; ok {
+				continue
+			}
+			if skip != nil && skip(p) {
+				continue
+			}
+			depth[p] = depth[cur] + 1
+			queue = append(queue, p)
+		}
+	}
+	return depth
+}
+
+// sectionContinuity 多个知识点同时可学时，优先继续上一步所在的小节，其次按章节、小节的创建顺序，减少来回切换
+func sectionContinuity(info map[int64]pointInfo) func(a, b, last int64) bool {
+	return func(a, b, last int64) bool {
+		pa, pb := info[a], info[b]
+		if prev, ok := info[last]; ok {
+			if sa, sb := pa.SectionID == prev.SectionID, pb.SectionID == prev.SectionID; sa != sb {
+				return sa
+			}
+		}
+		if pa.ChapterID != pb.ChapterID {
+			return pa.ChapterID < pb.ChapterID
+		}
+		if pa.SectionID != pb.SectionID {
+			return pa.SectionID < pb.SectionID
+		}
+		return a < b
+	}
+}
+
+// topologicalOrder 对 ids 中的知识点做拓扑排序，保证每个知识点排在其前置知识点之后。
+// 多个知识点同时可学时由 less 决定先后，last 为上一步的知识点，第一步时为 -1。
+// 存在环时返回无法排序的知识点
+func (g *prerequisiteGraph) topologicalOrder(ids []int64, less func(a, b, last int64) bool) ([]int64, []int64) {
+	inSet := make(map[int64]bool, len(ids))
+	for _, id := range ids {
+		inSet[id] = true
+	}
+	indegree := make(map[int64]int, len(ids))
+	for _, id := range ids {
+		seen := make(map[int64]bool)
+		for _, p := range g.preds[id] {
+			if inSet[p] && !seen[p] {
+				seen[p] = true
+				indegree[id]++
+			}
+		}
+	}
+	var ready []int64
+	for _, id := range ids {
+		if indegree[id] == 0 {
+			ready = append(ready, id)
+		}
+	}
+
+	order := make([]int64, 0, len(ids))
+	var last int64 = -1
+	emitted := make(map[int64]bool, len(ids))
+	for len(ready) > 0 {
+		sort.Slice(ready, func(i, j int) bool {
+			return less(ready[i], ready[j], last)
+		})
+		next := ready[0]
+		ready = ready[1:]
+		order = append(order, next)
+		emitted[next] = true
+		last = next
+
+		seen := make(map[int64]bool)
+		for _, s := range g.succs[next] {
+			if !inSet[s] || seen[s] {
+				continue
+			}
+			seen[s] = true
+			indegree[s]--
+			if indegree[s] == 0 {
+				ready = append(ready, s)
+			}
+		}
+	}
+
+	var blocked []int64
+	for _, id := range ids {
+		if !emitted[id] {
+			blocked = append(blocked, id)
+		}
+	}
+	sort.Slice(blocked, func(i, j int) bool { return blocked[i] < blocked[j] })
+	return order, blocked
+}
+
+// buildSteps 把排好序的知识点转换为学习步骤，并把连续属于同一小节的步骤分为一组
+func buildSteps(order []int64, depth map[int64]int, info map[int64]pointInfo) ([]Step, []StepGroup) {
+	steps := make([]Step, 0, len(order))
+	groups := []StepGroup{}
+	for i, id := range order {
+		p := info[id]
+		step := Step{
+			Order:   i + 1,
+			PointID: id,
+			Name:    p.Name,
+			Depth:   depth[id],
+			Chapter: p.ChapterName,
+			Section: p.SectionName,
+		}
+		steps = append(steps, step)
+		if n := len(groups); n == 0 || groups[n-1].SectionID != p.SectionID || groups[n-1].ChapterID != p.ChapterID {
+			groups = append(groups, StepGroup{
+				ChapterID: p.ChapterID,
+				Chapter:   p.ChapterName,
+				SectionID: p.SectionID,
+				Section:   p.SectionName,
+			})
+		}
+		groups[len(groups)-1].Steps = append(groups[len(groups)-1].Steps, step)
+	}
+	return steps, groups
+}
+
+// loadPrerequisiteGraph 读取全部知识点间的 前置 关系
+func loadPrerequisiteGraph(session neo4j.Session) (*prerequisiteGraph, error) {
+	result, err := session.Run(`
+	MATCH (a:point)-[:前置]->(b:point)
+	RETURN id(a) AS source, id(b) AS target`, nil)
+	if err != nil {
+		return nil, err
+	}
+	var edges [][2]int64
+	for result.Next() {
+		record := result.Record()
+		source, _ := record.Get("source")
+		target, _ := record.Get("target")
+		s, ok1 := source.(int64)
+		t, ok2 := target.(int64)
+		if ok1 && ok2 && s != t {
+			edges = append(edges, [2]int64{s, t})
+		}
+	}
+	if err := result.Err(); err != nil {
+		return nil, err
+	}
+	return newPrerequisiteGraph(edges), nil
+}
+
+// loadPointInfo 读取知识点名称及其所在小节和章节，不属于任何小节的知识点章节信息为空
+func loadPointInfo(session neo4j.Session, ids []int64) (map[int64]pointInfo, error) {
+	result, err := session.Run(`
+	MATCH (p:point)
+	WHERE id(p) IN $ids
+	OPTIONAL MATCH (s:section)-[:包含]->(p)
+	OPTIONAL MATCH (c:chapter)-[:包含]->(s)
+	RETURN id(p) AS id, p.name AS name, id(s) AS sectionId, s.name AS sectionName,
+		id(c) AS chapterId, c.name AS chapterName`,
+		map[string]interface{}{"ids": ids})
+	if err != nil {
+		return nil, err
+	}
+	info := make(map[int64]pointInfo, len(ids))
+	for result.Next() {
+		record := result.Record()
+		id, _ := record.Get("id")
+		pointId, ok := id.(int64)
+		if !ok {
+			continue
+		}
+		if _, exists := info[pointId]; exists {
+			continue
+		}
+		p := pointInfo{ID: pointId}
+		name, _ := record.Get("name")
+		sectionId, _ := record.Get("sectionId")
+		sectionName, _ := record.Get("sectionName")
+		chapterId, _ := record.Get("chapterId")
+		chapterName, _ := record.Get("chapterName")
+		p.Name, _ = name.(string)
+		p.SectionName, _ = sectionName.(string)
+		p.ChapterName, _ = chapterName.(string)
+		p.SectionID, ok = sectionId.(int64)
+		if !ok {
+			p.SectionID = -1
+		}
+		p.ChapterID, ok = chapterId.(int64)
+		if !ok {
+			p.ChapterID = -1
+		}
+		info[pointId] = p
+	}
+	return info, result.Err()
+}
+
+// resolveTargetPoint 根据 point_id 或 point_name 参数确定目标知识点，失败时已写入响应
+func resolveTargetPoint(c *gin.Context, session neo4j.Session) (int64, bool) {
+	return resolvePoint(c, session, "point")
+}
+
+// resolvePoint 根据 <prefix>_id 或 <prefix>_name 参数确定知识点，失败时已写入响应
+func resolvePoint(c *gin.Context, session neo4j.Session, prefix string) (int64, bool) {
+	if idStr := c.Query(prefix + "_id"); idStr != "" {
+		id, err := strconv.ParseInt(idStr, 10, 64)
+		if err != nil {
+			c.JSON(400, response.Error(400, fmt.Sprintf("无效的 %s_id", prefix)))
+			return 0, false
+		}
+		return id, true
+	}
+	name := c.Query(prefix + "_name")
+	if name == "" {
+		c.JSON(400, response.Error(400, fmt.Sprintf("缺少参数: %s_id 或 %s_name", prefix, prefix)))
+		return 0, false
+	}
+	result, err := session.Run("MATCH (p:point {name: $name}) RETURN id(p) AS id", map[string]interface{}{"name": name})
+	if err != nil {
+		c.JSON(500, response.Error(500, "查询知识点失败"))
+		return 0, false
+	}
+	if !result.Next() {
+		if err := result.Err(); err != nil {
+			c.JSON(500, response.Error(500, "查询知识点失败"))
+			return 0, false
+		}
+		c.JSON(404, response.Error(404, fmt.Sprintf("知识点 '%s' 不存在", name)))
+		return 0, false
+	}
+	id, _ := result.Record().Values[0].(int64)
+	return id, true
+}
+
+// curriculum 目标知识点的完整学习顺序
+type curriculum struct {
+	graph *prerequisiteGraph
+	info  map[int64]pointInfo
+	depth map[int64]int
+	order []int64
+}
+
+// buildCurriculum 收集目标的全部前置知识点并排序，失败时已写入响应
+func buildCurriculum(c *gin.Context, session neo4j.Session, target int64, maxDepth int) (*curriculum, bool) {
+	graph, err := loadPrerequisiteGraph(session)
+	if err != nil {
+		log.Errorf("load prerequisite graph failed: %v", err)
+		c.JSON(500, response.Error(500, "路径生成失败"))
+		return nil, false
+	}
+	depth := graph.collectPrerequisites(target, maxDepth, nil)
+	ids := make([]int64, 0, len(depth))
+	for id := range depth {
+		ids = append(ids, id)
+	}
+	info, err := loadPointInfo(session, ids)
+	if err != nil {
+		log.Errorf("load point info failed: %v", err)
+		c.JSON(500, response.Error(500, "路径生成失败"))
+		return nil, false
+	}
+	if _, ok := info[target]; !ok {
+		c.JSON(404, response.Error(404, "未找到对应的知识点"))
+		return nil, false
+	}
+
+	order, blocked := graph.topologicalOrder(ids, sectionContinuity(info))
+	if len(blocked) > 0 {
+		names := make([]string, len(blocked))
+		for i, id := range blocked {
+			names[i] = info[id].Name
+		}
+		c.JSON(409, response.Error(409, fmt.Sprintf("前置关系存在环，无法确定学习顺序: %s", strings.Join(names, ", "))))
+		return nil, false
+	}
+	return &curriculum{graph: graph, info: info, depth: depth, order: order}, true
+}
+
+// parseMaxDepth 解析可选的 max_depth 参数，失败时已写入响应
+func parseMaxDepth(c *gin.Context) (int, bool) {
+	maxDepth := 0
+	if depthStr := c.Query("max_depth"); depthStr != "" {
+		var err error
+		if maxDepth, err = strconv.Atoi(depthStr); err != nil || maxDepth < 0 {
+			c.JSON(400, response.Error(400, "无效的 max_depth"))
+			return 0, false
+		}
+	}
+	return maxDepth, true
+}
+
+// GenerateCurriculum 为目标知识点生成按拓扑顺序排列的前置学习计划，并按章节和小节分组。
+// max_depth 限制沿 前置 关系向前追溯的层数，0 或不传表示不限制
+func GenerateCurriculum(c *gin.Context) {
+	maxDepth, ok := parseMaxDepth(c)
+	if !ok {
+		return
+	}
+
+	session := neo4jUtils.GetSession()
+	if session == nil {
+		c.JSON(500, response.Error(500, "无法获取 Neo4j 会话"))
+		return
+	}
+	defer session.Close()
+
+	target, ok := resolveTargetPoint(c, session)
+	if !ok {
+		return
+	}
+	cur, ok := buildCurriculum(c, session, target, maxDepth)
+	if !ok {
+		return
+	}
+	steps, groups := buildSteps(cur.order, cur.depth, cur.info)
+
+	c.JSON(200, response.Success(map[string]interface{}{
+		"target":    map[string]interface{}{"id": target, "name": cur.info[target].Name},
+		"max_depth": maxDepth,
+		"steps":     steps,
+		"groups":    groups,
+	}))
+}
@@ -0,0 +1,74 @@
+package recommend
+
+import "testing"
+
+func TestCurriculumOrder(t *testing.T) {
+	// 1 -> 3, 2 -> 3, 3 -> 5, 4 -> 5；知识点 2、3 属于小节 20，其余属于小节 10
+	g := newPrerequisiteGraph([][2]int64{{1, 3}, {2, 3}, {3, 5}, {4, 5}, {9, 1}})
+	info := map[int64]pointInfo{
+		1: {ID: 1, SectionID: 10, ChapterID: 100},
+		2: {ID: 2, SectionID: 20, ChapterID: 100},
+		3: {ID: 3, SectionID: 20, ChapterID: 100},
+		4: {ID: 4, SectionID: 10, ChapterID: 100},
+		5: {ID: 5, SectionID: 10, ChapterID: 100},
+	}
+
+	depth := g.collectPrerequisites(5, 2, nil)
+	if _, ok := depth[9]; ok {
+		t.Fatal("point 9 is beyond max depth")
+	}
+	ids := []int64{5, 4, 3, 2, 1}
+	order, blocked := g.topologicalOrder(ids, sectionContinuity(info))
+	if len(blocked) != 0 {
+		t.Fatalf("unexpected blocked points: %v", blocked)
+	}
+	position := make(map[int64]int)
+	for i, id := range order {
+		position[id] = i
+	}
+	for _, e := range [][2]int64{{1, 3}, {2, 3}, {3, 5}, {4, 5}} {
+		if position[e[0]] > position[e[1]] {
+			t.Errorf("%d should come before %d in %v", e[0], e[1], order)
+		}
+	}
+
+	_, groups := buildSteps(order, depth, info)
+	// 1,4 同属小节 10 会连续学习：[1 4] [2 3] [5]
+	if len(groups) != 3 || len(groups[0].Steps) != 2 {
+		t.Errorf("unexpected groups: %+v", groups)
+	}
+}
+
+func TestCurriculumCycle(t *testing.T) {
+	g := newPrerequisiteGraph([][2]int64{{1, 2}, {2, 1}, {2, 3}})
+	depth := g.collectPrerequisites(3, 0, nil)
+	ids := make([]int64, 0, len(depth))
+	for id := range depth {
+		ids = append(ids, id)
+	}
+	_, blocked := g.topologicalOrder(ids, sectionContinuity(map[int64]pointInfo{}))
+	if len(blocked) != 3 {
+		t.Errorf("expected all points blocked by the cycle, got %v", blocked)
+	}
+}
+
+func TestPersonalizedPruning(t *testing.T) {
+	// 9 -> 1 -> 3 -> 5, 4 -> 5；已掌握知识点 1 时，它的前置 9 也不再需要
+	g := newPrerequisiteGraph([][2]int64{{9, 1}, {1, 3}, {3, 5}, {4, 5}})
+	mastery := map[int64]float64{1: 0.9, 4: 0.3}
+	depth := g.collectPrerequisites(5, 0, func(id int64) bool { return mastery[id] >= 0.8 })
+	if _, ok := depth[1]; ok {
+		t.Error("mastered point 1 should be pruned")
+	}
+	if _, ok := depth[9]; ok {
+		t.Error("prerequisite of a mastered point should be pruned")
+	}
+
+	ids := []int64{5, 4, 3}
+	difficulty := map[int64]float64{3: 2, 4: 1, 5: 5}
+	gap := map[int64]float64{3: 1, 4: 0.7, 5: 1}
+	order, _ := g.topologicalOrder(ids, masteryOrder(difficulty, gap))
+	if order[0] != 4 || order[2] != 5 {
+		t.Errorf("unexpected order %v", order)
+	}
+}
@@ -0,0 +1,217 @@
+package recommend
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	analysis "github.com/RMS_V3/internal/kg/application/Analysis"
+	"github.com/RMS_V3/internal/kg/repository"
+	"github.com/RMS_V3/internal/user"
+	"github.com/RMS_V3/log"
+	"github.com/RMS_V3/middleware/neo4jUtils"
+	"github.com/RMS_V3/pkg/response"
+	"github.com/gin-gonic/gin"
+)
+
+// defaultMasteryThreshold 掌握度达到该值视为已掌握
+const defaultMasteryThreshold = 0.8
+
+// PersonalStep 个性化学习路径中的一步
+type PersonalStep struct {
+	Step
+	// Mastery 当前掌握度，没有学习记录时为 0
+	Mastery    float64  `json:"mastery"`
+	Gap        float64  `json:"gap"`
+	Difficulty float64  `json:"difficulty"`
+	RequiredBy []string `json:"required_by"`
+	Reason     string   `json:"reason"`
+}
+
+// SkippedPoint 因已掌握而被跳过的前置知识点
+type SkippedPoint struct {
+	PointID int64   `json:"point_id"`
+	Name    string  `json:"name"`
+	Mastery float64 `json:"mastery"`
+}
+
+// masteryOrder 多个知识点同时可学时，先学难度低的，难度相同时先补掌握度差距大的
+func masteryOrder(difficulty map[int64]float64, gap map[int64]float64) func(a, b, last int64) bool {
+	return func(a, b, last int64) bool {
+		if difficulty[a] != difficulty[b] {
+			return difficulty[a] < difficulty[b]
+		}
+		if gap[a] != gap[b] {
+			return gap[a] > gap[b]
+		}
+		return a < b
+	}
+}
+
+// personalPath 个性化学习路径
+type personalPath struct {
+	Target    map[string]interface{} `json:"target"`
+	Threshold float64                `json:"threshold"`
+	MaxDepth  int                    `json:"max_depth"`
+	Steps     []PersonalStep         `json:"steps"`
+	Skipped   []SkippedPoint         `json:"skipped"`
+}
+
+// GeneratePersonalizedPath 根据当前登录学生的掌握情况生成学习路径：
+// 已掌握的前置知识点及其更早的前置不再出现，其余按难度和掌握度差距排序，并说明每一步的原因
+func GeneratePersonalizedPath(c *gin.Context) {
+	u := user.CurrentUser(c)
+	path, ok := buildPersonalizedPath(c, u)
+	if !ok {
+		return
+	}
+	c.JSON(200, response.Success(path))
+}
+
+// buildPersonalizedPath 为学生 u 生成个性化学习路径，失败时已写入响应
+func buildPersonalizedPath(c *gin.Context, u *user.User) (*personalPath, bool) {
+	maxDepth, ok := parseMaxDepth(c)
+	if !ok {
+		return nil, false
+	}
+	threshold := defaultMasteryThreshold
+	if thresholdStr := c.Query("threshold"); thresholdStr != "" {
+		var err error
+		threshold, err = strconv.ParseFloat(thresholdStr, 64)
+		if err != nil || threshold <= 0 || threshold > 1 {
+			c.JSON(400, response.Error(400, "threshold 必须在 (0, 1] 之间"))
+			return nil, false
+		}
+	}
+
+	session := neo4jUtils.GetSession()
+	if session == nil {
+		c.JSON(500, response.Error(500, "无法获取 Neo4j 会话"))
+		return nil, false
+	}
+	defer session.Close()
+
+	target, ok := resolveTargetPoint(c, session)
+	if !ok {
+		return nil, false
+	}
+	graph, err := loadPrerequisiteGraph(session)
+	if err != nil {
+		log.Errorf("load prerequisite graph failed: %v", err)
+		c.JSON(500, response.Error(500, "路径生成失败"))
+		return nil, false
+	}
+
+	// 先收集全部前置知识点以读取掌握度，再跳过已掌握的知识点重新收集
+	all := graph.collectPrerequisites(target, maxDepth, nil)
+	allIds := make([]int64, 0, len(all))
+	for id := range all {
+		allIds = append(allIds, id)
+	}
+	mastery, err := repository.GetLearnerMastery(u.Id, allIds)
+	if err != nil {
+		log.Errorf("load mastery of user %s failed: %v", u.Id, err)
+		c.JSON(500, response.Error(500, "读取掌握度失败"))
+		return nil, false
+	}
+	mastered := func(id int64) bool {
+		return id != target && mastery[id] >= threshold
+	}
+	depth := graph.collectPrerequisites(target, maxDepth, mastered)
+	ids := make([]int64, 0, len(depth))
+	for id := range depth {
+		ids = append(ids, id)
+	}
+
+	info, err := loadPointInfo(session, allIds)
+	if err != nil {
+		log.Errorf("load point info failed: %v", err)
+		c.JSON(500, response.Error(500, "路径生成失败"))
+		return nil, false
+	}
+	if _, ok := info[target]; !ok {
+		c.JSON(404, response.Error(404, "未找到对应的知识点"))
+		return nil, false
+	}
+	difficulty, err := analysis.PointDifficultyScores(session, ids)
+	if err != nil {
+		log.Errorf("score difficulty failed: %v", err)
+		c.JSON(500, response.Error(500, "路径生成失败"))
+		return nil, false
+	}
+	gap := make(map[int64]float64, len(ids))
+	for _, id := range ids {
+		gap[id] = 1 - mastery[id]
+	}
+
+	order, blocked := graph.topologicalOrder(ids, masteryOrder(difficulty, gap))
+	if len(blocked) > 0 {
+		names := make([]string, len(blocked))
+		for i, id := range blocked {
+			names[i] = info[id].Name
+		}
+		c.JSON(409, response.Error(409, fmt.Sprintf("前置关系存在环，无法确定学习顺序: %s", strings.Join(names, ", "))))
+		return nil, false
+	}
+
+	baseSteps, _ := buildSteps(order, depth, info)
+	steps := make([]PersonalStep, 0, len(baseSteps))
+	for _, step := range baseSteps {
+		id := step.PointID
+		var requiredBy []string
+		for _, s := range graph.succs[id] {
+			if _, ok := depth[s]; ok {
+				requiredBy = append(requiredBy, info[s].Name)
+			}
+		}
+		sort.Strings(requiredBy)
+		steps = append(steps, PersonalStep{
+			Step:       step,
+			Mastery:    mastery[id],
+			Gap:        gap[id],
+			Difficulty: difficulty[id],
+			RequiredBy: requiredBy,
+			Reason:     stepReason(id == target, requiredBy, mastery, id, threshold),
+		})
+	}
+
+	// 已掌握且被路径中的知识点直接依赖的前置知识点，说明它们为何被跳过
+	skipped := []SkippedPoint{}
+	for _, id := range allIds {
+		if !mastered(id) {
+			continue
+		}
+		for _, s := range graph.succs[id] {
+			if _, ok := depth[s]; ok {
+				skipped = append(skipped, SkippedPoint{PointID: id, Name: info[id].Name, Mastery: mastery[id]})
+				break
+			}
+		}
+	}
+	sort.Slice(skipped, func(i, j int) bool { return skipped[i].PointID < skipped[j].PointID })
+
+	return &personalPath{
+		Target:    map[string]interface{}{"id": target, "name": info[target].Name},
+		Threshold: threshold,
+		MaxDepth:  maxDepth,
+		Steps:     steps,
+		Skipped:   skipped,
+	}, true
+}
+
+// stepReason 生成每一步的说明
+func stepReason(isTarget bool, requiredBy []string, mastery map[int64]float64, id int64, threshold float64) string {
+	status := "尚未学习"
+	if m, ok := mastery[id]; ok {
+		if m >= threshold {
+			status = fmt.Sprintf("已掌握（掌握度 %.2f），可作为复习", m)
+		} else {
+			status = fmt.Sprintf("尚未掌握（掌握度 %.2f）", m)
+		}
+	}
+	if isTarget {
+		return "学习目标，" + status
+	}
+	return fmt.Sprintf("是「%s」的前置知识点，%s", strings.Join(requiredBy, "」「"), status)
+}
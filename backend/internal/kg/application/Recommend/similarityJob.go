@@ -0,0 +1,105 @@
+package recommend
+
+import (
+	"sync"
+	"time"
+
+	"github.com/RMS_V3/config"
+	"github.com/RMS_V3/internal/kg/repository"
+	"github.com/RMS_V3/internal/kg/repository/models"
+	"github.com/RMS_V3/log"
+	"github.com/RMS_V3/pkg/response"
+	"github.com/gin-gonic/gin"
+)
+
+var defaultSimilaritySettings = similaritySettings{Neighbors: 20, MinCommonUsers: 2, Shrinkage: 10}
+
+func configuredSimilaritySettings() similaritySettings {
+	return similaritySettingsFromConfig(config.GetGlobalConfig().RecommendConfig)
+}
+
+// similaritySettingsFromConfig 逐项取配置值，未配置或不为正数的项使用默认值
+func similaritySettingsFromConfig(cfg *config.RecommendConfig) similaritySettings {
+	settings := defaultSimilaritySettings
+	if cfg == nil {
+		return settings
+	}
+	if cfg.Neighbors > 0 {
+		settings.Neighbors = cfg.Neighbors
+	}
+	if cfg.MinCommonUsers > 0 {
+		settings.MinCommonUsers = cfg.MinCommonUsers
+	}
+	if cfg.Shrinkage > 0 {
+		settings.Shrinkage = cfg.Shrinkage
+	}
+	return settings
+}
+
+// refreshMu 保证同一时间只有一个相似度计算在运行
+var refreshMu sync.Mutex
+
+// RefreshSimilarities 根据全部交互数据重新计算资源相似度并替换已保存的结果，返回保存的相似资源对数
+func RefreshSimilarities() (int, error) {
+	refreshMu.Lock()
+	defer refreshMu.Unlock()
+
+	feedback, err := repository.ListResourceFeedback()
+	if err != nil {
+		return 0, err
+	}
+	watches, err := repository.ListVideoWatches()
+	if err != nil {
+		return 0, err
+	}
+	neighbors := itemSimilarities(buildInteractions(feedback, watches), configuredSimilaritySettings())
+	now := time.Now()
+	var rows []models.ResourceSimilarity
+	for key, list := range neighbors {
+		for _, n := range list {
+			rows = append(rows, models.ResourceSimilarity{
+				ResourceType: key.Type,
+				ResourceID:   key.ID,
+				SimilarType:  n.Key.Type,
+				SimilarID:    n.Key.ID,
+				Score:        n.Score,
+				CommonUsers:  n.CommonUsers,
+				ComputedAt:   now,
+			})
+		}
+	}
+	return len(rows), repository.ReplaceResourceSimilarities(rows)
+}
+
+// StartSimilarityJob 启动后台任务：立即计算一次资源相似度，之后按配置的间隔定期重新计算
+func StartSimilarityJob() {
+	interval := 0
+	if cfg := config.GetGlobalConfig().RecommendConfig; cfg != nil {
+		interval = cfg.RefreshInterval
+	}
+	go func() {
+		for {
+			start := time.Now()
+			if n, err := RefreshSimilarities(); err != nil {
+				log.Errorf("refresh resource similarities failed: %v", err)
+			} else {
+				log.Infof("refreshed %d resource similarities in %v", n, time.Since(start))
+			}
+			if interval <= 0 {
+				return
+			}
+			time.Sleep(time.Duration(interval) * time.Minute)
+		}
+	}()
+}
+
+// RefreshSimilaritiesNow 管理员手动触发一次资源相似度计算
+func RefreshSimilaritiesNow(c *gin.Context) {
+	n, err := RefreshSimilarities()
+	if err != nil {
+		log.Errorf("refresh resource similarities failed: %v", err)
+		c.JSON(500, response.Error(500, "计算资源相似度失败"))
+		return
+	}
+	c.JSON(200, response.Success(map[string]interface{}{"pairs": n}))
+}
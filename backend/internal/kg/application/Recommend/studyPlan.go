@@ -0,0 +1,248 @@
+package recommend
+
+import (
+	"sort"
+
+	"github.com/RMS_V3/internal/kg/repository"
+	"github.com/RMS_V3/internal/kg/repository/models"
+	"github.com/RMS_V3/internal/user"
+	"github.com/RMS_V3/log"
+	"github.com/RMS_V3/pkg/response"
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	// ratingPrior、ratingPriorWeight 评分的贝叶斯先验：评分人数少时向 ratingPrior 收缩
+	ratingPrior       = 3.0
+	ratingPriorWeight = 3.0
+	// ratingShare 综合得分中评分所占比例，其余为完成率
+	ratingShare = 0.6
+	// exercisesPerStep 每一步推荐的习题数
+	exercisesPerStep = 3
+)
+
+// exerciseLevels 习题难度由低到高
+var exerciseLevels = []string{"easy", "medium", "hard"}
+
+// RankedResource 推荐给学生的资源及其排序依据
+type RankedResource struct {
+	ID             int64   `json:"id"`
+	Title          string  `json:"title"`
+	URL            string  `json:"url"`
+	Difficulty     string  `json:"difficulty,omitempty"`
+	Score          float64 `json:"score"`
+	AvgRating      float64 `json:"avg_rating"`
+	Ratings        int64   `json:"ratings"`
+	CompletionRate float64 `json:"completion_rate"`
+}
+
+// StudyPlanStep 学习计划中的一步：知识点以及为其挑选的资源
+type StudyPlanStep struct {
+	PersonalStep
+	// ExerciseLevel 按掌握度匹配的习题难度
+	ExerciseLevel string           `json:"exercise_level"`
+	Video         *RankedResource  `json:"video"`
+	Courseware    *RankedResource  `json:"courseware"`
+	Exercises     []RankedResource `json:"exercises"`
+}
+
+// resourceScore 综合评分和完成率给资源打分，没有反馈数据的资源得到中等分数
+func resourceScore(stats repository.ResourceStats) float64 {
+	rating := (ratingPrior*ratingPriorWeight + stats.AvgRating*float64(stats.Ratings)) /
+		(ratingPriorWeight + float64(stats.Ratings))
+	// 完成率使用拉普拉斯平滑，避免一两个学习者决定排序
+	completion := (float64(stats.Completions) + 1) / (float64(stats.Learners) + 2)
+	return ratingShare*rating/5 + (1-ratingShare)*completion
+}
+
+func rankResource(id int64, title, url, difficulty string, stats repository.ResourceStats) RankedResource {
+	r := RankedResource{
+		ID:         id,
+		Title:      title,
+		URL:        url,
+		Difficulty: difficulty,
+		Score:      resourceScore(stats),
+		AvgRating:  stats.AvgRating,
+		Ratings:    stats.Ratings,
+	}
+	if stats.Learners > 0 {
+		r.CompletionRate = float64(stats.Completions) / float64(stats.Learners)
+	}
+	return r
+}
+
+// sortByScore 得分高的在前，得分相同时较新的资源在前
+func sortByScore(resources []RankedResource) {
+	sort.SliceStable(resources, func(i, j int) bool {
+		if resources[i].Score != resources[j].Score {
+			return resources[i].Score > resources[j].Score
+		}
+		return resources[i].ID > resources[j].ID
+	})
+}
+
+// exerciseLevel 按掌握度匹配习题难度
+func exerciseLevel(mastery float64) string {
+	switch {
+	case mastery < 0.4:
+		return "easy"
+	case mastery < 0.7:
+		return "medium"
+	default:
+		return "hard"
+	}
+}
+
+func levelIndex(level string) int {
+	for i, l := range exerciseLevels {
+		if l == level {
+			return i
+		}
+	}
+	return 0
+}
+
+// pickExercises 优先选择目标难度的习题，不足时用相邻难度补足，同一难度内按得分排序
+func pickExercises(exercises []RankedResource, level string, limit int) []RankedResource {
+	target := levelIndex(level)
+	candidates := make([]RankedResource, len(exercises))
+	copy(candidates, exercises)
+	sortByScore(candidates)
+	sort.SliceStable(candidates, func(i, j int) bool {
+		di := abs(levelIndex(candidates[i].Difficulty) - target)
+		dj := abs(levelIndex(candidates[j].Difficulty) - target)
+		return di < dj
+	})
+	if len(candidates) > limit {
+		candidates = candidates[:limit]
+	}
+	return candidates
+}
+
+func abs(x int) int {
+	if x < 0 {
+		return -x
+	}
+	return x
+}
+
+// bestResource 返回得分最高的资源，没有资源时返回 nil
+func bestResource(resources []RankedResource) *RankedResource {
+	if len(resources) == 0 {
+		return nil
+	}
+	sorted := append([]RankedResource(nil), resources...)
+	sortByScore(sorted)
+	return &sorted[0]
+}
+
+// rankedPointResources 按知识点分组并打分的资源
+type rankedPointResources struct {
+	videos      map[int64][]RankedResource
+	coursewares map[int64][]RankedResource
+	exercises   map[int64][]RankedResource
+}
+
+// loadRankedResources 读取知识点关联的资源及其反馈统计
+func loadRankedResources(pointIds []int64) (*rankedPointResources, error) {
+	videos, exercises, coursewares, err := repository.GetResourcesByPoints(pointIds)
+	if err != nil {
+		return nil, err
+	}
+	videoStats, err := repository.GetResourceStats("video", videoIds(videos))
+	if err != nil {
+		return nil, err
+	}
+	exerciseStats, err := repository.GetResourceStats("exercise", exerciseIds(exercises))
+	if err != nil {
+		return nil, err
+	}
+	coursewareStats, err := repository.GetResourceStats("courseware", coursewareIds(coursewares))
+	if err != nil {
+		return nil, err
+	}
+
+	ranked := &rankedPointResources{
+		videos:      make(map[int64][]RankedResource),
+		coursewares: make(map[int64][]RankedResource),
+		exercises:   make(map[int64][]RankedResource),
+	}
+	for _, v := range videos {
+		ranked.videos[v.KnowledgePointID] = append(ranked.videos[v.KnowledgePointID],
+			rankResource(v.ID, v.Title, v.PlayURL, "", videoStats[v.ID]))
+	}
+	for _, cw := range coursewares {
+		ranked.coursewares[cw.KnowledgePointID] = append(ranked.coursewares[cw.KnowledgePointID],
+			rankResource(cw.ID, cw.Title, cw.CoursewareURL, "", coursewareStats[cw.ID]))
+	}
+	for _, e := range exercises {
+		ranked.exercises[e.KnowledgePointID] = append(ranked.exercises[e.KnowledgePointID],
+			rankResource(e.ID, e.Title, e.ExerciseURL, e.Difficulty, exerciseStats[e.ID]))
+	}
+	return ranked, nil
+}
+
+func videoIds(videos []models.Video) []int64 {
+	ids := make([]int64, len(videos))
+	for i, v := range videos {
+		ids[i] = v.ID
+	}
+	return ids
+}
+
+func exerciseIds(exercises []models.Exercise) []int64 {
+	ids := make([]int64, len(exercises))
+	for i, e := range exercises {
+		ids[i] = e.ID
+	}
+	return ids
+}
+
+func coursewareIds(coursewares []models.Courseware) []int64 {
+	ids := make([]int64, len(coursewares))
+	for i, cw := range coursewares {
+		ids[i] = cw.ID
+	}
+	return ids
+}
+
+// GenerateStudyPlan 在个性化学习路径的基础上，为每一步挑选评分和完成率最高的视频、课件，
+// 以及与当前掌握度匹配难度的习题，参数与 GeneratePersonalizedPath 相同
+func GenerateStudyPlan(c *gin.Context) {
+	u := user.CurrentUser(c)
+	path, ok := buildPersonalizedPath(c, u)
+	if !ok {
+		return
+	}
+
+	pointIds := make([]int64, len(path.Steps))
+	for i, step := range path.Steps {
+		pointIds[i] = step.PointID
+	}
+	resources, err := loadRankedResources(pointIds)
+	if err != nil {
+		log.Errorf("load resources for study plan failed: %v", err)
+		c.JSON(500, response.Error(500, "读取学习资源失败"))
+		return
+	}
+
+	steps := make([]StudyPlanStep, len(path.Steps))
+	for i, step := range path.Steps {
+		level := exerciseLevel(step.Mastery)
+		steps[i] = StudyPlanStep{
+			PersonalStep:  step,
+			ExerciseLevel: level,
+			Video:         bestResource(resources.videos[step.PointID]),
+			Courseware:    bestResource(resources.coursewares[step.PointID]),
+			Exercises:     pickExercises(resources.exercises[step.PointID], level, exercisesPerStep),
+		}
+	}
+
+	c.JSON(200, response.Success(map[string]interface{}{
+		"target":    path.Target,
+		"threshold": path.Threshold,
+		"max_depth": path.MaxDepth,
+		"steps":     steps,
+		"skipped":   path.Skipped,
+	}))
+}
@@ -0,0 +1,40 @@
+package recommend
+
+import (
+	"testing"
+
+	"github.com/RMS_V3/internal/kg/repository"
+)
+
+func TestResourceScore(t *testing.T) {
+	none := resourceScore(repository.ResourceStats{})
+	// 一个五星评分不应胜过大量高分且完成率高的资源
+	single := resourceScore(repository.ResourceStats{AvgRating: 5, Ratings: 1, Completions: 1, Learners: 1})
+	popular := resourceScore(repository.ResourceStats{AvgRating: 4.6, Ratings: 40, Completions: 36, Learners: 40})
+	poor := resourceScore(repository.ResourceStats{AvgRating: 1.5, Ratings: 20, Completions: 2, Learners: 20})
+	if !(popular > single && single > none && none > poor) {
+		t.Errorf("unexpected order: popular=%v single=%v none=%v poor=%v", popular, single, none, poor)
+	}
+}
+
+func TestPickExercises(t *testing.T) {
+	exercises := []RankedResource{
+		{ID: 1, Difficulty: "easy", Score: 0.9},
+		{ID: 2, Difficulty: "medium", Score: 0.5},
+		{ID: 3, Difficulty: "hard", Score: 0.8},
+		{ID: 4, Difficulty: "medium", Score: 0.7},
+	}
+	got := pickExercises(exercises, exerciseLevel(0.5), 3)
+	want := []int64{4, 2, 1}
+	if len(got) != len(want) {
+		t.Fatalf("expected %d exercises, got %d", len(want), len(got))
+	}
+	for i, e := range got {
+		if e.ID != want[i] {
+			t.Errorf("exercise %d = %d, want %d", i, e.ID, want[i])
+		}
+	}
+	if got := pickExercises(nil, "easy", 3); got == nil || len(got) != 0 {
+		t.Errorf("expected empty slice, got %v", got)
+	}
+}
@@ -0,0 +1,343 @@
+package review
+
+import (
+	"fmt"
+
+	"github.com/RMS_V3/log"
+	"github.com/RMS_V3/middleware/neo4jUtils"
+	"github.com/neo4j/neo4j-go-driver/v4/neo4j"
+	"github.com/neo4j/neo4j-go-driver/v4/neo4j/dbtype"
+)
+
+// 修改申请支持的操作
+const (
+	OpCreateNode     = "create_node"
+	OpUpdateNode     = "update_node"
+	OpDeleteNode     = "delete_node"
+	OpCreateRelation = "create_relation"
+	OpUpdateRelation = "update_relation"
+	OpDeleteRelation = "delete_relation"
+)
+
+// Operation 一条图谱修改操作，字段含义与 addNode、updateLink 等接口的参数一致
+type Operation struct {
+	Op              string `json:"op"`
+	NodeType        string `json:"node_type,omitempty"`
+	Name            string `json:"name,omitempty"`
+	Description     string `json:"description,omitempty"`
+	PropertyName    string `json:"property_name,omitempty"`
+	NewValue        string `json:"new_value,omitempty"`
+	SourceType      string `json:"source_type,omitempty"`
+	SourceName      string `json:"source_name,omitempty"`
+	TargetType      string `json:"target_type,omitempty"`
+	TargetName      string `json:"target_name,omitempty"`
+	RelationType    string `json:"relation_type,omitempty"`
+	NewRelationType string `json:"new_relation_type,omitempty"`
+}
+
+// OperationDiff 操作执行前后的对比，Before 为当前图谱中的状态，After 为批准后的状态
+type OperationDiff struct {
+	Operation Operation              `json:"operation"`
+	Before    map[string]interface{} `json:"before"`
+	After     map[string]interface{} `json:"after"`
+	// Cascade 删除章节或小节时会一并删除的下级节点数量
+	Cascade int64 `json:"cascade,omitempty"`
+	// Conflict 当前图谱状态导致该操作无法执行的原因
+	Conflict string `json:"conflict,omitempty"`
+}
+
+var validNodeTypes = map[string]bool{
+	"chapter": true,
+	"section": true,
+	"point":   true,
+}
+
+var validRelationTypes = map[string]bool{
+	"包含": true,
+	"前置": true,
+	"相关": true,
+	"扩展": true,
+}
+
+// validateOperation 检查操作参数是否完整，标签和关系类型只允许白名单中的值，避免拼接进 Cypher 时被注入
+func validateOperation(op Operation) error {
+	switch op.Op {
+	case OpCreateNode, OpDeleteNode:
+		if op.Name == "" || !validNodeTypes[op.NodeType] {
+			return fmt.Errorf("%s 操作必须提供有效的 node_type 和 name", op.Op)
+		}
+	case OpUpdateNode:
+		if op.Name == "" || !validNodeTypes[op.NodeType] || op.PropertyName == "" || op.NewValue == "" {
+			return fmt.Errorf("%s 操作必须提供有效的 node_type, name, property_name 和 new_value", op.Op)
+		}
+		if op.PropertyName == "name" {
+			return fmt.Errorf("不允许修改 name 属性")
+		}
+		// SanitizeLabel 会去掉非法字符，属性名直接拼接进 Cypher，必须与清理结果完全一致
+		clean, err := neo4jUtils.SanitizeLabel(op.PropertyName)
+		if err != nil {
+			return fmt.Errorf("无效的属性名 %s: %s", op.PropertyName, err.Error())
+		}
+		if clean != op.PropertyName {
+			return fmt.Errorf("无效的属性名 %s: 只允许字母、数字和下划线", op.PropertyName)
+		}
+	case OpCreateRelation, OpDeleteRelation, OpUpdateRelation:
+		if op.SourceName == "" || op.TargetName == "" || !validNodeTypes[op.SourceType] || !validNodeTypes[op.TargetType] {
+			return fmt.Errorf("%s 操作必须提供有效的 source_type, source_name, target_type 和 target_name", op.Op)
+		}
+		if !validRelationTypes[op.RelationType] {
+			return fmt.Errorf("无效的关系类型: %s", op.RelationType)
+		}
+		if op.Op == OpUpdateRelation && !validRelationTypes[op.NewRelationType] {
+			return fmt.Errorf("无效的新关系类型: %s", op.NewRelationType)
+		}
+	default:
+		return fmt.Errorf("不支持的操作: %s", op.Op)
+	}
+	return nil
+}
+
+// nodeProps 返回节点的属性，节点不存在时返回 nil
+func nodeProps(r neo4j.Transaction, nodeType string, name string) (map[string]interface{}, error) {
+	query := fmt.Sprintf("MATCH (n:%s {name: $name}) RETURN n", nodeType)
+	result, err := r.Run(query, map[string]interface{}{"name": name})
+	if err != nil {
+		return nil, err
+	}
+	var props map[string]interface{}
+	if result.Next() {
+		if node, ok := result.Record().Values[0].(dbtype.Node); ok {
+			props = node.Props
+		}
+	}
+	return props, result.Err()
+}
+
+func relationExists(r neo4j.Transaction, op Operation, relationType string) (bool, error) {
+	query := fmt.Sprintf(`
+		MATCH (a:%s {name: $sourceName})-[r:%s]->(b:%s {name: $targetName})
+		RETURN count(r) AS count`, op.SourceType, relationType, op.TargetType)
+	result, err := r.Run(query, map[string]interface{}{
+		"sourceName": op.SourceName,
+		"targetName": op.TargetName,
+	})
+	if err != nil {
+		return false, err
+	}
+	record, err := result.Single()
+	if err != nil {
+		return false, err
+	}
+	count, _ := record.Values[0].(int64)
+	return count > 0, nil
+}
+
+// cascadeCount 统计删除章节或小节时会一并删除的下级节点数量
+func cascadeCount(r neo4j.Transaction, op Operation) (int64, error) {
+	if op.NodeType == "point" {
+		return 0, nil
+	}
+	query := fmt.Sprintf(`
+		MATCH (n:%s {name: $name})-[:包含*]->(child)
+		RETURN count(DISTINCT child) AS count`, op.NodeType)
+	result, err := r.Run(query, map[string]interface{}{"name": op.Name})
+	if err != nil {
+		return 0, err
+	}
+	record, err := result.Single()
+	if err != nil {
+		return 0, err
+	}
+	count, _ := record.Values[0].(int64)
+	return count, nil
+}
+
+func relationSnapshot(op Operation, relationType string) map[string]interface{} {
+	return map[string]interface{}{
+		"source_type":   op.SourceType,
+		"source_name":   op.SourceName,
+		"target_type":   op.TargetType,
+		"target_name":   op.TargetName,
+		"relation_type": relationType,
+	}
+}
+
+// diffOperation 根据当前图谱计算单个操作的前后对比
+func diffOperation(r neo4j.Transaction, op Operation) (OperationDiff, error) {
+	diff := OperationDiff{Operation: op}
+	switch op.Op {
+	case OpCreateNode, OpUpdateNode, OpDeleteNode:
+		props, err := nodeProps(r, op.NodeType, op.Name)
+		if err != nil {
+			return diff, err
+		}
+		diff.Before = props
+		switch op.Op {
+		case OpCreateNode:
+			if props != nil {
+				diff.Conflict = "节点已存在"
+			}
+			diff.After = map[string]interface{}{"name": op.Name, "description": op.Description}
+		case OpUpdateNode:
+			if props == nil {
+				diff.Conflict = "节点不存在"
+				break
+			}
+			diff.After = make(map[string]interface{}, len(props)+1)
+			for k, v := range props {
+				diff.After[k] = v
+			}
+			diff.After[op.PropertyName] = op.NewValue
+		case OpDeleteNode:
+			if props == nil {
+				diff.Conflict = "节点不存在"
+				break
+			}
+			if diff.Cascade, err = cascadeCount(r, op); err != nil {
+				return diff, err
+			}
+		}
+	default:
+		for _, name := range []struct{ nodeType, name string }{{op.SourceType, op.SourceName}, {op.TargetType, op.TargetName}} {
+			props, err := nodeProps(r, name.nodeType, name.name)
+			if err != nil {
+				return diff, err
+			}
+			if props == nil && diff.Conflict == "" {
+				diff.Conflict = fmt.Sprintf("节点 '%s' 不存在", name.name)
+			}
+		}
+		exists, err := relationExists(r, op, op.RelationType)
+		if err != nil {
+			return diff, err
+		}
+		if exists {
+			diff.Before = relationSnapshot(op, op.RelationType)
+		}
+		switch op.Op {
+		case OpCreateRelation:
+			if exists && diff.Conflict == "" {
+				diff.Conflict = "关系已存在"
+			}
+			diff.After = relationSnapshot(op, op.RelationType)
+		case OpUpdateRelation:
+			if !exists && diff.Conflict == "" {
+				diff.Conflict = "关系不存在"
+			}
+			diff.After = relationSnapshot(op, op.NewRelationType)
+		case OpDeleteRelation:
+			if !exists && diff.Conflict == "" {
+				diff.Conflict = "关系不存在"
+			}
+		}
+	}
+	return diff, nil
+}
+
+// applyOperation 在事务中执行单个操作，前置条件不满足时返回错误，由调用方回滚整个事务
+func applyOperation(tx neo4j.Transaction, op Operation) error {
+	var query string
+	params := map[string]interface{}{}
+
+	switch op.Op {
+	case OpCreateNode, OpUpdateNode, OpDeleteNode:
+		props, err := nodeProps(tx, op.NodeType, op.Name)
+		if err != nil {
+			return err
+		}
+		if op.Op == OpCreateNode && props != nil {
+			return fmt.Errorf("类型为 '%s' 的节点 '%s' 已存在", op.NodeType, op.Name)
+		}
+		if op.Op != OpCreateNode && props == nil {
+			return fmt.Errorf("类型为 '%s' 的节点 '%s' 不存在", op.NodeType, op.Name)
+		}
+		params["name"] = op.Name
+		switch op.Op {
+		case OpCreateNode:
+			query = fmt.Sprintf("CREATE (n:%s {name: $name, description: $description})", op.NodeType)
+			params["description"] = op.Description
+		case OpUpdateNode:
+			query = fmt.Sprintf("MATCH (n:%s {name: $name}) SET n.%s = $newValue", op.NodeType, op.PropertyName)
+			params["newValue"] = op.NewValue
+		case OpDeleteNode:
+			// 与 DeleteNode 接口一致，章节和小节级联删除下级节点
+			query = fmt.Sprintf(`
+				MATCH (n:%s {name: $name})
+				OPTIONAL MATCH (n)-[:包含*]->(child)
+				WITH n, child
+				DETACH DELETE n, child`, op.NodeType)
+		}
+	default:
+		for _, name := range []struct{ nodeType, name string }{{op.SourceType, op.SourceName}, {op.TargetType, op.TargetName}} {
+			props, err := nodeProps(tx, name.nodeType, name.name)
+			if err != nil {
+				return err
+			}
+			if props == nil {
+				return fmt.Errorf("类型为 '%s' 的节点 '%s' 不存在", name.nodeType, name.name)
+			}
+		}
+		exists, err := relationExists(tx, op, op.RelationType)
+		if err != nil {
+			return err
+		}
+		if op.Op == OpCreateRelation && exists {
+			return fmt.Errorf("从节点 %s 到节点 %s 关系 '%s' 已存在", op.SourceName, op.TargetName, op.RelationType)
+		}
+		if op.Op != OpCreateRelation && !exists {
+			return fmt.Errorf("从节点 %s 到节点 %s 关系 '%s' 不存在", op.SourceName, op.TargetName, op.RelationType)
+		}
+		params["sourceName"] = op.SourceName
+		params["targetName"] = op.TargetName
+		switch op.Op {
+		case OpCreateRelation:
+			query = fmt.Sprintf(`
+				MATCH (a:%s {name: $sourceName}), (b:%s {name: $targetName})
+				CREATE (a)-[:%s]->(b)`, op.SourceType, op.TargetType, op.RelationType)
+		case OpUpdateRelation:
+			query = fmt.Sprintf(`
+				MATCH (a:%s {name: $sourceName})-[r:%s]->(b:%s {name: $targetName})
+				DELETE r
+				WITH DISTINCT a, b
+				CREATE (a)-[:%s]->(b)`, op.SourceType, op.RelationType, op.TargetType, op.NewRelationType)
+		case OpDeleteRelation:
+			query = fmt.Sprintf(`
+				MATCH (a:%s {name: $sourceName})-[r:%s]->(b:%s {name: $targetName})
+				DELETE r`, op.SourceType, op.RelationType, op.TargetType)
+		}
+	}
+
+	log.Infof("Executing change request query: %s with params: %v", query, params)
+	result, err := tx.Run(query, params)
+	if err != nil {
+		return err
+	}
+	_, err = result.Consume()
+	return err
+}
+
+// applyOperations 在同一个事务中依次执行全部操作，任一操作失败则全部回滚
+func applyOperations(ops []Operation) error {
+	session := neo4jUtils.GetSession()
+	if session == nil {
+		return fmt.Errorf("无法获取 Neo4j 会话")
+	}
+	defer session.Close()
+
+	tx, err := session.BeginTransaction()
+	if err != nil {
+		return fmt.Errorf("开始事务失败: %s", err.Error())
+	}
+	defer tx.Close()
+
+	for i, op := range ops {
+		if err := applyOperation(tx, op); err != nil {
+			tx.Rollback()
+			return fmt.Errorf("第 %d 个操作 %s 执行失败: %s", i+1, op.Op, err.Error())
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("提交事务失败: %s", err.Error())
+	}
+	return nil
+}
@@ -0,0 +1,176 @@
+package review
+
+import (
+	"errors"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/neo4j/neo4j-go-driver/v4/neo4j"
+	"github.com/neo4j/neo4j-go-driver/v4/neo4j/dbtype"
+)
+
+func TestValidateOperation(t *testing.T) {
+	tests := []struct {
+		name  string
+		op    Operation
+		valid bool
+	}{
+		{"create node", Operation{Op: OpCreateNode, NodeType: "point", Name: "栈"}, true},
+		{"create node without name", Operation{Op: OpCreateNode, NodeType: "point"}, false},
+		{"unknown node type", Operation{Op: OpDeleteNode, NodeType: "Point) DETACH DELETE (m", Name: "栈"}, false},
+		{"update node", Operation{Op: OpUpdateNode, NodeType: "section", Name: "1.1", PropertyName: "description", NewValue: "x"}, true},
+		{"update node without value", Operation{Op: OpUpdateNode, NodeType: "section", Name: "1.1", PropertyName: "description"}, false},
+		{"rename node", Operation{Op: OpUpdateNode, NodeType: "point", Name: "栈", PropertyName: "name", NewValue: "队列"}, false},
+		{"invalid property name", Operation{Op: OpUpdateNode, NodeType: "point", Name: "栈", PropertyName: "a`b", NewValue: "x"}, false},
+		{"create relation", Operation{Op: OpCreateRelation, SourceType: "point", SourceName: "栈", TargetType: "point", TargetName: "队列", RelationType: "前置"}, true},
+		{"relation missing target", Operation{Op: OpDeleteRelation, SourceType: "point", SourceName: "栈", TargetType: "point", RelationType: "前置"}, false},
+		{"unknown relation type", Operation{Op: OpCreateRelation, SourceType: "point", SourceName: "栈", TargetType: "point", TargetName: "队列", RelationType: "属于"}, false},
+		{"update relation", Operation{Op: OpUpdateRelation, SourceType: "point", SourceName: "栈", TargetType: "point", TargetName: "队列", RelationType: "前置", NewRelationType: "相关"}, true},
+		{"update relation to unknown type", Operation{Op: OpUpdateRelation, SourceType: "point", SourceName: "栈", TargetType: "point", TargetName: "队列", RelationType: "前置", NewRelationType: "x"}, false},
+		{"unknown op", Operation{Op: "merge_node", NodeType: "point", Name: "栈"}, false},
+	}
+	for _, tt := range tests {
+		if err := validateOperation(tt.op); (err == nil) != tt.valid {
+			t.Errorf("%s: validateOperation() error = %v, want valid %v", tt.name, err, tt.valid)
+		}
+	}
+}
+
+// fakeGraph 只实现 diffOperation 用到的查询：按名称查节点、统计关系数量和下级节点数量
+type fakeGraph struct {
+	nodes     map[string]map[string]interface{} // "类型/名称" -> 属性
+	relations map[string]bool                   // "源类型/源名称-关系->目标类型/目标名称"
+	children  map[string]int64                  // "类型/名称" -> 下级节点数量
+}
+
+var (
+	nodeQuery     = regexp.MustCompile(`MATCH \(n:(\w+) \{name: \$name\}\) RETURN n`)
+	relationQuery = regexp.MustCompile(`MATCH \(a:(\w+) \{name: \$sourceName\}\)-\[r:(\S+)\]->\(b:(\w+) \{name: \$targetName\}\)`)
+	cascadeQuery  = regexp.MustCompile(`MATCH \(n:(\w+) \{name: \$name\}\)-\[:包含\*\]->\(child\)`)
+)
+
+func (g *fakeGraph) Run(cypher string, params map[string]interface{}) (neo4j.Result, error) {
+	cypher = strings.Join(strings.Fields(cypher), " ")
+	if m := nodeQuery.FindStringSubmatch(cypher); m != nil {
+		props, ok := g.nodes[m[1]+"/"+params["name"].(string)]
+		if !ok {
+			return &fakeResult{}, nil
+		}
+		return &fakeResult{records: []*neo4j.Record{{Values: []interface{}{dbtype.Node{Props: props}}}}}, nil
+	}
+	if m := relationQuery.FindStringSubmatch(cypher); m != nil {
+		var count int64
+		if g.relations[m[1]+"/"+params["sourceName"].(string)+"-"+m[2]+"->"+m[3]+"/"+params["targetName"].(string)] {
+			count = 1
+		}
+		return &fakeResult{records: []*neo4j.Record{{Values: []interface{}{count}}}}, nil
+	}
+	if m := cascadeQuery.FindStringSubmatch(cypher); m != nil {
+		return &fakeResult{records: []*neo4j.Record{{Values: []interface{}{g.children[m[1]+"/"+params["name"].(string)]}}}}, nil
+	}
+	return nil, errors.New("unexpected query: " + cypher)
+}
+
+func (g *fakeGraph) Commit() error   { return nil }
+func (g *fakeGraph) Rollback() error { return nil }
+func (g *fakeGraph) Close() error    { return nil }
+
+type fakeResult struct {
+	records []*neo4j.Record
+	current *neo4j.Record
+}
+
+func (r *fakeResult) Keys() ([]string, error) { return nil, nil }
+func (r *fakeResult) Next() bool {
+	if len(r.records) == 0 {
+		r.current = nil
+		return false
+	}
+	r.current, r.records = r.records[0], r.records[1:]
+	return true
+}
+func (r *fakeResult) NextRecord(record **neo4j.Record) bool {
+	ok := r.Next()
+	*record = r.current
+	return ok
+}
+func (r *fakeResult) Err() error            { return nil }
+func (r *fakeResult) Record() *neo4j.Record { return r.current }
+func (r *fakeResult) Collect() ([]*neo4j.Record, error) {
+	records := r.records
+	r.records = nil
+	return records, nil
+}
+func (r *fakeResult) Single() (*neo4j.Record, error) {
+	if len(r.records) != 1 {
+		return nil, errors.New("expected exactly one record")
+	}
+	return r.records[0], nil
+}
+func (r *fakeResult) Consume() (neo4j.ResultSummary, error) { return nil, nil }
+
+func TestDiffOperation(t *testing.T) {
+	graph := &fakeGraph{
+		nodes: map[string]map[string]interface{}{
+			"chapter/第一章": {"name": "第一章"},
+			"point/栈":     {"name": "栈", "description": "后进先出"},
+			"point/队列":    {"name": "队列"},
+		},
+		relations: map[string]bool{"point/栈-前置->point/队列": true},
+		children:  map[string]int64{"chapter/第一章": 3},
+	}
+	relation := func(op, relationType string, target string) Operation {
+		return Operation{Op: op, SourceType: "point", SourceName: "栈", TargetType: "point", TargetName: target,
+			RelationType: relationType, NewRelationType: "相关"}
+	}
+	tests := []struct {
+		name     string
+		op       Operation
+		conflict string
+		check    func(t *testing.T, diff OperationDiff)
+	}{
+		{name: "create new node", op: Operation{Op: OpCreateNode, NodeType: "point", Name: "堆", Description: "d"},
+			check: func(t *testing.T, diff OperationDiff) {
+				if diff.Before != nil || diff.After["description"] != "d" {
+					t.Errorf("diff = %+v", diff)
+				}
+			}},
+		{name: "create existing node", op: Operation{Op: OpCreateNode, NodeType: "point", Name: "栈"}, conflict: "节点已存在"},
+		{name: "update node", op: Operation{Op: OpUpdateNode, NodeType: "point", Name: "栈", PropertyName: "description", NewValue: "LIFO"},
+			check: func(t *testing.T, diff OperationDiff) {
+				if diff.Before["description"] != "后进先出" || diff.After["description"] != "LIFO" || diff.After["name"] != "栈" {
+					t.Errorf("diff = %+v", diff)
+				}
+			}},
+		{name: "update missing node", op: Operation{Op: OpUpdateNode, NodeType: "point", Name: "堆", PropertyName: "description", NewValue: "x"}, conflict: "节点不存在"},
+		{name: "delete chapter cascades", op: Operation{Op: OpDeleteNode, NodeType: "chapter", Name: "第一章"},
+			check: func(t *testing.T, diff OperationDiff) {
+				if diff.Cascade != 3 || diff.After != nil {
+					t.Errorf("diff = %+v", diff)
+				}
+			}},
+		{name: "create existing relation", op: relation(OpCreateRelation, "前置", "队列"), conflict: "关系已存在"},
+		{name: "create relation to missing node", op: relation(OpCreateRelation, "相关", "堆"), conflict: "节点 '堆' 不存在"},
+		{name: "update relation", op: relation(OpUpdateRelation, "前置", "队列"),
+			check: func(t *testing.T, diff OperationDiff) {
+				if diff.Before["relation_type"] != "前置" || diff.After["relation_type"] != "相关" {
+					t.Errorf("diff = %+v", diff)
+				}
+			}},
+		{name: "delete missing relation", op: relation(OpDeleteRelation, "相关", "队列"), conflict: "关系不存在"},
+	}
+	for _, tt := range tests {
+		diff, err := diffOperation(graph, tt.op)
+		if err != nil {
+			t.Errorf("%s: unexpected error: %v", tt.name, err)
+			continue
+		}
+		if diff.Conflict != tt.conflict {
+			t.Errorf("%s: conflict = %q, want %q", tt.name, diff.Conflict, tt.conflict)
+		}
+		if tt.check != nil {
+			tt.check(t, diff)
+		}
+	}
+}
@@ -0,0 +1,283 @@
+package review
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/RMS_V3/internal/kg/repository"
+	"github.com/RMS_V3/internal/kg/repository/models"
+	"github.com/RMS_V3/internal/user"
+	"github.com/RMS_V3/log"
+	"github.com/RMS_V3/middleware/neo4jUtils"
+	"github.com/RMS_V3/pkg/response"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// IsGraphEditor 拥有 user.manage 权限的账号和课程负责人可以直接编辑图谱、审核修改申请
+func IsGraphEditor(u *user.User) (bool, error) {
+	if user.HasPermission(u.UserType, user.UserManage) {
+		return true, nil
+	}
+	return repository.IsCourseOwner(u.Id)
+}
+
+// RequireGraphEditor 图谱写接口的访问控制，非课程负责人需要通过修改申请提交变更
+func RequireGraphEditor() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		u := user.CurrentUser(c)
+		editor, err := IsGraphEditor(u)
+		if err != nil {
+			c.AbortWithStatusJSON(500, response.Error(500, err.Error()))
+			return
+		}
+		if !editor {
+			c.AbortWithStatusJSON(http.StatusForbidden, response.Error(http.StatusForbidden,
+				"只有课程负责人可以直接修改图谱，请通过 /knowledge/changeRequest/submit 提交修改申请"))
+			return
+		}
+		c.Next()
+	}
+}
+
+type submitChangeRequest struct {
+	Comment    string      `json:"comment"`
+	Operations []Operation `json:"operations" binding:"required,min=1"`
+}
+
+// SubmitChangeRequest 教师提交一组图谱修改操作，等待课程负责人审核
+func SubmitChangeRequest(c *gin.Context) {
+	u := user.CurrentUser(c)
+	var req submitChangeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(400, response.Error(400, "参数验证失败"))
+		return
+	}
+	for i, op := range req.Operations {
+		if err := validateOperation(op); err != nil {
+			c.JSON(400, response.Error(400, fmt.Sprintf("第 %d 个操作无效: %s", i+1, err.Error())))
+			return
+		}
+	}
+
+	operations, err := json.Marshal(req.Operations)
+	if err != nil {
+		c.JSON(500, response.Error(500, err.Error()))
+		return
+	}
+	cr := &models.ChangeRequest{
+		Submitter:  u.Id,
+		Comment:    req.Comment,
+		Operations: string(operations),
+		Status:     models.ChangeRequestPending,
+	}
+	if err := repository.CreateChangeRequest(cr); err != nil {
+		log.Errorf("create change request failed: %v", err)
+		c.JSON(500, response.Error(500, "保存修改申请失败"))
+		return
+	}
+	c.JSON(200, response.Success(changeRequestView(cr, req.Operations)))
+}
+
+func changeRequestView(cr *models.ChangeRequest, ops []Operation) gin.H {
+	return gin.H{
+		"change_request": cr,
+		"operations":     ops,
+	}
+}
+
+func decodeOperations(cr *models.ChangeRequest) ([]Operation, error) {
+	var ops []Operation
+	if err := json.Unmarshal([]byte(cr.Operations), &ops); err != nil {
+		return nil, fmt.Errorf("修改申请 %d 的操作列表损坏: %s", cr.ID, err.Error())
+	}
+	return ops, nil
+}
+
+// ListChangeRequests 列出修改申请，课程负责人和管理员可以看到全部申请，其他教师只能看到自己提交的
+func ListChangeRequests(c *gin.Context) {
+	u := user.CurrentUser(c)
+	status := c.Query("status")
+	if status != "" && status != models.ChangeRequestPending && status != models.ChangeRequestApproved && status != models.ChangeRequestRejected {
+		c.JSON(400, response.Error(400, fmt.Sprintf("无效的状态: %s", status)))
+		return
+	}
+	editor, err := IsGraphEditor(u)
+	if err != nil {
+		c.JSON(500, response.Error(500, err.Error()))
+		return
+	}
+	submitter := ""
+	if !editor {
+		submitter = u.Id
+	}
+	reqs, err := repository.ListChangeRequests(status, submitter)
+	if err != nil {
+		c.JSON(500, response.Error(500, err.Error()))
+		return
+	}
+	c.JSON(200, response.Success(reqs))
+}
+
+// loadChangeRequest 读取申请并检查访问权限，失败时已写入响应
+func loadChangeRequest(c *gin.Context, u *user.User, requireEditor bool) (*models.ChangeRequest, []Operation, bool) {
+	id, err := strconv.ParseInt(c.Query("id"), 10, 64)
+	if err != nil {
+		c.JSON(400, response.Error(400, "无效的申请ID"))
+		return nil, nil, false
+	}
+	cr, err := repository.GetChangeRequest(id)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(404, response.Error(404, "修改申请不存在"))
+			return nil, nil, false
+		}
+		c.JSON(500, response.Error(500, err.Error()))
+		return nil, nil, false
+	}
+	editor, err := IsGraphEditor(u)
+	if err != nil {
+		c.JSON(500, response.Error(500, err.Error()))
+		return nil, nil, false
+	}
+	if !editor && (requireEditor || cr.Submitter != u.Id) {
+		c.JSON(http.StatusForbidden, response.Error(http.StatusForbidden, "只有课程负责人或管理员可以进行该操作"))
+		return nil, nil, false
+	}
+	ops, err := decodeOperations(cr)
+	if err != nil {
+		c.JSON(500, response.Error(500, err.Error()))
+		return nil, nil, false
+	}
+	return cr, ops, true
+}
+
+// GetChangeRequest 获取申请详情以及每个操作相对当前图谱的前后对比
+func GetChangeRequest(c *gin.Context) {
+	u := user.CurrentUser(c)
+	cr, ops, ok := loadChangeRequest(c, u, false)
+	if !ok {
+		return
+	}
+	view := changeRequestView(cr, ops)
+	// 已审核的申请不再计算对比，当前图谱已与提交时不同
+	if cr.Status == models.ChangeRequestPending {
+		session := neo4jUtils.GetSession()
+		if session == nil {
+			c.JSON(500, response.Error(500, "无法获取 Neo4j 会话"))
+			return
+		}
+		defer session.Close()
+		tx, err := session.BeginTransaction()
+		if err != nil {
+			c.JSON(500, response.Error(500, fmt.Sprintf("开始事务失败: %s", err.Error())))
+			return
+		}
+		// 只读取数据，结束时回滚
+		defer tx.Close()
+
+		diffs := make([]OperationDiff, 0, len(ops))
+		for _, op := range ops {
+			diff, err := diffOperation(tx, op)
+			if err != nil {
+				c.JSON(500, response.Error(500, fmt.Sprintf("计算修改对比失败: %s", err.Error())))
+				return
+			}
+			diffs = append(diffs, diff)
+		}
+		view["diff"] = diffs
+	}
+	c.JSON(200, response.Success(view))
+}
+
+// ApproveChangeRequest 批准申请，全部操作在同一事务中执行
+func ApproveChangeRequest(c *gin.Context) {
+	u := user.CurrentUser(c)
+	cr, ops, ok := loadChangeRequest(c, u, true)
+	if !ok {
+		return
+	}
+	if cr.Status != models.ChangeRequestPending {
+		c.JSON(400, response.Error(400, fmt.Sprintf("申请状态为 %s，不能重复审核", cr.Status)))
+		return
+	}
+
+	// 先把申请标记为已批准，并发的重复审核在这里失败，不会重复执行操作
+	if err := repository.ReviewChangeRequest(cr.ID, models.ChangeRequestApproved, u.Id, ""); err != nil {
+		c.JSON(409, response.Error(409, err.Error()))
+		return
+	}
+	if err := applyOperations(ops); err != nil {
+		log.Errorf("apply change request %d failed: %v", cr.ID, err)
+		if reopenErr := repository.ReopenChangeRequest(cr.ID); reopenErr != nil {
+			log.Errorf("reopen change request %d failed: %v", cr.ID, reopenErr)
+		}
+		c.JSON(409, response.Error(409, err.Error()))
+		return
+	}
+	c.JSON(200, response.Success(fmt.Sprintf("修改申请 %d 已批准，共执行 %d 个操作", cr.ID, len(ops))))
+}
+
+type rejectChangeRequest struct {
+	Feedback string `json:"feedback" binding:"required"`
+}
+
+// RejectChangeRequest 驳回申请并给出反馈意见
+func RejectChangeRequest(c *gin.Context) {
+	u := user.CurrentUser(c)
+	var req rejectChangeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(400, response.Error(400, "驳回时必须填写反馈意见"))
+		return
+	}
+	cr, _, ok := loadChangeRequest(c, u, true)
+	if !ok {
+		return
+	}
+	if err := repository.ReviewChangeRequest(cr.ID, models.ChangeRequestRejected, u.Id, req.Feedback); err != nil {
+		c.JSON(400, response.Error(400, err.Error()))
+		return
+	}
+	c.JSON(200, response.Success(fmt.Sprintf("修改申请 %d 已驳回", cr.ID)))
+}
+
+// ListCourseOwners 列出课程负责人
+func ListCourseOwners(c *gin.Context) {
+	owners, err := repository.ListCourseOwners()
+	if err != nil {
+		c.JSON(500, response.Error(500, err.Error()))
+		return
+	}
+	c.JSON(200, response.Success(owners))
+}
+
+// AddCourseOwner 管理员指定课程负责人
+func AddCourseOwner(c *gin.Context) {
+	userId := c.Query("user_id")
+	if userId == "" {
+		c.JSON(400, response.Error(400, "参数不完整: 必须提供 user_id"))
+		return
+	}
+	if err := repository.AddCourseOwner(userId); err != nil {
+		c.JSON(400, response.Error(400, fmt.Sprintf("添加课程负责人失败: %s", err.Error())))
+		return
+	}
+	c.JSON(200, response.Success(fmt.Sprintf("已将用户 %s 设为课程负责人", userId)))
+}
+
+// RemoveCourseOwner 管理员移除课程负责人
+func RemoveCourseOwner(c *gin.Context) {
+	userId := c.Query("user_id")
+	if userId == "" {
+		c.JSON(400, response.Error(400, "参数不完整: 必须提供 user_id"))
+		return
+	}
+	if err := repository.RemoveCourseOwner(userId); err != nil {
+		c.JSON(400, response.Error(400, err.Error()))
+		return
+	}
+	c.JSON(200, response.Success(fmt.Sprintf("已移除课程负责人 %s", userId)))
+}
@@ -27,9 +27,14 @@ func ExtractKnowledgeFromFile(c *gin.Context) {
 		return
 	}
 
-	// 验证数据
-	if err := ValidateKnowledgeGraph(graph); err != nil {
-		c.JSON(400, response.Error(400, err.Error()))
+	// 验证数据，有任何错误都不写入图谱
+	report := ValidateKnowledgeGraph(graph, neo4jNodeLookup)
+	if !report.Valid {
+		c.JSON(400, &response.Response{
+			Code:    400,
+			Message: fmt.Sprintf("知识图谱校验失败: %d 个错误, %d 个警告", len(report.Errors), len(report.Warnings)),
+			Data:    report,
+		})
 		return
 	}
 
@@ -40,7 +45,84 @@ func ExtractKnowledgeFromFile(c *gin.Context) {
 		return
 	}
 
-	c.JSON(200, response.Success("知识图谱构建成功"))
+	c.JSON(200, response.Success(gin.H{
+		"message": "知识图谱构建成功",
+		"report":  report,
+	}))
+}
+
+// ValidateKnowledgeFile 只校验上传的知识图谱文件，不写入数据库
+func ValidateKnowledgeFile(c *gin.Context) {
+	file, err := c.FormFile("file")
+	if err != nil {
+		c.JSON(400, response.Error(400, "文件上传失败"))
+		return
+	}
+
+	graph, err := parseFile(file)
+	if err != nil {
+		c.JSON(400, response.Error(400, err.Error()))
+		return
+	}
+
+	c.JSON(200, response.Success(ValidateKnowledgeGraph(graph, neo4jNodeLookup)))
+}
+
+// ExtractKnowledgeFromDocument 从 PDF、DOCX、TXT 教材中按规则抽取章节和知识点，
+// 只返回抽取结果和校验报告供教师审核，不写入数据库
+func ExtractKnowledgeFromDocument(c *gin.Context) {
+	file, err := c.FormFile("file")
+	if err != nil {
+		c.JSON(400, response.Error(400, "文件上传失败"))
+		return
+	}
+
+	paragraphs, err := parseDocument(file)
+	if err != nil {
+		c.JSON(400, response.Error(400, err.Error()))
+		return
+	}
+
+	graph := NewRuleBasedExtractor().Extract(paragraphs)
+	if len(graph.Nodes) == 0 {
+		c.JSON(400, response.Error(400, "未识别到任何章节或知识点，请检查文档的标题编号或标题样式"))
+		return
+	}
+
+	c.JSON(200, response.Success(gin.H{
+		"graph":  graph,
+		"report": ValidateKnowledgeGraph(graph, neo4jNodeLookup),
+	}))
+}
+
+// CommitKnowledgeGraph 将审核后的知识图谱(JSON请求体)写入数据库
+func CommitKnowledgeGraph(c *gin.Context) {
+	var graph KnowledgeGraph
+	if err := c.ShouldBindJSON(&graph); err != nil {
+		c.JSON(400, response.Error(400, "参数验证失败"))
+		return
+	}
+
+	report := ValidateKnowledgeGraph(&graph, neo4jNodeLookup)
+	if !report.Valid {
+		c.JSON(400, &response.Response{
+			Code:    400,
+			Message: fmt.Sprintf("知识图谱校验失败: %d 个错误, %d 个警告", len(report.Errors), len(report.Warnings)),
+			Data:    report,
+		})
+		return
+	}
+
+	if err := batchCreateNodesAndRelations(graph.Nodes, graph.Relations); err != nil {
+		log.Errorf("commit knowledge graph failed: %v", err)
+		c.JSON(500, response.Error(500, "图谱构建失败"))
+		return
+	}
+
+	c.JSON(200, response.Success(gin.H{
+		"message": "知识图谱构建成功",
+		"report":  report,
+	}))
 }
 
 func batchCreateNodesAndRelations(nodes []Node, relations []Relation) error {
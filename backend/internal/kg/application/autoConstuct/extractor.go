@@ -0,0 +1,118 @@
+package auto
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"github.com/RMS_V3/config"
+)
+
+// ProposedNode 抽取器给出的候选节点
+type ProposedNode struct {
+	Node
+	Confidence float64 `json:"confidence"`
+}
+
+// ProposedRelation 抽取器给出的候选关系
+type ProposedRelation struct {
+	Relation
+	Confidence float64 `json:"confidence"`
+}
+
+// Proposal 抽取器的输出，进入暂存区等待审核
+type Proposal struct {
+	Nodes     []ProposedNode     `json:"nodes"`
+	Relations []ProposedRelation `json:"relations"`
+}
+
+// maxNodeNameLength 暂存区名称列的长度上限（字符数）
+const maxNodeNameLength = 100
+
+func checkNodeNameLength(name string) error {
+	if utf8.RuneCountInString(name) > maxNodeNameLength {
+		return fmt.Errorf("名称超过 %d 个字符: %s...", maxNodeNameLength, string([]rune(name)[:20]))
+	}
+	return nil
+}
+
+// checkNames 检查候选节点和关系中的名称都不超过列长度
+func (p *Proposal) checkNames() error {
+	for _, n := range p.Nodes {
+		if err := checkNodeNameLength(n.Name); err != nil {
+			return err
+		}
+	}
+	for _, r := range p.Relations {
+		if err := checkNodeNameLength(r.SourceName); err != nil {
+			return err
+		}
+		if err := checkNodeNameLength(r.TargetName); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// KnowledgeExtractor 知识抽取器。规则抽取之外的实现(如外部 NLP/LLM 服务)
+// 通过 RegisterExtractor 注册后即可在接口中按名称选用。
+type KnowledgeExtractor interface {
+	// Name 抽取器名称，对应接口参数 extractor
+	Name() string
+	// Propose 从教材段落中抽取候选节点和关系
+	Propose(ctx context.Context, paragraphs []Paragraph) (*Proposal, error)
+}
+
+var (
+	extractorsMu sync.RWMutex
+	extractors   = make(map[string]KnowledgeExtractor)
+)
+
+func init() {
+	RegisterExtractor(NewRuleBasedExtractor())
+}
+
+// RegisterExtractor 注册抽取器，同名抽取器会被替换
+func RegisterExtractor(e KnowledgeExtractor) {
+	extractorsMu.Lock()
+	defer extractorsMu.Unlock()
+	extractors[e.Name()] = e
+}
+
+// GetExtractor 按名称获取抽取器。配置了外部服务地址时 "http" 抽取器按当前配置创建
+func GetExtractor(name string) (KnowledgeExtractor, error) {
+	extractorsMu.RLock()
+	e, ok := extractors[name]
+	extractorsMu.RUnlock()
+	if ok {
+		return e, nil
+	}
+	if name == "http" {
+		if cfg := config.GetGlobalConfig().ExtractorConfig; cfg != nil && cfg.Endpoint != "" {
+			return NewHTTPExtractor(cfg.Endpoint, cfg.ApiKey, time.Duration(cfg.Timeout)*time.Second), nil
+		}
+		return nil, fmt.Errorf("未配置外部抽取服务地址")
+	}
+	return nil, fmt.Errorf("未知的抽取器: %s", name)
+}
+
+// ExtractorNames 返回可用的抽取器名称
+func ExtractorNames() []string {
+	extractorsMu.RLock()
+	names := make([]string, 0, len(extractors)+1)
+	for name := range extractors {
+		names = append(names, name)
+	}
+	_, hasHTTP := extractors["http"]
+	extractorsMu.RUnlock()
+	if !hasHTTP {
+		if cfg := config.GetGlobalConfig().ExtractorConfig; cfg != nil && cfg.Endpoint != "" {
+			names = append(names, "http")
+		}
+	}
+	sort.Strings(names)
+	return names
+}
@@ -1,19 +1,52 @@
 package auto
 
 import (
+	"archive/zip"
+	"bytes"
 	"encoding/json"
+	"encoding/xml"
+	"errors"
 	"fmt"
 	"io"
 	"mime/multipart"
 	"path/filepath"
 	"strings"
+	"unicode/utf8"
+
+	"golang.org/x/text/encoding/simplifiedchinese"
 )
 
+// maxUploadSize 上传的图谱文件和教材的大小上限
+const maxUploadSize = 50 << 20
+
+// maxExtractedSize 从 PDF 压缩流或 DOCX 压缩包中解压出的内容总量上限，防止压缩炸弹
+var maxExtractedSize int64 = 100 << 20
+
+// errContentTooLarge 读取的内容超过大小上限
+var errContentTooLarge = errors.New("内容超过大小上限")
+
+// readAllLimited 最多读取 limit 字节，超出时返回 errContentTooLarge；
+// 其他读取错误与已读出的内容一并返回，由调用方决定是否使用
+func readAllLimited(r io.Reader, limit int64) ([]byte, error) {
+	content, err := io.ReadAll(io.LimitReader(r, limit+1))
+	if int64(len(content)) > limit {
+		return nil, errContentTooLarge
+	}
+	return content, err
+}
+
 type KnowledgeGraph struct {
 	Nodes     []Node     `json:"nodes"`
 	Relations []Relation `json:"relations"`
 }
 
+// Paragraph 表示从文档中读出的一个段落
+type Paragraph struct {
+	Text string
+	// Level 为文档样式给出的标题级别(1-3)，0 表示正文或未知
+	Level int
+}
+
 // parseFile 只处理JSON文件
 func parseFile(file *multipart.FileHeader) (*KnowledgeGraph, error) {
 	// 检查文件扩展名
@@ -22,6 +55,41 @@ func parseFile(file *multipart.FileHeader) (*KnowledgeGraph, error) {
 		return nil, fmt.Errorf("只支持JSON文件格式")
 	}
 
+	// 读取文件内容
+	content, err := readUploadedFile(file)
+	if err != nil {
+		return nil, err
+	}
+
+	// 解析JSON
+	var graph KnowledgeGraph
+	if err := json.Unmarshal(content, &graph); err != nil {
+		return nil, fmt.Errorf("JSON解析失败: %v", err)
+	}
+
+	return &graph, nil
+}
+
+// parseDocument 读取 PDF、DOCX、TXT 教材，按顺序返回段落
+func parseDocument(file *multipart.FileHeader) ([]Paragraph, error) {
+	content, err := readUploadedFile(file)
+	if err != nil {
+		return nil, err
+	}
+
+	switch ext := strings.ToLower(filepath.Ext(file.Filename)); ext {
+	case ".txt":
+		return parseTextParagraphs(content), nil
+	case ".docx":
+		return parseDocxParagraphs(content)
+	case ".pdf":
+		return parsePdfParagraphs(content)
+	default:
+		return nil, fmt.Errorf("不支持的文件格式: %s，只支持 PDF、DOCX、TXT", ext)
+	}
+}
+
+func readUploadedFile(file *multipart.FileHeader) ([]byte, error) {
 	// 打开文件
 	src, err := file.Open()
 	if err != nil {
@@ -30,16 +98,192 @@ func parseFile(file *multipart.FileHeader) (*KnowledgeGraph, error) {
 	defer src.Close()
 
 	// 读取文件内容
-	content, err := io.ReadAll(src)
+	content, err := readAllLimited(src, maxUploadSize)
+	if errors.Is(err, errContentTooLarge) {
+		return nil, fmt.Errorf("文件大小超过 %d MB 限制", maxUploadSize>>20)
+	}
 	if err != nil {
 		return nil, fmt.Errorf("读取文件失败: %v", err)
 	}
+	return content, nil
+}
 
-	// 解析JSON
-	var graph KnowledgeGraph
-	if err := json.Unmarshal(content, &graph); err != nil {
-		return nil, fmt.Errorf("JSON解析失败: %v", err)
+// parseTextParagraphs 按行切分纯文本，非 UTF-8 编码的文本按 GBK 解码
+func parseTextParagraphs(content []byte) []Paragraph {
+	content = bytes.TrimPrefix(content, []byte("\xef\xbb\xbf"))
+	if !utf8.Valid(content) {
+		if decoded, err := simplifiedchinese.GBK.NewDecoder().Bytes(content); err == nil {
+			content = decoded
+		}
 	}
+	return splitLines(string(content))
+}
 
-	return &graph, nil
+func splitLines(text string) []Paragraph {
+	var paragraphs []Paragraph
+	for _, line := range strings.Split(strings.ReplaceAll(text, "\r\n", "\n"), "\n") {
+		line = strings.TrimSpace(line)
+		if line != "" {
+			paragraphs = append(paragraphs, Paragraph{Text: line})
+		}
+	}
+	return paragraphs
+}
+
+// parseDocxParagraphs 解析 word/document.xml，结合 styles.xml 识别标题样式
+func parseDocxParagraphs(content []byte) ([]Paragraph, error) {
+	reader, err := zip.NewReader(bytes.NewReader(content), int64(len(content)))
+	if err != nil {
+		return nil, fmt.Errorf("DOCX解析失败: %v", err)
+	}
+
+	var document []byte
+	styleLevels := map[string]int{}
+	remaining := maxExtractedSize
+	for _, f := range reader.File {
+		switch f.Name {
+		case "word/document.xml":
+			if document, err = readZipFile(f, remaining); err != nil {
+				return nil, err
+			}
+			remaining -= int64(len(document))
+		case "word/styles.xml":
+			styles, err := readZipFile(f, remaining)
+			if err != nil {
+				return nil, err
+			}
+			remaining -= int64(len(styles))
+			styleLevels = parseDocxStyles(styles)
+		}
+	}
+	if document == nil {
+		return nil, fmt.Errorf("DOCX解析失败: 缺少 word/document.xml")
+	}
+
+	var paragraphs []Paragraph
+	decoder := xml.NewDecoder(bytes.NewReader(document))
+	var text strings.Builder
+	level := 0
+	inText := false
+	for {
+		token, err := decoder.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("DOCX解析失败: %v", err)
+		}
+		switch t := token.(type) {
+		case xml.StartElement:
+			switch t.Name.Local {
+			case "p":
+				text.Reset()
+				level = 0
+			case "pStyle":
+				level = docxHeadingLevel(xmlAttr(t, "val"), styleLevels)
+			case "outlineLvl":
+				// 段落直接设置了大纲级别，0 对应一级标题
+				var lvl int
+				if _, err := fmt.Sscanf(xmlAttr(t, "val"), "%d", &lvl); err == nil && lvl < 3 {
+					level = lvl + 1
+				}
+			case "t":
+				inText = true
+			case "tab":
+				text.WriteString(" ")
+			}
+		case xml.CharData:
+			if inText {
+				text.Write(t)
+			}
+		case xml.EndElement:
+			switch t.Name.Local {
+			case "t":
+				inText = false
+			case "p":
+				if s := strings.TrimSpace(text.String()); s != "" {
+					paragraphs = append(paragraphs, Paragraph{Text: s, Level: level})
+				}
+			}
+		}
+	}
+	return paragraphs, nil
+}
+
+// readZipFile 读取压缩包中的一个文件，解压后超过 limit 字节时返回错误
+func readZipFile(f *zip.File, limit int64) ([]byte, error) {
+	rc, err := f.Open()
+	if err != nil {
+		return nil, fmt.Errorf("DOCX解析失败: %v", err)
+	}
+	defer rc.Close()
+	content, err := readAllLimited(rc, limit)
+	if errors.Is(err, errContentTooLarge) {
+		return nil, fmt.Errorf("DOCX解析失败: 解压后的内容超过 %d MB", maxExtractedSize>>20)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("DOCX解析失败: %v", err)
+	}
+	return content, nil
+}
+
+func xmlAttr(e xml.StartElement, name string) string {
+	for _, attr := range e.Attr {
+		if attr.Name.Local == name {
+			return attr.Value
+		}
+	}
+	return ""
+}
+
+// parseDocxStyles 建立 styleId 到标题级别的映射，兼容中文版 Word 的 "标题 1" 等样式名
+func parseDocxStyles(content []byte) map[string]int {
+	levels := map[string]int{}
+	decoder := xml.NewDecoder(bytes.NewReader(content))
+	styleID := ""
+	for {
+		token, err := decoder.Token()
+		if err != nil {
+			break
+		}
+		start, ok := token.(xml.StartElement)
+		if !ok {
+			continue
+		}
+		switch start.Name.Local {
+		case "style":
+			styleID = xmlAttr(start, "styleId")
+		case "name":
+			if styleID != "" {
+				if lvl := headingLevelFromName(xmlAttr(start, "val")); lvl > 0 {
+					levels[styleID] = lvl
+				}
+			}
+		}
+	}
+	return levels
+}
+
+func docxHeadingLevel(styleID string, styleLevels map[string]int) int {
+	if lvl, ok := styleLevels[styleID]; ok {
+		return lvl
+	}
+	return headingLevelFromName(styleID)
+}
+
+func headingLevelFromName(name string) int {
+	name = strings.ToLower(strings.ReplaceAll(name, " ", ""))
+	for _, prefix := range []string{"heading", "标题"} {
+		if strings.HasPrefix(name, prefix) {
+			switch strings.TrimPrefix(name, prefix) {
+			case "1":
+				return 1
+			case "2":
+				return 2
+			case "3":
+				return 3
+			}
+		}
+	}
+	return 0
 }
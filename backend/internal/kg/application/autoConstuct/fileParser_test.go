@@ -0,0 +1,64 @@
+package auto
+
+import (
+	"archive/zip"
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestReadAllLimited(t *testing.T) {
+	if content, err := readAllLimited(strings.NewReader("12345"), 5); err != nil || string(content) != "12345" {
+		t.Errorf("readAllLimited at limit = %q, %v", content, err)
+	}
+	if _, err := readAllLimited(strings.NewReader("123456"), 5); !errors.Is(err, errContentTooLarge) {
+		t.Errorf("readAllLimited over limit error = %v, want errContentTooLarge", err)
+	}
+}
+
+func docxFile(t *testing.T, files map[string]string) []byte {
+	var buf bytes.Buffer
+	w := zip.NewWriter(&buf)
+	for name, content := range files {
+		f, err := w.Create(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		f.Write([]byte(content))
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func TestParseDocxParagraphs(t *testing.T) {
+	content := docxFile(t, map[string]string{
+		"word/document.xml": `<w:document><w:body>` +
+			`<w:p><w:pPr><w:pStyle w:val="1"/></w:pPr><w:r><w:t>第一章 线性表</w:t></w:r></w:p>` +
+			`<w:p><w:r><w:t>栈是</w:t></w:r><w:r><w:t>后进先出的线性表</w:t></w:r></w:p>` +
+			`</w:body></w:document>`,
+		"word/styles.xml": `<w:styles><w:style w:styleId="1"><w:name w:val="heading 1"/></w:style></w:styles>`,
+	})
+	paragraphs, err := parseDocxParagraphs(content)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := []Paragraph{{Text: "第一章 线性表", Level: 1}, {Text: "栈是后进先出的线性表"}}
+	if len(paragraphs) != len(want) || paragraphs[0] != want[0] || paragraphs[1] != want[1] {
+		t.Errorf("paragraphs = %+v, want %+v", paragraphs, want)
+	}
+}
+
+func TestParseDocxParagraphsDecompressionLimit(t *testing.T) {
+	defer func(limit int64) { maxExtractedSize = limit }(maxExtractedSize)
+	maxExtractedSize = 1 << 10
+
+	content := docxFile(t, map[string]string{
+		"word/document.xml": "<w:document>" + strings.Repeat("<w:p/>", 1<<10) + "</w:document>",
+	})
+	if _, err := parseDocxParagraphs(content); err == nil || !strings.Contains(err.Error(), "超过") {
+		t.Errorf("error = %v, want size limit error", err)
+	}
+}
@@ -0,0 +1,362 @@
+package auto
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/RMS_V3/middleware/neo4jUtils"
+)
+
+// 校验问题的级别
+const (
+	LevelError   = "error"
+	LevelWarning = "warning"
+)
+
+// 校验问题的分类代码，便于前端按类别展示
+const (
+	IssueInvalidNodeType     = "invalid_node_type"
+	IssueEmptyNodeName       = "empty_node_name"
+	IssueDuplicateNode       = "duplicate_node"
+	IssueInvalidRelationType = "invalid_relation_type"
+	IssueInvalidHierarchy    = "invalid_hierarchy"
+	IssueDanglingSource      = "dangling_source"
+	IssueDanglingTarget      = "dangling_target"
+	IssueOrphanPoint         = "orphan_point"
+	IssuePrerequisiteCycle   = "prerequisite_cycle"
+	IssueLookupFailed        = "lookup_failed"
+)
+
+// ValidationIssue 表示一条校验问题
+type ValidationIssue struct {
+	Level    string    `json:"level"`
+	Code     string    `json:"code"`
+	Message  string    `json:"message"`
+	Node     *Node     `json:"node,omitempty"`
+	Relation *Relation `json:"relation,omitempty"`
+	Cycle    []string  `json:"cycle,omitempty"`
+}
+
+// ValidationReport 汇总一次校验的全部错误和警告
+type ValidationReport struct {
+	Valid     bool              `json:"valid"`
+	NodeCount int               `json:"node_count"`
+	LinkCount int               `json:"relation_count"`
+	Errors    []ValidationIssue `json:"errors"`
+	Warnings  []ValidationIssue `json:"warnings"`
+}
+
+func (r *ValidationReport) addError(issue ValidationIssue) {
+	issue.Level = LevelError
+	r.Errors = append(r.Errors, issue)
+}
+
+func (r *ValidationReport) addWarning(issue ValidationIssue) {
+	issue.Level = LevelWarning
+	r.Warnings = append(r.Warnings, issue)
+}
+
+// NodeLookup 查询数据库中已存在的节点，返回 names 中已存在的节点名称集合
+type NodeLookup func(nodeType string, names []string) (map[string]bool, error)
+
+// nodeKey 节点在图谱中由 类型+名称 唯一确定
+type nodeKey struct {
+	Type string
+	Name string
+}
+
+// ValidateKnowledgeGraph 校验知识图谱数据，收集全部错误和警告后一并返回。
+// lookup 为 nil 时不检查数据库，只在文件内部做引用完整性校验。
+func ValidateKnowledgeGraph(graph *KnowledgeGraph, lookup NodeLookup) *ValidationReport {
+	report := &ValidationReport{
+		NodeCount: len(graph.Nodes),
+		LinkCount: len(graph.Relations),
+		Errors:    []ValidationIssue{},
+		Warnings:  []ValidationIssue{},
+	}
+
+	// 节点的作用域由指向它的包含关系确定：同名同类型的节点挂在不同父节点下时不算重复，
+	// 因此每个 类型+名称 最多可以定义的次数为其不同父节点的数量（至少一次）
+	parents := make(map[nodeKey]map[nodeKey]bool)
+	for _, relation := range graph.Relations {
+		if relation.Type != "包含" || !isValidHierarchicalRelation(relation.SourceType, relation.TargetType) {
+			continue
+		}
+		child := nodeKey{relation.TargetType, relation.TargetName}
+		if parents[child] == nil {
+			parents[child] = make(map[nodeKey]bool)
+		}
+		parents[child][nodeKey{relation.SourceType, relation.SourceName}] = true
+	}
+
+	// 1. 校验节点，同时建立文件内的节点索引
+	fileNodes := make(map[nodeKey]bool)
+	defined := make(map[nodeKey]int)
+	for i := range graph.Nodes {
+		node := graph.Nodes[i]
+		if !isValidNodeType(node.Type) {
+			report.addError(ValidationIssue{
+				Code:    IssueInvalidNodeType,
+				Message: fmt.Sprintf("第%d个节点类型无效: %s", i+1, node.Type),
+				Node:    &node,
+			})
+			continue
+		}
+		if node.Name == "" {
+			report.addError(ValidationIssue{
+				Code:    IssueEmptyNodeName,
+				Message: fmt.Sprintf("第%d个节点名称不能为空", i+1),
+				Node:    &node,
+			})
+			continue
+		}
+		key := nodeKey{node.Type, node.Name}
+		defined[key]++
+		if defined[key] > max(1, len(parents[key])) {
+			report.addError(ValidationIssue{
+				Code:    IssueDuplicateNode,
+				Message: fmt.Sprintf("%s 节点 '%s' 在同一父节点下重复定义", node.Type, node.Name),
+				Node:    &node,
+			})
+			continue
+		}
+		fileNodes[key] = true
+	}
+
+	// 2. 校验关系的类型和层级，收集文件中找不到的端点
+	missing := make(map[string]map[string]bool)
+	addMissing := func(nodeType, name string) {
+		if missing[nodeType] == nil {
+			missing[nodeType] = make(map[string]bool)
+		}
+		missing[nodeType][name] = true
+	}
+	hasParent := make(map[string]bool)
+	for i := range graph.Relations {
+		relation := graph.Relations[i]
+		if !isValidNodeType(relation.SourceType) || !isValidNodeType(relation.TargetType) {
+			report.addError(ValidationIssue{
+				Code:     IssueInvalidNodeType,
+				Message:  fmt.Sprintf("关系中的节点类型无效: %s -> %s", relation.SourceType, relation.TargetType),
+				Relation: &relation,
+			})
+			continue
+		}
+		if relation.SourceType != relation.TargetType {
+			// 不同类型节点间只能是有效层级上的包含关系
+			if !isValidHierarchicalRelation(relation.SourceType, relation.TargetType) {
+				report.addError(ValidationIssue{
+					Code:     IssueInvalidHierarchy,
+					Message:  fmt.Sprintf("无效的节点层级关系: %s -> %s", relation.SourceType, relation.TargetType),
+					Relation: &relation,
+				})
+			} else if relation.Type != "包含" {
+				report.addError(ValidationIssue{
+					Code:     IssueInvalidRelationType,
+					Message:  fmt.Sprintf("不同类型节点间只支持包含关系，当前关系类型: %s", relation.Type),
+					Relation: &relation,
+				})
+			} else if relation.TargetType == "point" {
+				hasParent[relation.TargetName] = true
+			}
+		} else if !isValidRelationType(relation.Type) {
+			report.addError(ValidationIssue{
+				Code:     IssueInvalidRelationType,
+				Message:  fmt.Sprintf("无效的关系类型: %s", relation.Type),
+				Relation: &relation,
+			})
+		}
+
+		if !fileNodes[nodeKey{relation.SourceType, relation.SourceName}] {
+			addMissing(relation.SourceType, relation.SourceName)
+		}
+		if !fileNodes[nodeKey{relation.TargetType, relation.TargetName}] {
+			addMissing(relation.TargetType, relation.TargetName)
+		}
+	}
+
+	// 3. 文件中不存在的端点需要在数据库中存在，否则 MATCH 会静默失败
+	// 查询失败的类型已报告 lookup_failed，无法判断其端点是否存在，不再报告悬空关系
+	existing := make(map[string]map[string]bool)
+	lookupFailed := make(map[string]bool)
+	for nodeType, names := range missing {
+		existing[nodeType] = make(map[string]bool)
+		if lookup == nil {
+			continue
+		}
+		found, err := lookup(nodeType, sortedKeys(names))
+		if err != nil {
+			report.addError(ValidationIssue{
+				Code:    IssueLookupFailed,
+				Message: fmt.Sprintf("查询已有 %s 节点失败: %s", nodeType, err.Error()),
+			})
+			lookupFailed[nodeType] = true
+			continue
+		}
+		existing[nodeType] = found
+	}
+	for i := range graph.Relations {
+		relation := graph.Relations[i]
+		if lookupFailed[relation.SourceType] || lookupFailed[relation.TargetType] {
+			continue
+		}
+		if names, ok := missing[relation.SourceType]; ok && names[relation.SourceName] && !existing[relation.SourceType][relation.SourceName] {
+			report.addError(ValidationIssue{
+				Code:     IssueDanglingSource,
+				Message:  fmt.Sprintf("关系的源节点 %s '%s' 在文件和数据库中均不存在", relation.SourceType, relation.SourceName),
+				Relation: &relation,
+			})
+		}
+		if names, ok := missing[relation.TargetType]; ok && names[relation.TargetName] && !existing[relation.TargetType][relation.TargetName] {
+			report.addError(ValidationIssue{
+				Code:     IssueDanglingTarget,
+				Message:  fmt.Sprintf("关系的目标节点 %s '%s' 在文件和数据库中均不存在", relation.TargetType, relation.TargetName),
+				Relation: &relation,
+			})
+		}
+	}
+
+	// 4. 没有所属小节的知识点（数据库中已存在的知识点不会被重新创建，跳过）
+	var orphanNames []string
+	for _, node := range graph.Nodes {
+		if node.Type == "point" && node.Name != "" && !hasParent[node.Name] {
+			orphanNames = append(orphanNames, node.Name)
+		}
+	}
+	if len(orphanNames) > 0 {
+		inDB := map[string]bool{}
+		lookupOK := true
+		if lookup != nil {
+			found, err := lookup("point", orphanNames)
+			if err != nil {
+				// 查询失败时无法区分新知识点和数据库中已有的知识点，不报告孤立知识点
+				report.addError(ValidationIssue{
+					Code:    IssueLookupFailed,
+					Message: fmt.Sprintf("查询已有 point 节点失败: %s", err.Error()),
+				})
+				lookupOK = false
+			} else {
+				inDB = found
+			}
+		}
+		for i := range graph.Nodes {
+			if !lookupOK {
+				break
+			}
+			node := graph.Nodes[i]
+			if node.Type == "point" && node.Name != "" && !hasParent[node.Name] && !inDB[node.Name] {
+				report.addWarning(ValidationIssue{
+					Code:    IssueOrphanPoint,
+					Message: fmt.Sprintf("知识点 '%s' 没有所属的小节", node.Name),
+					Node:    &node,
+				})
+			}
+		}
+	}
+
+	// 5. 文件内部的前置关系不能成环
+	for _, cycle := range findPrerequisiteCycles(graph.Relations) {
+		report.addError(ValidationIssue{
+			Code:    IssuePrerequisiteCycle,
+			Message: fmt.Sprintf("前置关系存在环: %v", cycle),
+			Cycle:   cycle,
+		})
+	}
+
+	report.Valid = len(report.Errors) == 0
+	return report
+}
+
+// findPrerequisiteCycles 在前置关系中查找环，每个强连通分量只报告一个环
+func findPrerequisiteCycles(relations []Relation) [][]string {
+	adj := make(map[string][]string)
+	for _, relation := range relations {
+		if relation.Type != "前置" || relation.SourceType != "point" || relation.TargetType != "point" {
+			continue
+		}
+		adj[relation.SourceName] = append(adj[relation.SourceName], relation.TargetName)
+	}
+
+	const (
+		white = iota
+		grey
+		black
+	)
+	color := make(map[string]int)
+	var stack []string
+	var cycles [][]string
+	var visit func(name string)
+	visit = func(name string) {
+		color[name] = grey
+		stack = append(stack, name)
+		for _, next := range adj[name] {
+			switch color[next] {
+			case white:
+				visit(next)
+			case grey:
+				// 从栈中截取 next 到当前节点的部分即为环
+				for i := len(stack) - 1; i >= 0; i-- {
+					if stack[i] == next {
+						cycle := append([]string{}, stack[i:]...)
+						cycles = append(cycles, append(cycle, next))
+						break
+					}
+				}
+			}
+		}
+		stack = stack[:len(stack)-1]
+		color[name] = black
+	}
+
+	names := make([]string, 0, len(adj))
+	for name := range adj {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if color[name] == white {
+			visit(name)
+		}
+	}
+	return cycles
+}
+
+func sortedKeys(set map[string]bool) []string {
+	keys := make([]string, 0, len(set))
+	for k := range set {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// neo4jNodeLookup 从 Neo4j 中查询已存在的节点
+func neo4jNodeLookup(nodeType string, names []string) (map[string]bool, error) {
+	label, err := neo4jUtils.SanitizeLabel(nodeType)
+	if err != nil {
+		return nil, err
+	}
+	session := neo4jUtils.GetSession()
+	if session == nil {
+		return nil, fmt.Errorf("无法获取 Neo4j 会话")
+	}
+	defer session.Close()
+
+	query := fmt.Sprintf("MATCH (n:%s) WHERE n.name IN $names RETURN n.name AS name", label)
+	result, err := session.Run(query, map[string]interface{}{"names": names})
+	if err != nil {
+		return nil, err
+	}
+	found := make(map[string]bool)
+	for result.Next() {
+		if name, ok := result.Record().Get("name"); ok {
+			if s, ok := name.(string); ok {
+				found[s] = true
+			}
+		}
+	}
+	if err = result.Err(); err != nil {
+		return nil, err
+	}
+	return found, nil
+}
@@ -0,0 +1,137 @@
+package auto
+
+import (
+	"errors"
+	"testing"
+)
+
+func countCode(issues []ValidationIssue, code string) int {
+	n := 0
+	for _, issue := range issues {
+		if issue.Code == code {
+			n++
+		}
+	}
+	return n
+}
+
+func TestValidateKnowledgeGraphCollectsAllIssues(t *testing.T) {
+	graph := &KnowledgeGraph{
+		Nodes: []Node{
+			{Name: "第一章", Type: "chapter"},
+			{Name: "1.1", Type: "section"},
+			{Name: "A", Type: "point"},
+			{Name: "B", Type: "point"},
+			{Name: "B", Type: "point"},
+			{Name: "C", Type: "point"},
+			{Name: "X", Type: "course"},
+		},
+		Relations: []Relation{
+			{Type: "包含", SourceType: "chapter", TargetType: "section", SourceName: "第一章", TargetName: "1.1"},
+			{Type: "包含", SourceType: "section", TargetType: "point", SourceName: "1.1", TargetName: "A"},
+			{Type: "包含", SourceType: "section", TargetType: "point", SourceName: "1.1", TargetName: "B"},
+			{Type: "前置", SourceType: "point", TargetType: "point", SourceName: "A", TargetName: "B"},
+			{Type: "前置", SourceType: "point", TargetType: "point", SourceName: "B", TargetName: "A"},
+			{Type: "相关", SourceType: "point", TargetType: "point", SourceName: "A", TargetName: "已有知识点"},
+			{Type: "相关", SourceType: "point", TargetType: "point", SourceName: "A", TargetName: "不存在"},
+		},
+	}
+	lookup := func(nodeType string, names []string) (map[string]bool, error) {
+		found := map[string]bool{}
+		for _, name := range names {
+			if nodeType == "point" && name == "已有知识点" {
+				found[name] = true
+			}
+		}
+		return found, nil
+	}
+
+	report := ValidateKnowledgeGraph(graph, lookup)
+	if report.Valid {
+		t.Fatal("expected report to be invalid")
+	}
+	if n := countCode(report.Errors, IssueInvalidNodeType); n != 1 {
+		t.Errorf("invalid node type errors = %d, want 1", n)
+	}
+	if n := countCode(report.Errors, IssueDuplicateNode); n != 1 {
+		t.Errorf("duplicate node errors = %d, want 1", n)
+	}
+	if n := countCode(report.Errors, IssueDanglingTarget); n != 1 {
+		t.Errorf("dangling target errors = %d, want 1", n)
+	}
+	if n := countCode(report.Errors, IssuePrerequisiteCycle); n != 1 {
+		t.Errorf("cycle errors = %d, want 1", n)
+	}
+	if n := countCode(report.Warnings, IssueOrphanPoint); n != 1 {
+		t.Errorf("orphan point warnings = %d, want 1", n)
+	}
+}
+
+func TestValidateKnowledgeGraphValid(t *testing.T) {
+	graph := &KnowledgeGraph{
+		Nodes: []Node{
+			{Name: "1.1", Type: "section"},
+			{Name: "A", Type: "point"},
+		},
+		Relations: []Relation{
+			{Type: "包含", SourceType: "section", TargetType: "point", SourceName: "1.1", TargetName: "A"},
+		},
+	}
+	report := ValidateKnowledgeGraph(graph, nil)
+	if !report.Valid || len(report.Warnings) != 0 {
+		t.Fatalf("expected clean report, got %+v", report)
+	}
+}
+
+func TestValidateKnowledgeGraphDuplicateScopedByParent(t *testing.T) {
+	graph := &KnowledgeGraph{
+		Nodes: []Node{
+			{Name: "1.1", Type: "section"},
+			{Name: "2.1", Type: "section"},
+			{Name: "小结", Type: "point"},
+			{Name: "小结", Type: "point"},
+		},
+		Relations: []Relation{
+			{Type: "包含", SourceType: "section", TargetType: "point", SourceName: "1.1", TargetName: "小结"},
+			{Type: "包含", SourceType: "section", TargetType: "point", SourceName: "2.1", TargetName: "小结"},
+		},
+	}
+	report := ValidateKnowledgeGraph(graph, nil)
+	if n := countCode(report.Errors, IssueDuplicateNode); n != 0 {
+		t.Errorf("duplicate node errors = %d, want 0 for points under different sections", n)
+	}
+
+	graph.Nodes = append(graph.Nodes, Node{Name: "小结", Type: "point"})
+	report = ValidateKnowledgeGraph(graph, nil)
+	if n := countCode(report.Errors, IssueDuplicateNode); n != 1 {
+		t.Errorf("duplicate node errors = %d, want 1", n)
+	}
+}
+
+func TestValidateKnowledgeGraphLookupFailure(t *testing.T) {
+	graph := &KnowledgeGraph{
+		Nodes: []Node{
+			{Name: "A", Type: "point"},
+		},
+		Relations: []Relation{
+			{Type: "相关", SourceType: "point", TargetType: "point", SourceName: "A", TargetName: "已有知识点"},
+		},
+	}
+	lookup := func(nodeType string, names []string) (map[string]bool, error) {
+		return nil, errors.New("connection refused")
+	}
+
+	report := ValidateKnowledgeGraph(graph, lookup)
+	if report.Valid {
+		t.Fatal("expected report to be invalid")
+	}
+	if n := countCode(report.Errors, IssueLookupFailed); n != 2 {
+		t.Errorf("lookup failed errors = %d, want 2", n)
+	}
+	if n := countCode(report.Errors, IssueDanglingTarget); n != 0 {
+		t.Errorf("dangling target errors = %d, want 0 when lookup failed", n)
+	}
+	if n := countCode(report.Warnings, IssueOrphanPoint); n != 0 {
+		t.Errorf("orphan point warnings = %d, want 0 when lookup failed", n)
+	}
+}
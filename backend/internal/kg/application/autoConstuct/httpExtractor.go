@@ -0,0 +1,113 @@
+package auto
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// HTTPExtractor 调用外部 NLP/LLM 服务抽取知识。
+//
+// 请求: POST {"paragraphs": [{"text": "...", "level": 0}]}
+// 响应: {"nodes": [{"name", "type", "description", "confidence"}],
+// "relations": [{"type", "source_type", "target_type", "source_name", "target_name", "confidence"}]}
+type HTTPExtractor struct {
+	Endpoint string
+	ApiKey   string
+	Client   *http.Client
+}
+
+// NewHTTPExtractor 创建外部服务抽取器，timeout 为 0 时默认 60 秒
+func NewHTTPExtractor(endpoint, apiKey string, timeout time.Duration) *HTTPExtractor {
+	if timeout <= 0 {
+		timeout = 60 * time.Second
+	}
+	return &HTTPExtractor{
+		Endpoint: endpoint,
+		ApiKey:   apiKey,
+		Client:   &http.Client{Timeout: timeout},
+	}
+}
+
+func (e *HTTPExtractor) Name() string {
+	return "http"
+}
+
+// maxExtractResponseSize 抽取服务响应的大小上限
+const maxExtractResponseSize = 10 << 20
+
+type httpExtractRequest struct {
+	Paragraphs []httpParagraph `json:"paragraphs"`
+}
+
+type httpParagraph struct {
+	Text  string `json:"text"`
+	Level int    `json:"level"`
+}
+
+func (e *HTTPExtractor) Propose(ctx context.Context, paragraphs []Paragraph) (*Proposal, error) {
+	req := httpExtractRequest{Paragraphs: make([]httpParagraph, 0, len(paragraphs))}
+	for _, p := range paragraphs {
+		req.Paragraphs = append(req.Paragraphs, httpParagraph{Text: p.Text, Level: p.Level})
+	}
+	body, err := json.Marshal(req)
+	if err != nil {
+		return nil, err
+	}
+
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, e.Endpoint, bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("构造抽取请求失败: %v", err)
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+	if e.ApiKey != "" {
+		httpReq.Header.Set("Authorization", "Bearer "+e.ApiKey)
+	}
+
+	resp, err := e.Client.Do(httpReq)
+	if err != nil {
+		return nil, fmt.Errorf("调用抽取服务失败: %v", err)
+	}
+	defer resp.Body.Close()
+
+	content, err := readAllLimited(resp.Body, maxExtractResponseSize)
+	if errors.Is(err, errContentTooLarge) {
+		return nil, fmt.Errorf("抽取服务响应超过 %d MB", maxExtractResponseSize>>20)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("读取抽取服务响应失败: %v", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("抽取服务返回错误状态 %d: %s", resp.StatusCode, string(content))
+	}
+
+	var proposal Proposal
+	if err := json.Unmarshal(content, &proposal); err != nil {
+		return nil, fmt.Errorf("解析抽取服务响应失败: %v", err)
+	}
+	if err := proposal.checkNames(); err != nil {
+		return nil, fmt.Errorf("抽取服务响应无效: %v", err)
+	}
+	// 置信度限制在 [0, 1]
+	for i := range proposal.Nodes {
+		proposal.Nodes[i].Confidence = clampConfidence(proposal.Nodes[i].Confidence)
+	}
+	for i := range proposal.Relations {
+		proposal.Relations[i].Confidence = clampConfidence(proposal.Relations[i].Confidence)
+	}
+	return &proposal, nil
+}
+
+func clampConfidence(c float64) float64 {
+	if c < 0 {
+		return 0
+	}
+	if c > 1 {
+		return 1
+	}
+	return c
+}
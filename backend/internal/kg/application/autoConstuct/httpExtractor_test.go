@@ -0,0 +1,93 @@
+package auto
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// newStubExtractService 模拟外部抽取服务：每个段落生成一个知识点
+func newStubExtractService(t *testing.T) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer test-key" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		var req httpExtractRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Errorf("decode request: %v", err)
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		proposal := Proposal{}
+		for _, p := range req.Paragraphs {
+			proposal.Nodes = append(proposal.Nodes, ProposedNode{
+				Node:       Node{Name: p.Text, Type: "point"},
+				Confidence: 1.5,
+			})
+		}
+		json.NewEncoder(w).Encode(proposal)
+	}))
+}
+
+func TestHTTPExtractorPropose(t *testing.T) {
+	server := newStubExtractService(t)
+	defer server.Close()
+
+	extractor := NewHTTPExtractor(server.URL, "test-key", time.Second)
+	proposal, err := extractor.Propose(context.Background(), []Paragraph{{Text: "集合"}, {Text: "函数"}})
+	if err != nil {
+		t.Fatalf("Propose: %v", err)
+	}
+	if len(proposal.Nodes) != 2 || proposal.Nodes[1].Name != "函数" {
+		t.Fatalf("unexpected nodes: %+v", proposal.Nodes)
+	}
+	if proposal.Nodes[0].Confidence != 1 {
+		t.Errorf("confidence should be clamped to 1, got %v", proposal.Nodes[0].Confidence)
+	}
+}
+
+func TestHTTPExtractorServiceError(t *testing.T) {
+	server := newStubExtractService(t)
+	defer server.Close()
+
+	extractor := NewHTTPExtractor(server.URL, "wrong-key", time.Second)
+	if _, err := extractor.Propose(context.Background(), []Paragraph{{Text: "集合"}}); err == nil {
+		t.Fatal("expected error for unauthorized request")
+	}
+}
+
+func TestHTTPExtractorInvalidResponse(t *testing.T) {
+	tests := []struct {
+		name string
+		body func(w http.ResponseWriter)
+	}{
+		{"name too long", func(w http.ResponseWriter) {
+			json.NewEncoder(w).Encode(Proposal{Nodes: []ProposedNode{{Node: Node{Name: strings.Repeat("栈", maxNodeNameLength+1), Type: "point"}}}})
+		}},
+		{"relation name too long", func(w http.ResponseWriter) {
+			json.NewEncoder(w).Encode(Proposal{Relations: []ProposedRelation{{Relation: Relation{Type: "前置",
+				SourceType: "point", SourceName: "栈", TargetType: "point", TargetName: strings.Repeat("a", maxNodeNameLength+1)}}}})
+		}},
+		{"response too large", func(w http.ResponseWriter) {
+			w.Write([]byte(`{"nodes": [], "padding": "`))
+			w.Write(bytes.Repeat([]byte("a"), maxExtractResponseSize))
+			w.Write([]byte(`"}`))
+		}},
+	}
+	for _, tt := range tests {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			tt.body(w)
+		}))
+		extractor := NewHTTPExtractor(server.URL, "", time.Second)
+		if _, err := extractor.Propose(context.Background(), []Paragraph{{Text: "栈"}}); err == nil {
+			t.Errorf("%s: expected error", tt.name)
+		}
+		server.Close()
+	}
+}
@@ -1,6 +1,13 @@
 package auto
 
-import "fmt"
+import (
+	"context"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
 
 // Node 表示节点
 type Node struct {
@@ -18,46 +25,6 @@ type Relation struct {
 	TargetName string `json:"target_name"`
 }
 
-// ValidateKnowledgeGraph 验证知识图谱数据
-func ValidateKnowledgeGraph(graph *KnowledgeGraph) error {
-	// 验证节点
-	for _, node := range graph.Nodes {
-		if !isValidNodeType(node.Type) {
-			return fmt.Errorf("无效的节点类型: %s", node.Type)
-		}
-		if node.Name == "" {
-			return fmt.Errorf("节点名称不能为空")
-		}
-	}
-
-	// 验证关系
-	for _, relation := range graph.Relations {
-		// 检查节点类型是否有效
-		if !isValidNodeType(relation.SourceType) || !isValidNodeType(relation.TargetType) {
-			return fmt.Errorf("关系中的节点类型无效")
-		}
-
-		// 如果是不同类型的节点之间的关系
-		if relation.SourceType != relation.TargetType {
-			// 检查是否为有效的层级关系
-			if !isValidHierarchicalRelation(relation.SourceType, relation.TargetType) {
-				return fmt.Errorf("无效的节点层级关系: %s -> %s", relation.SourceType, relation.TargetType)
-			}
-			// 不同类型节点间只能是包含关系
-			if relation.Type != "包含" {
-				return fmt.Errorf("不同类型节点间只支持包含关系，当前关系类型: %s", relation.Type)
-			}
-		} else {
-			// 同类型节点间的关系验证
-			if !isValidRelationType(relation.Type) {
-				return fmt.Errorf("无效的关系类型: %s", relation.Type)
-			}
-		}
-	}
-
-	return nil
-}
-
 // isValidNodeType 检查节点类型是否有效
 func isValidNodeType(nodeType string) bool {
 	validTypes := map[string]bool{
@@ -91,3 +58,237 @@ func isValidRelationType(relationType string) bool {
 	}
 	return validTypes[relationType]
 }
+
+// 章节编号的识别规则
+var (
+	chapterPattern    = regexp.MustCompile(`^第\s*([0-9一二三四五六七八九十百零〇两]+)\s*章[\s:：、.．]*(.*)$`)
+	enChapterPattern  = regexp.MustCompile(`(?i)^chapter\s+(\d+)[\s:.]*(.*)$`)
+	cnSectionPattern  = regexp.MustCompile(`^第\s*([0-9一二三四五六七八九十百零〇两]+)\s*节[\s:：、.．]*(.*)$`)
+	pointNumPattern   = regexp.MustCompile(`^(\d+)[.．](\d+)[.．](\d+)[.．]?\s*([^\d\s.．].*)$`)
+	sectionNumPattern = regexp.MustCompile(`^(\d+)[.．](\d+)[.．]?\s*([^\d\s.．].*)$`)
+	// 目录条目末尾的引导符和页码，如 "1.1 集合 ........ 12"
+	tocSuffixPattern = regexp.MustCompile(`[\s.．·…_-]{2,}\d+$`)
+)
+
+// RuleBasedExtractor 按编号格式和标题样式从教材段落中抽取章、节、知识点。
+// 标题之后的正文段落作为最近一个节点的描述。
+type RuleBasedExtractor struct {
+	// MaxTitleLen 标题的最大字数，超过时按正文处理
+	MaxTitleLen int
+	// MaxDescriptionLen 描述的最大字数
+	MaxDescriptionLen int
+}
+
+// NewRuleBasedExtractor 创建使用默认参数的规则抽取器
+func NewRuleBasedExtractor() *RuleBasedExtractor {
+	return &RuleBasedExtractor{
+		MaxTitleLen:       40,
+		MaxDescriptionLen: 300,
+	}
+}
+
+// heading 表示识别出的一个标题
+type heading struct {
+	Type   string
+	Number string
+	Title  string
+}
+
+// Extract 从段落序列中抽取知识图谱
+func (e *RuleBasedExtractor) Extract(paragraphs []Paragraph) *KnowledgeGraph {
+	graph, _ := e.extract(paragraphs)
+	return graph
+}
+
+// Name 实现 KnowledgeExtractor 接口
+func (e *RuleBasedExtractor) Name() string {
+	return "rule"
+}
+
+// Propose 实现 KnowledgeExtractor 接口。有编号的标题置信度较高，
+// 只依据标题样式识别的标题置信度较低，关系取两端节点中较低的置信度。
+func (e *RuleBasedExtractor) Propose(ctx context.Context, paragraphs []Paragraph) (*Proposal, error) {
+	graph, confidence := e.extract(paragraphs)
+	proposal := &Proposal{}
+	byName := make(map[nodeKey]float64, len(graph.Nodes))
+	for i, node := range graph.Nodes {
+		proposal.Nodes = append(proposal.Nodes, ProposedNode{Node: node, Confidence: confidence[i]})
+		byName[nodeKey{node.Type, node.Name}] = confidence[i]
+	}
+	for _, relation := range graph.Relations {
+		c := math.Min(byName[nodeKey{relation.SourceType, relation.SourceName}], byName[nodeKey{relation.TargetType, relation.TargetName}])
+		proposal.Relations = append(proposal.Relations, ProposedRelation{Relation: relation, Confidence: c})
+	}
+	return proposal, nil
+}
+
+// extract 返回抽取出的图谱以及每个节点的置信度
+func (e *RuleBasedExtractor) extract(paragraphs []Paragraph) (*KnowledgeGraph, []float64) {
+	graph := &KnowledgeGraph{Nodes: []Node{}, Relations: []Relation{}}
+	var confidence []float64
+
+	// 同一标题在目录和正文中各出现一次，用 类型+编号 合并为同一个节点
+	index := make(map[string]int)
+	names := make(map[nodeKey]string)
+	linked := make(map[string]bool)
+	var chapter, section *heading
+	var chapterNode, sectionNode, currentNode = -1, -1, -1
+
+	for _, p := range paragraphs {
+		text := strings.TrimSpace(p.Text)
+		if text == "" {
+			continue
+		}
+		h := e.classify(text, p.Level, chapter, section)
+		if h == nil {
+			// 正文段落追加到最近的节点描述中
+			if currentNode >= 0 {
+				e.appendDescription(&graph.Nodes[currentNode], text)
+			}
+			continue
+		}
+
+		key := h.Type + "|" + h.Number
+		if h.Number == "" {
+			key = h.Type + "|" + h.Title
+		}
+		if h.Type == "section" && h.Number != "" && chapter != nil && strings.HasPrefix(key, "section|第") {
+			// "第一节" 只在章内唯一
+			key = chapter.Number + "|" + key
+		}
+
+		idx, exists := index[key]
+		if !exists {
+			name := h.Title
+			if name == "" {
+				name = text
+			}
+			if other, dup := names[nodeKey{h.Type, name}]; dup && other != key && h.Number != "" {
+				name = h.Number + " " + name
+			}
+			names[nodeKey{h.Type, name}] = key
+			graph.Nodes = append(graph.Nodes, Node{Name: name, Type: h.Type})
+			confidence = append(confidence, 0.8)
+			idx = len(graph.Nodes) - 1
+			index[key] = idx
+		}
+		if h.Number != "" {
+			confidence[idx] = 0.95
+		}
+
+		switch h.Type {
+		case "chapter":
+			chapter, section = h, nil
+			chapterNode, sectionNode = idx, -1
+		case "section":
+			section = h
+			sectionNode = idx
+			if chapterNode >= 0 {
+				e.link(graph, linked, chapterNode, idx)
+			}
+		case "point":
+			if sectionNode >= 0 {
+				e.link(graph, linked, sectionNode, idx)
+			}
+		}
+		currentNode = idx
+	}
+	return graph, confidence
+}
+
+func (e *RuleBasedExtractor) link(graph *KnowledgeGraph, linked map[string]bool, parent, child int) {
+	p, c := graph.Nodes[parent], graph.Nodes[child]
+	key := p.Type + "|" + p.Name + "|" + c.Type + "|" + c.Name
+	if linked[key] {
+		return
+	}
+	linked[key] = true
+	graph.Relations = append(graph.Relations, Relation{
+		Type:       "包含",
+		SourceType: p.Type,
+		TargetType: c.Type,
+		SourceName: p.Name,
+		TargetName: c.Name,
+	})
+}
+
+func (e *RuleBasedExtractor) appendDescription(node *Node, text string) {
+	current := []rune(node.Description)
+	if len(current) >= e.MaxDescriptionLen {
+		return
+	}
+	remain := e.MaxDescriptionLen - len(current)
+	runes := []rune(text)
+	if len(runes) > remain {
+		runes = runes[:remain]
+	}
+	node.Description += string(runes)
+}
+
+// classify 判断段落是否为标题。编号形如 "a.b" 的小节要求 a 与当前章编号一致，
+// 形如 "a.b.c" 的知识点要求 a.b 与当前小节编号一致，以减少正文中数字被误判。
+func (e *RuleBasedExtractor) classify(text string, level int, chapter, section *heading) *heading {
+	text = strings.TrimSpace(tocSuffixPattern.ReplaceAllString(text, ""))
+	if utf8.RuneCountInString(text) > e.MaxTitleLen {
+		return nil
+	}
+
+	if m := chapterPattern.FindStringSubmatch(text); m != nil {
+		return &heading{Type: "chapter", Number: strconv.Itoa(chineseNumber(m[1])), Title: strings.TrimSpace(m[2])}
+	}
+	if m := enChapterPattern.FindStringSubmatch(text); m != nil {
+		return &heading{Type: "chapter", Number: m[1], Title: strings.TrimSpace(m[2])}
+	}
+	if m := cnSectionPattern.FindStringSubmatch(text); m != nil {
+		return &heading{Type: "section", Number: "第" + strconv.Itoa(chineseNumber(m[1])), Title: strings.TrimSpace(m[2])}
+	}
+	if m := pointNumPattern.FindStringSubmatch(text); m != nil {
+		if section == nil || section.Number == m[1]+"."+m[2] {
+			return &heading{Type: "point", Number: m[1] + "." + m[2] + "." + m[3], Title: strings.TrimSpace(m[4])}
+		}
+	}
+	if m := sectionNumPattern.FindStringSubmatch(text); m != nil {
+		if chapter == nil || chapter.Number == m[1] {
+			return &heading{Type: "section", Number: m[1] + "." + m[2], Title: strings.TrimSpace(m[3])}
+		}
+	}
+
+	// 没有编号时使用文档的标题样式
+	switch level {
+	case 1:
+		return &heading{Type: "chapter", Title: text}
+	case 2:
+		return &heading{Type: "section", Title: text}
+	case 3:
+		return &heading{Type: "point", Title: text}
+	}
+	return nil
+}
+
+// chineseNumber 将 "十二"、"二十一" 等中文数字或阿拉伯数字转换为整数
+func chineseNumber(s string) int {
+	if n, err := strconv.Atoi(s); err == nil {
+		return n
+	}
+	digits := map[rune]int{'零': 0, '〇': 0, '一': 1, '二': 2, '两': 2, '三': 3, '四': 4, '五': 5, '六': 6, '七': 7, '八': 8, '九': 9}
+	total, current := 0, 0
+	for _, r := range s {
+		switch r {
+		case '十':
+			if current == 0 {
+				current = 1
+			}
+			total += current * 10
+			current = 0
+		case '百':
+			if current == 0 {
+				current = 1
+			}
+			total += current * 100
+			current = 0
+		default:
+			current = digits[r]
+		}
+	}
+	return total + current
+}
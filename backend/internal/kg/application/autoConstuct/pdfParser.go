@@ -0,0 +1,803 @@
+package auto
+
+import (
+	"bytes"
+	"compress/zlib"
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf16"
+)
+
+// parsePdfParagraphs 从 PDF 的内容流中提取文本行。
+// 按页面树找到每页的内容流，并使用页面资源中各字体的 ToUnicode 映射还原中文；
+// 只支持未压缩或 FlateDecode 压缩的流，扫描版 PDF 没有文本层，无法提取。
+func parsePdfParagraphs(content []byte) ([]Paragraph, error) {
+	if !bytes.HasPrefix(bytes.TrimSpace(content), []byte("%PDF")) {
+		return nil, fmt.Errorf("PDF解析失败: 文件头无效")
+	}
+
+	doc, err := parsePdfDocument(content)
+	if err != nil {
+		return nil, err
+	}
+	pages, err := doc.pages()
+	if err != nil {
+		return nil, err
+	}
+	var text string
+	if len(pages) > 0 {
+		var out strings.Builder
+		for _, page := range pages {
+			pageText, err := doc.contentText(page.contents, page.resources, 0)
+			if err != nil {
+				return nil, err
+			}
+			out.WriteString(pageText)
+			out.WriteString("\n")
+		}
+		text = out.String()
+	} else if text, err = doc.fallbackText(); err != nil {
+		return nil, err
+	}
+
+	paragraphs := splitLines(text)
+	if len(paragraphs) == 0 {
+		return nil, fmt.Errorf("PDF解析失败: 未找到文本内容，可能是扫描版PDF")
+	}
+	return paragraphs, nil
+}
+
+// pdfObject 是文件中的一个间接对象，stream 对象的数据在首次使用时解码
+type pdfObject struct {
+	value   pdfToken
+	raw     []byte
+	decoded []byte
+	// unsupported 表示 stream 使用了不支持的压缩方式
+	unsupported bool
+}
+
+// 恶意构造的文件可以让页面树和表单相互引用，或用很小的压缩流生成大量数据，
+// 以下上限保证解析时间和内存与文件大小无关
+const (
+	maxPdfPages = 5000
+	maxPdfForms = 1000
+	// maxPdfCMapEntries 为全部 ToUnicode 映射的条目总数上限，常见中文字体约 3 万条
+	maxPdfCMapEntries = 1 << 18
+)
+
+// pdfDocument 按对象编号索引文件中的对象，包括压缩在对象流中的对象
+type pdfDocument struct {
+	objects map[int]*pdfObject
+	// nums 为按编号排序的对象编号
+	nums  []int
+	cmaps map[int]pdfCMap
+	// remaining 为还可以解压的字节数
+	remaining int64
+	// contentRemaining 为还可以分析的内容流字节数，同一个流被多次引用时重复计算
+	contentRemaining int64
+	// cmapRemaining 为还可以创建的映射条目数
+	cmapRemaining int
+	// visited 为已遍历的页面树节点和已提取的表单，每个对象只处理一次
+	visited map[int]bool
+	forms   int
+}
+
+var pdfObjectHeader = regexp.MustCompile(`(\d+)\s+\d+\s+obj\b`)
+
+func parsePdfDocument(content []byte) (*pdfDocument, error) {
+	doc := &pdfDocument{
+		objects:          make(map[int]*pdfObject),
+		cmaps:            make(map[int]pdfCMap),
+		remaining:        maxExtractedSize,
+		contentRemaining: maxExtractedSize,
+		cmapRemaining:    maxPdfCMapEntries,
+		visited:          make(map[int]bool),
+	}
+	pos := 0
+	for {
+		loc := pdfObjectHeader.FindSubmatchIndex(content[pos:])
+		if loc == nil {
+			break
+		}
+		num, _ := strconv.Atoi(string(content[pos+loc[2] : pos+loc[3]]))
+		bodyStart := pos + loc[1]
+		end := bytes.Index(content[bodyStart:], []byte("endobj"))
+		if end < 0 {
+			end = len(content) - bodyStart
+		}
+		obj := &pdfObject{}
+		pos = bodyStart + end
+		if idx := bytes.Index(content[bodyStart:], []byte("stream")); idx >= 0 && idx < end {
+			// stream 关键字后紧跟 CRLF 或 LF
+			dataStart := bodyStart + idx + len("stream")
+			if dataStart < len(content) && content[dataStart] == '\r' {
+				dataStart++
+			}
+			if dataStart < len(content) && content[dataStart] == '\n' {
+				dataStart++
+			}
+			dataEnd := bytes.Index(content[dataStart:], []byte("endstream"))
+			if dataEnd < 0 {
+				break
+			}
+			obj.raw = content[dataStart : dataStart+dataEnd]
+			end = idx
+			pos = dataStart + dataEnd + len("endstream")
+		}
+		if tokens := pdfTokenize(content[bodyStart : bodyStart+end]); len(tokens) > 0 {
+			obj.value = tokens[0]
+		}
+		doc.objects[num] = obj
+	}
+
+	// 展开对象流，文件中直接定义的对象优先
+	for num, obj := range doc.objects {
+		if obj.raw == nil || pdfDictName(obj.value, "/Type") != "/ObjStm" {
+			continue
+		}
+		data, err := doc.stream(num)
+		if err != nil {
+			return nil, err
+		}
+		doc.expandObjectStream(obj.value, data)
+	}
+	for num := range doc.objects {
+		doc.nums = append(doc.nums, num)
+	}
+	sort.Ints(doc.nums)
+	return doc, nil
+}
+
+// expandObjectStream 对象流开头为 N 对 "对象编号 偏移"，偏移相对 /First
+func (d *pdfDocument) expandObjectStream(dict pdfToken, data []byte) {
+	n, _ := strconv.Atoi(pdfDictValue(dict, "/N").value)
+	first, _ := strconv.Atoi(pdfDictValue(dict, "/First").value)
+	if first <= 0 || first > len(data) {
+		return
+	}
+	header := pdfTokenize(data[:first])
+	if len(header) < 2*n {
+		return
+	}
+	for i := 0; i < n; i++ {
+		num, _ := strconv.Atoi(header[2*i].value)
+		start, _ := strconv.Atoi(header[2*i+1].value)
+		end := len(data) - first
+		if i+1 < n {
+			end, _ = strconv.Atoi(header[2*i+3].value)
+		}
+		if _, ok := d.objects[num]; ok || start < 0 || start > end || first+end > len(data) {
+			continue
+		}
+		if tokens := pdfTokenize(data[first+start : first+end]); len(tokens) > 0 {
+			d.objects[num] = &pdfObject{value: tokens[0]}
+		}
+	}
+}
+
+// object 返回间接引用指向的对象
+func (d *pdfDocument) object(ref pdfToken) (int, *pdfObject) {
+	if ref.kind != pdfRef {
+		return 0, nil
+	}
+	num, _ := strconv.Atoi(ref.value)
+	return num, d.objects[num]
+}
+
+// resolve 把间接引用替换为所指对象的值
+func (d *pdfDocument) resolve(t pdfToken) pdfToken {
+	for depth := 0; t.kind == pdfRef && depth < 8; depth++ {
+		_, obj := d.object(t)
+		if obj == nil {
+			return pdfToken{}
+		}
+		t = obj.value
+	}
+	return t
+}
+
+// stream 返回对象解码后的 stream 数据，不支持的压缩方式返回 nil；
+// 解压总量超过 maxExtractedSize 时返回错误
+func (d *pdfDocument) stream(num int) ([]byte, error) {
+	obj := d.objects[num]
+	if obj == nil || obj.raw == nil || obj.unsupported {
+		return nil, nil
+	}
+	if obj.decoded != nil {
+		return obj.decoded, nil
+	}
+	filter := d.resolve(pdfDictValue(obj.value, "/Filter"))
+	if filter.kind == pdfArray && len(filter.items) == 1 {
+		filter = filter.items[0]
+	}
+	switch filter.value {
+	case "":
+		obj.decoded = obj.raw
+	case "/FlateDecode":
+		r, err := zlib.NewReader(bytes.NewReader(obj.raw))
+		if err != nil {
+			obj.unsupported = true
+			return nil, nil
+		}
+		defer r.Close()
+		// 流长度不精确时 zlib 可能在末尾报错，已解出的部分仍然可用
+		decoded, err := readAllLimited(r, d.remaining)
+		if errors.Is(err, errContentTooLarge) {
+			return nil, fmt.Errorf("PDF解析失败: 解压后的内容超过 %d MB", maxExtractedSize>>20)
+		}
+		d.remaining -= int64(len(decoded))
+		obj.decoded = decoded
+	default:
+		// 其他压缩方式不支持
+		obj.unsupported = true
+	}
+	return obj.decoded, nil
+}
+
+// pdfPage 为一页的内容流引用和（可能继承自上级节点的）资源字典
+type pdfPage struct {
+	contents  []pdfToken
+	resources pdfToken
+}
+
+// pages 从文档目录开始按顺序遍历页面树
+func (d *pdfDocument) pages() ([]pdfPage, error) {
+	var pages []pdfPage
+	for _, num := range d.nums {
+		if value := d.objects[num].value; pdfDictName(value, "/Type") == "/Catalog" {
+			if err := d.walkPages(pdfDictValue(value, "/Pages"), pdfToken{}, 0, &pages); err != nil {
+				return nil, err
+			}
+			break
+		}
+	}
+	return pages, nil
+}
+
+// walkPages 每个节点对象只访问一次，循环引用或重复引用的子节点被跳过
+func (d *pdfDocument) walkPages(node pdfToken, resources pdfToken, depth int, pages *[]pdfPage) error {
+	if num, obj := d.object(node); obj != nil {
+		if d.visited[num] {
+			return nil
+		}
+		d.visited[num] = true
+	}
+	node = d.resolve(node)
+	if node.kind != pdfDict || depth > 32 {
+		return nil
+	}
+	if r := d.resolve(pdfDictValue(node, "/Resources")); r.kind == pdfDict {
+		resources = r
+	}
+	if pdfDictName(node, "/Type") == "/Page" {
+		if len(*pages) >= maxPdfPages {
+			return fmt.Errorf("PDF解析失败: 页数超过 %d", maxPdfPages)
+		}
+		*pages = append(*pages, pdfPage{contents: d.refs(pdfDictValue(node, "/Contents")), resources: resources})
+		return nil
+	}
+	for _, kid := range d.resolve(pdfDictValue(node, "/Kids")).items {
+		if err := d.walkPages(kid, resources, depth+1, pages); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// refs 返回单个引用或引用数组中的全部引用
+func (d *pdfDocument) refs(t pdfToken) []pdfToken {
+	if _, obj := d.object(t); obj != nil && obj.raw != nil {
+		return []pdfToken{t}
+	}
+	var refs []pdfToken
+	for _, item := range d.resolve(t).items {
+		if item.kind == pdfRef {
+			refs = append(refs, item)
+		}
+	}
+	return refs
+}
+
+// fonts 返回资源字典中每个字体名称对应的 ToUnicode 映射，没有映射的字体不在结果中
+func (d *pdfDocument) fonts(resources pdfToken) (map[string]pdfCMap, error) {
+	fonts := make(map[string]pdfCMap)
+	dict := d.resolve(pdfDictValue(resources, "/Font"))
+	for i := 0; i+1 < len(dict.items); i += 2 {
+		num, obj := d.object(pdfDictValue(d.resolve(dict.items[i+1]), "/ToUnicode"))
+		if obj == nil {
+			continue
+		}
+		cmap, ok := d.cmaps[num]
+		if !ok {
+			data, err := d.stream(num)
+			if err != nil {
+				return nil, err
+			}
+			cmap = pdfCMap{}
+			if err := cmap.parse(data, &d.cmapRemaining); err != nil {
+				return nil, err
+			}
+			d.cmaps[num] = cmap
+		}
+		fonts[dict.items[i].value] = cmap
+	}
+	return fonts, nil
+}
+
+// contentText 提取一组内容流的文本，Do 引用的表单 XObject 使用其自身的资源递归提取
+func (d *pdfDocument) contentText(contents []pdfToken, resources pdfToken, depth int) (string, error) {
+	fonts, err := d.fonts(resources)
+	if err != nil {
+		return "", err
+	}
+	xobjects := d.resolve(pdfDictValue(resources, "/XObject"))
+	var formErr error
+	// 每个表单只提取一次：页眉等在每页重复出现的表单只输出一次文字，也不会自我引用
+	form := func(name string) string {
+		ref := pdfDictValue(xobjects, name)
+		num, obj := d.object(ref)
+		if obj == nil || d.visited[num] || depth >= 8 || formErr != nil || pdfDictName(obj.value, "/Subtype") != "/Form" {
+			return ""
+		}
+		d.visited[num] = true
+		if d.forms++; d.forms > maxPdfForms {
+			formErr = fmt.Errorf("PDF解析失败: 表单数量超过 %d", maxPdfForms)
+			return ""
+		}
+		formResources := resources
+		if r := d.resolve(pdfDictValue(obj.value, "/Resources")); r.kind == pdfDict {
+			formResources = r
+		}
+		text, err := d.contentText([]pdfToken{ref}, formResources, depth+1)
+		if err != nil {
+			formErr = err
+		}
+		return text
+	}
+
+	var out strings.Builder
+	for _, ref := range contents {
+		num, _ := d.object(ref)
+		data, err := d.stream(num)
+		if err != nil {
+			return "", err
+		}
+		if d.contentRemaining -= int64(len(data)); d.contentRemaining < 0 {
+			return "", fmt.Errorf("PDF解析失败: 内容流总量超过 %d MB", maxExtractedSize>>20)
+		}
+		out.WriteString(pdfContentText(data, fonts, nil, form))
+		out.WriteString("\n")
+	}
+	return out.String(), formErr
+}
+
+// fallbackText 找不到页面树（如文件损坏）时，把含文本对象的流都当作页面内容，
+// 此时无法确定字体与映射的对应关系，所有 ToUnicode 映射合并使用
+func (d *pdfDocument) fallbackText() (string, error) {
+	merged := pdfCMap{}
+	var contents [][]byte
+	for _, num := range d.nums {
+		obj := d.objects[num]
+		if obj.raw == nil {
+			continue
+		}
+		if subtype := pdfDictName(obj.value, "/Subtype"); subtype != "" && subtype != "/Form" {
+			continue
+		}
+		data, err := d.stream(num)
+		if err != nil {
+			return "", err
+		}
+		if bytes.Contains(data, []byte("begincmap")) {
+			if err := merged.parse(data, &d.cmapRemaining); err != nil {
+				return "", err
+			}
+		} else if hasPdfOperator(data, "BT") {
+			contents = append(contents, data)
+		}
+	}
+	var out strings.Builder
+	for _, data := range contents {
+		out.WriteString(pdfContentText(data, nil, merged, nil))
+		out.WriteString("\n")
+	}
+	return out.String(), nil
+}
+
+func hasPdfOperator(data []byte, operator string) bool {
+	for _, tok := range pdfTokenize(data) {
+		if tok.kind == pdfOperator && tok.value == operator {
+			return true
+		}
+	}
+	return false
+}
+
+// pdfCMap 是一个字体的 ToUnicode 映射，key 为字符编码的字节串
+type pdfCMap map[string]string
+
+// parse 读取 bfchar 和 bfrange 映射，remaining 为还可以创建的条目数，用完时返回错误
+func (m pdfCMap) parse(stream []byte, remaining *int) error {
+	add := func(code, text string) error {
+		if *remaining <= 0 {
+			return fmt.Errorf("PDF解析失败: 字符映射条目超过 %d", maxPdfCMapEntries)
+		}
+		*remaining--
+		m[code] = text
+		return nil
+	}
+	tokens := pdfTokenize(stream)
+	for i := 0; i < len(tokens); i++ {
+		switch tokens[i].value {
+		case "beginbfchar":
+			for i+2 < len(tokens) && tokens[i+1].value != "endbfchar" {
+				if err := add(tokens[i+1].value, utf16BEString(tokens[i+2].value)); err != nil {
+					return err
+				}
+				i += 2
+			}
+		case "beginbfrange":
+			for i+3 < len(tokens) && tokens[i+1].value != "endbfrange" {
+				lo, hi, dst := tokens[i+1], tokens[i+2], tokens[i+3]
+				i += 3
+				loN, hiN := bytesToInt(lo.value), bytesToInt(hi.value)
+				if hiN < loN || hiN-loN > 0xffff {
+					continue
+				}
+				if dst.kind == pdfArray {
+					for j, item := range dst.items {
+						if err := add(intToBytes(loN+j, len(lo.value)), utf16BEString(item.value)); err != nil {
+							return err
+						}
+					}
+					continue
+				}
+				base := []rune(utf16BEString(dst.value))
+				if len(base) == 0 {
+					continue
+				}
+				for code := loN; code <= hiN; code++ {
+					r := append([]rune{}, base...)
+					r[len(r)-1] += rune(code - loN)
+					if err := add(intToBytes(code, len(lo.value)), string(r)); err != nil {
+						return err
+					}
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// decode 先尝试按 2 字节、再按 1 字节查找映射，都失败时按单字节编码输出
+func (m pdfCMap) decode(s string) string {
+	if strings.HasPrefix(s, "\xfe\xff") {
+		return utf16BEString(s[2:])
+	}
+	if len(m) > 0 {
+		for _, width := range []int{2, 1} {
+			if len(s)%width != 0 {
+				continue
+			}
+			var out strings.Builder
+			ok := true
+			for i := 0; i < len(s); i += width {
+				u, found := m[s[i:i+width]]
+				if !found {
+					ok = false
+					break
+				}
+				out.WriteString(u)
+			}
+			if ok {
+				return out.String()
+			}
+		}
+	}
+	var out strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] >= 0x20 && s[i] < 0x7f {
+			out.WriteByte(s[i])
+		}
+	}
+	return out.String()
+}
+
+// pdfContentText 按文本操作符还原内容流中的文字，换行操作符输出换行。
+// Tf 选择 fonts 中对应字体的映射；fonts 为 nil 时始终使用 cmap。
+// form 返回 Do 操作符引用的表单 XObject 中的文字，可以为 nil
+func pdfContentText(stream []byte, fonts map[string]pdfCMap, cmap pdfCMap, form func(name string) string) string {
+	var out strings.Builder
+	var operands []pdfToken
+	lastY := ""
+	for _, tok := range pdfTokenize(stream) {
+		if tok.kind != pdfOperator {
+			operands = append(operands, tok)
+			continue
+		}
+		switch tok.value {
+		case "Tf":
+			if n := len(operands); fonts != nil && n >= 2 && operands[n-2].kind == pdfName {
+				// 没有 ToUnicode 映射的字体按单字节编码输出
+				cmap = fonts[operands[n-2].value]
+			}
+		case "Do":
+			if n := len(operands); form != nil && n > 0 && operands[n-1].kind == pdfName {
+				out.WriteString(form(operands[n-1].value))
+				out.WriteString("\n")
+			}
+		case "Tj", "'", "\"":
+			if tok.value != "Tj" {
+				out.WriteString("\n")
+			}
+			if n := len(operands); n > 0 && operands[n-1].kind == pdfString {
+				out.WriteString(cmap.decode(operands[n-1].value))
+			}
+		case "TJ":
+			if n := len(operands); n > 0 && operands[n-1].kind == pdfArray {
+				for _, item := range operands[n-1].items {
+					if item.kind == pdfString {
+						out.WriteString(cmap.decode(item.value))
+					} else if v, err := strconv.ParseFloat(item.value, 64); err == nil && v < -250 {
+						// 较大的负间距在西文排版中表示单词间空格
+						out.WriteString(" ")
+					}
+				}
+			}
+		case "Td", "TD":
+			if n := len(operands); n >= 2 {
+				if ty, err := strconv.ParseFloat(operands[n-1].value, 64); err == nil && ty != 0 {
+					out.WriteString("\n")
+				}
+			}
+		case "T*", "ET":
+			out.WriteString("\n")
+		case "Tm":
+			if n := len(operands); n >= 6 {
+				if y := operands[n-1].value; y != lastY {
+					out.WriteString("\n")
+					lastY = y
+				}
+			}
+		}
+		operands = operands[:0]
+	}
+	return out.String()
+}
+
+const (
+	pdfOperator = iota
+	pdfNumber
+	pdfName
+	pdfString
+	pdfArray
+	pdfDict
+	// pdfRef 为间接引用 "N G R"，value 为对象编号
+	pdfRef
+)
+
+type pdfToken struct {
+	kind  int
+	value string
+	// items 为数组的元素或字典交替排列的键和值
+	items []pdfToken
+}
+
+// pdfDictValue 返回字典中 key 对应的值，不存在时返回零值
+func pdfDictValue(dict pdfToken, key string) pdfToken {
+	if dict.kind != pdfDict {
+		return pdfToken{}
+	}
+	for i := 0; i+1 < len(dict.items); i += 2 {
+		if dict.items[i].kind == pdfName && dict.items[i].value == key {
+			return dict.items[i+1]
+		}
+	}
+	return pdfToken{}
+}
+
+// pdfDictName 返回字典中 key 对应的名称值，如 /Type 的 "/Page"
+func pdfDictName(dict pdfToken, key string) string {
+	if v := pdfDictValue(dict, key); v.kind == pdfName {
+		return v.value
+	}
+	return ""
+}
+
+// pdfTokenize 是一个简化的 PDF 词法分析器，只保留文本提取需要的信息
+func pdfTokenize(data []byte) []pdfToken {
+	tokens, _ := pdfTokenizeUntil(data, 0, -1, 0)
+	return tokens
+}
+
+// closer 为结束当前数组或字典的字符，-1 表示读到数据末尾；
+// depth 为数组和字典的嵌套层数，超过上限的左括号被忽略，避免递归过深
+func pdfTokenizeUntil(data []byte, pos int, closer int, depth int) ([]pdfToken, int) {
+	var tokens []pdfToken
+	for pos < len(data) {
+		c := data[pos]
+		switch {
+		case int(c) == closer:
+			return tokens, pos + 1
+		case isPdfSpace(c):
+			pos++
+		case c == '%':
+			for pos < len(data) && data[pos] != '\n' && data[pos] != '\r' {
+				pos++
+			}
+		case c == '(':
+			s, next := pdfLiteralString(data, pos+1)
+			tokens = append(tokens, pdfToken{kind: pdfString, value: s})
+			pos = next
+		case c == '[' && depth >= 32:
+			pos++
+		case c == '<' && pos+1 < len(data) && data[pos+1] == '<' && depth >= 32:
+			pos += 2
+		case c == '<' && pos+1 < len(data) && data[pos+1] == '<':
+			items, next := pdfTokenizeUntil(data, pos+2, '>', depth+1)
+			// 字典以 ">>" 结束，跳过第二个 '>'
+			if next < len(data) && data[next] == '>' {
+				next++
+			}
+			tokens = append(tokens, pdfToken{kind: pdfDict, items: items})
+			pos = next
+		case c == '<':
+			end := bytes.IndexByte(data[pos:], '>')
+			if end < 0 {
+				return tokens, len(data)
+			}
+			tokens = append(tokens, pdfToken{kind: pdfString, value: pdfHexString(data[pos+1 : pos+end])})
+			pos += end + 1
+		case c == '[':
+			items, next := pdfTokenizeUntil(data, pos+1, ']', depth+1)
+			tokens = append(tokens, pdfToken{kind: pdfArray, items: items})
+			pos = next
+		case c == ']' || c == '>' || c == ')' || c == '{' || c == '}':
+			pos++
+		case c == '/':
+			end := pos + 1
+			for end < len(data) && !isPdfSpace(data[end]) && !isPdfDelimiter(data[end]) {
+				end++
+			}
+			tokens = append(tokens, pdfToken{kind: pdfName, value: string(data[pos:end])})
+			pos = end
+		default:
+			end := pos
+			for end < len(data) && !isPdfSpace(data[end]) && !isPdfDelimiter(data[end]) {
+				end++
+			}
+			if end == pos {
+				end++
+			}
+			word := string(data[pos:end])
+			pos = end
+			if n := len(tokens); word == "R" && n >= 2 && tokens[n-2].kind == pdfNumber && tokens[n-1].kind == pdfNumber {
+				// 间接引用合并为一个记号，字典的键和值因此总是成对出现
+				tokens = append(tokens[:n-2], pdfToken{kind: pdfRef, value: tokens[n-2].value})
+				continue
+			}
+			kind := pdfOperator
+			if _, err := strconv.ParseFloat(word, 64); err == nil {
+				kind = pdfNumber
+			}
+			tokens = append(tokens, pdfToken{kind: kind, value: word})
+		}
+	}
+	return tokens, pos
+}
+
+func pdfLiteralString(data []byte, pos int) (string, int) {
+	var out []byte
+	depth := 1
+	for pos < len(data) {
+		c := data[pos]
+		switch c {
+		case '\\':
+			pos++
+			if pos >= len(data) {
+				return string(out), pos
+			}
+			e := data[pos]
+			switch e {
+			case 'n':
+				out = append(out, '\n')
+			case 'r':
+				out = append(out, '\r')
+			case 't':
+				out = append(out, '\t')
+			case 'b':
+				out = append(out, '\b')
+			case 'f':
+				out = append(out, '\f')
+			case '\r', '\n':
+				// 续行
+			default:
+				if e >= '0' && e <= '7' {
+					v, n := 0, 0
+					for n < 3 && pos < len(data) && data[pos] >= '0' && data[pos] <= '7' {
+						v = v*8 + int(data[pos]-'0')
+						pos++
+						n++
+					}
+					out = append(out, byte(v))
+					continue
+				}
+				out = append(out, e)
+			}
+		case '(':
+			depth++
+			out = append(out, c)
+		case ')':
+			depth--
+			if depth == 0 {
+				return string(out), pos + 1
+			}
+			out = append(out, c)
+		default:
+			out = append(out, c)
+		}
+		pos++
+	}
+	return string(out), pos
+}
+
+func pdfHexString(hex []byte) string {
+	var digits []byte
+	for _, c := range hex {
+		if !isPdfSpace(c) {
+			digits = append(digits, c)
+		}
+	}
+	if len(digits)%2 == 1 {
+		digits = append(digits, '0')
+	}
+	out := make([]byte, 0, len(digits)/2)
+	for i := 0; i < len(digits); i += 2 {
+		v, err := strconv.ParseUint(string(digits[i:i+2]), 16, 8)
+		if err != nil {
+			return string(out)
+		}
+		out = append(out, byte(v))
+	}
+	return string(out)
+}
+
+func isPdfSpace(c byte) bool {
+	return c == ' ' || c == '\n' || c == '\r' || c == '\t' || c == '\f' || c == 0
+}
+
+func isPdfDelimiter(c byte) bool {
+	return strings.IndexByte("()<>[]{}/%", c) >= 0
+}
+
+func utf16BEString(s string) string {
+	if len(s)%2 != 0 {
+		return s
+	}
+	units := make([]uint16, 0, len(s)/2)
+	for i := 0; i < len(s); i += 2 {
+		units = append(units, uint16(s[i])<<8|uint16(s[i+1]))
+	}
+	return string(utf16.Decode(units))
+}
+
+func bytesToInt(s string) int {
+	n := 0
+	for i := 0; i < len(s); i++ {
+		n = n<<8 | int(s[i])
+	}
+	return n
+}
+
+func intToBytes(n int, width int) string {
+	out := make([]byte, width)
+	for i := width - 1; i >= 0; i-- {
+		out[i] = byte(n)
+		n >>= 8
+	}
+	return string(out)
+}
@@ -0,0 +1,154 @@
+package auto
+
+import (
+	"bytes"
+	"compress/zlib"
+	"fmt"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func paragraphTexts(paragraphs []Paragraph) []string {
+	texts := make([]string, 0, len(paragraphs))
+	for _, p := range paragraphs {
+		texts = append(texts, p.Text)
+	}
+	return texts
+}
+
+func TestParsePdfParagraphs(t *testing.T) {
+	tests := []struct {
+		fixture string
+		want    []string
+	}{
+		// 两种字体使用相同的编码，需按 Tf 切换映射；元数据流中的 "BT" 不是页面内容
+		{"testdata/fonts.pdf", []string{"知识", "图谱", "图谱", "Chapter 2"}},
+		// 页面树和字体在对象流中，部分文字在表单 XObject 中
+		{"testdata/objstm.pdf", []string{"栈", "队列"}},
+	}
+	for _, tt := range tests {
+		content, err := os.ReadFile(tt.fixture)
+		if err != nil {
+			t.Fatal(err)
+		}
+		paragraphs, err := parsePdfParagraphs(content)
+		if err != nil {
+			t.Errorf("%s: unexpected error: %v", tt.fixture, err)
+			continue
+		}
+		if got := paragraphTexts(paragraphs); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: paragraphs = %q, want %q", tt.fixture, got, tt.want)
+		}
+	}
+}
+
+func TestParsePdfParagraphsWithoutPageTree(t *testing.T) {
+	content := []byte("%PDF-1.4\n1 0 obj\n<< /Length 30 >>\nstream\nBT (Hello) Tj ET\nendstream\nendobj\n")
+	paragraphs, err := parsePdfParagraphs(content)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got := paragraphTexts(paragraphs); !reflect.DeepEqual(got, []string{"Hello"}) {
+		t.Errorf("paragraphs = %q", got)
+	}
+}
+
+func TestParsePdfParagraphsDecompressionLimit(t *testing.T) {
+	defer func(limit int64) { maxExtractedSize = limit }(maxExtractedSize)
+	maxExtractedSize = 1 << 10
+
+	var compressed bytes.Buffer
+	w := zlib.NewWriter(&compressed)
+	w.Write([]byte("BT (" + strings.Repeat("A", 4<<10) + ") Tj ET"))
+	w.Close()
+	var content bytes.Buffer
+	fmt.Fprintf(&content, "%%PDF-1.4\n1 0 obj\n<< /Filter /FlateDecode /Length %d >>\nstream\n", compressed.Len())
+	content.Write(compressed.Bytes())
+	content.WriteString("\nendstream\nendobj\n")
+
+	if _, err := parsePdfParagraphs(content.Bytes()); err == nil || !strings.Contains(err.Error(), "超过") {
+		t.Errorf("error = %v, want size limit error", err)
+	}
+}
+
+// 以下文件都很小，但不加限制时解析需要指数级时间或大量内存
+func TestParsePdfParagraphsMaliciousStructure(t *testing.T) {
+	ranges := strings.Repeat("<0000> <FFFF> <0041>\n", maxPdfCMapEntries/0x10000+1)
+	tests := []struct {
+		name    string
+		objects []string
+		// wantErr 为空时期望正常解析出 want
+		wantErr string
+		want    []string
+	}{
+		{name: "page tree refers to itself",
+			objects: []string{
+				"<< /Type /Catalog /Pages 2 0 R >>",
+				"<< /Type /Pages /Kids [2 0 R 2 0 R 2 0 R 2 0 R 3 0 R] >>",
+				"<< /Type /Page /Contents 4 0 R >>",
+				"<< /Length 20 >>\nstream\nBT (Hello) Tj ET\nendstream",
+			},
+			want: []string{"Hello"}},
+		{name: "form draws itself",
+			objects: []string{
+				"<< /Type /Catalog /Pages 2 0 R >>",
+				"<< /Type /Pages /Kids [3 0 R] >>",
+				"<< /Type /Page /Contents 5 0 R /Resources << /XObject << /X 4 0 R >> >> >>",
+				"<< /Type /XObject /Subtype /Form /Resources << /XObject << /X 4 0 R >> >> /Length 40 >>\nstream\nBT (Hello) Tj ET " + strings.Repeat("/X Do ", 16) + "\nendstream",
+				"<< /Length 12 >>\nstream\n/X Do /X Do\nendstream",
+			},
+			want: []string{"Hello"}},
+		{name: "too many cmap entries",
+			objects: []string{
+				"<< /Type /Catalog /Pages 2 0 R >>",
+				"<< /Type /Pages /Kids [3 0 R] >>",
+				"<< /Type /Page /Contents 4 0 R /Resources << /Font << /F1 5 0 R >> >> >>",
+				"<< /Length 20 >>\nstream\nBT /F1 12 Tf <0041> Tj ET\nendstream",
+				"<< /Type /Font /ToUnicode 6 0 R >>",
+				"<< /Length 0 >>\nstream\nbegincmap\n" + fmt.Sprintf("%d beginbfrange\n", maxPdfCMapEntries/0x10000+1) + ranges + "endbfrange\nendcmap\nendstream",
+			},
+			wantErr: "字符映射条目超过"},
+		{name: "deeply nested arrays",
+			objects: []string{
+				"<< /Length 0 >>\nstream\nBT " + strings.Repeat("[", 1<<16) + " (Hello) Tj ET\nendstream",
+			},
+			wantErr: "未找到文本内容"},
+	}
+	for _, tt := range tests {
+		var content strings.Builder
+		content.WriteString("%PDF-1.7\n")
+		for i, obj := range tt.objects {
+			fmt.Fprintf(&content, "%d 0 obj\n%s\nendobj\n", i+1, obj)
+		}
+
+		done := make(chan struct{})
+		var paragraphs []Paragraph
+		var err error
+		go func() {
+			paragraphs, err = parsePdfParagraphs([]byte(content.String()))
+			close(done)
+		}()
+		select {
+		case <-done:
+		case <-time.After(5 * time.Second):
+			t.Fatalf("%s: parsePdfParagraphs did not finish", tt.name)
+		}
+
+		if tt.wantErr != "" {
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("%s: error = %v, want %q", tt.name, err, tt.wantErr)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: unexpected error: %v", tt.name, err)
+			continue
+		}
+		if got := paragraphTexts(paragraphs); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: paragraphs = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
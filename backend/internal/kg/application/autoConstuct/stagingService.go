@@ -0,0 +1,398 @@
+package auto
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	review "github.com/RMS_V3/internal/kg/application/Review"
+	"github.com/RMS_V3/internal/kg/repository"
+	"github.com/RMS_V3/internal/kg/repository/models"
+	"github.com/RMS_V3/internal/user"
+	"github.com/RMS_V3/log"
+	"github.com/RMS_V3/pkg/response"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// ExtractToStaging 使用指定抽取器处理上传的教材，结果写入暂存区等待审核
+func ExtractToStaging(c *gin.Context) {
+	file, err := c.FormFile("file")
+	if err != nil {
+		c.JSON(400, response.Error(400, "文件上传失败"))
+		return
+	}
+	extractorName := c.DefaultPostForm("extractor", "rule")
+	extractor, err := GetExtractor(extractorName)
+	if err != nil {
+		c.JSON(400, response.Error(400, err.Error()))
+		return
+	}
+
+	paragraphs, err := parseDocument(file)
+	if err != nil {
+		c.JSON(400, response.Error(400, err.Error()))
+		return
+	}
+
+	proposal, err := extractor.Propose(c.Request.Context(), paragraphs)
+	if err != nil {
+		log.Errorf("extractor %s failed: %v", extractorName, err)
+		c.JSON(502, response.Error(502, fmt.Sprintf("知识抽取失败: %s", err.Error())))
+		return
+	}
+	if len(proposal.Nodes) == 0 {
+		c.JSON(400, response.Error(400, "未识别到任何章节或知识点"))
+		return
+	}
+
+	batch := &models.StagingBatch{
+		FileName:  file.Filename,
+		Extractor: extractor.Name(),
+		CreatedBy: user.CurrentUser(c).Id,
+		Status:    "pending",
+	}
+	items := proposalToStagingItems(proposal)
+	if err := repository.CreateStagingBatch(batch, items); err != nil {
+		log.Errorf("create staging batch failed: %v", err)
+		c.JSON(500, response.Error(500, "保存暂存结果失败"))
+		return
+	}
+
+	c.JSON(200, response.Success(gin.H{
+		"batch": batch,
+		"items": items,
+	}))
+}
+
+func proposalToStagingItems(proposal *Proposal) []models.StagingItem {
+	items := make([]models.StagingItem, 0, len(proposal.Nodes)+len(proposal.Relations))
+	for _, n := range proposal.Nodes {
+		items = append(items, models.StagingItem{
+			ItemType:    models.StagingItemNode,
+			Name:        n.Name,
+			NodeType:    n.Type,
+			Description: n.Description,
+			Confidence:  n.Confidence,
+			Status:      models.StagingPending,
+		})
+	}
+	for _, r := range proposal.Relations {
+		items = append(items, models.StagingItem{
+			ItemType:     models.StagingItemRelation,
+			RelationType: r.Type,
+			SourceType:   r.SourceType,
+			SourceName:   r.SourceName,
+			TargetType:   r.TargetType,
+			TargetName:   r.TargetName,
+			Confidence:   r.Confidence,
+			Status:       models.StagingPending,
+		})
+	}
+	return items
+}
+
+// ListStagingBatches 列出暂存批次和可用的抽取器
+func ListStagingBatches(c *gin.Context) {
+	batches, err := repository.ListStagingBatches()
+	if err != nil {
+		c.JSON(500, response.Error(500, err.Error()))
+		return
+	}
+	c.JSON(200, response.Success(gin.H{
+		"batches":    batches,
+		"extractors": ExtractorNames(),
+	}))
+}
+
+// GetStagingBatch 获取批次中的全部候选条目
+func GetStagingBatch(c *gin.Context) {
+	batchId, err := strconv.ParseInt(c.Query("batch_id"), 10, 64)
+	if err != nil {
+		c.JSON(400, response.Error(400, "无效的批次ID"))
+		return
+	}
+	batch, items, err := repository.GetStagingBatch(batchId)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(404, response.Error(404, "暂存批次不存在"))
+			return
+		}
+		c.JSON(500, response.Error(500, err.Error()))
+		return
+	}
+	c.JSON(200, response.Success(gin.H{
+		"batch": batch,
+		"items": items,
+	}))
+}
+
+// checkStagingBatchAccess 只有批次的创建人、管理员和课程负责人可以修改批次，无权限时写入响应并返回 false
+func checkStagingBatchAccess(c *gin.Context, batchId int64) bool {
+	batch, err := repository.FindStagingBatch(batchId)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(404, response.Error(404, "暂存批次不存在"))
+			return false
+		}
+		c.JSON(500, response.Error(500, err.Error()))
+		return false
+	}
+	u := user.CurrentUser(c)
+	if batch.CreatedBy == u.Id {
+		return true
+	}
+	editor, err := review.IsGraphEditor(u)
+	if err != nil {
+		c.JSON(500, response.Error(500, err.Error()))
+		return false
+	}
+	if !editor {
+		c.JSON(http.StatusForbidden, response.Error(http.StatusForbidden, "只有批次创建人、课程负责人或管理员可以修改该批次"))
+		return false
+	}
+	return true
+}
+
+type updateStagingItemRequest struct {
+	Name         *string `json:"name"`
+	NodeType     *string `json:"node_type"`
+	Description  *string `json:"description"`
+	RelationType *string `json:"relation_type"`
+	SourceType   *string `json:"source_type"`
+	SourceName   *string `json:"source_name"`
+	TargetType   *string `json:"target_type"`
+	TargetName   *string `json:"target_name"`
+	// Status 编辑后可同时接受或拒绝，为空时保持原状态
+	Status *string `json:"status" binding:"omitempty,oneof=pending accepted rejected"`
+}
+
+// UpdateStagingItem 修改候选条目，节点改名会同步到同批次引用它的关系
+func UpdateStagingItem(c *gin.Context) {
+	itemId, err := strconv.ParseInt(c.Query("item_id"), 10, 64)
+	if err != nil {
+		c.JSON(400, response.Error(400, "无效的条目ID"))
+		return
+	}
+	var req updateStagingItemRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(400, response.Error(400, "参数验证失败"))
+		return
+	}
+
+	item, err := repository.GetStagingItem(itemId)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(404, response.Error(404, "暂存条目不存在"))
+			return
+		}
+		c.JSON(500, response.Error(500, err.Error()))
+		return
+	}
+	if !checkStagingBatchAccess(c, item.BatchID) {
+		return
+	}
+	if item.Status == models.StagingCommitted {
+		c.JSON(400, response.Error(400, "条目已提交，不能修改"))
+		return
+	}
+
+	oldType, oldName := item.NodeType, item.Name
+	if err := applyStagingItemUpdate(item, &req); err != nil {
+		c.JSON(400, response.Error(400, err.Error()))
+		return
+	}
+
+	if err := repository.UpdateStagingItem(item, oldType, oldName); err != nil {
+		if errors.Is(err, repository.ErrStagingBatchClosed) {
+			c.JSON(400, response.Error(400, err.Error()))
+			return
+		}
+		c.JSON(500, response.Error(500, err.Error()))
+		return
+	}
+	c.JSON(200, response.Success(item))
+}
+
+// applyStagingItemUpdate 把请求中给出的字段写入条目并校验结果
+func applyStagingItemUpdate(item *models.StagingItem, req *updateStagingItemRequest) error {
+	setIfPresent := func(dst *string, src *string) {
+		if src != nil {
+			*dst = *src
+		}
+	}
+	if item.ItemType == models.StagingItemNode {
+		setIfPresent(&item.Name, req.Name)
+		setIfPresent(&item.NodeType, req.NodeType)
+		setIfPresent(&item.Description, req.Description)
+		if !isValidNodeType(item.NodeType) || item.Name == "" {
+			return fmt.Errorf("节点类型无效或名称为空")
+		}
+		if err := checkNodeNameLength(item.Name); err != nil {
+			return err
+		}
+	} else {
+		setIfPresent(&item.RelationType, req.RelationType)
+		setIfPresent(&item.SourceType, req.SourceType)
+		setIfPresent(&item.SourceName, req.SourceName)
+		setIfPresent(&item.TargetType, req.TargetType)
+		setIfPresent(&item.TargetName, req.TargetName)
+		if !isValidRelationType(item.RelationType) {
+			return fmt.Errorf("无效的关系类型: %s", item.RelationType)
+		}
+		if err := checkNodeNameLength(item.SourceName); err != nil {
+			return err
+		}
+		if err := checkNodeNameLength(item.TargetName); err != nil {
+			return err
+		}
+	}
+	setIfPresent(&item.Status, req.Status)
+	return nil
+}
+
+type reviewStagingItemsRequest struct {
+	BatchID int64   `json:"batch_id" binding:"required"`
+	ItemIDs []int64 `json:"item_ids" binding:"required,min=1"`
+	Status  string  `json:"status" binding:"required,oneof=pending accepted rejected"`
+}
+
+// ReviewStagingItems 接受或拒绝一个或多个候选条目
+func ReviewStagingItems(c *gin.Context) {
+	var req reviewStagingItemsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(400, response.Error(400, "参数验证失败"))
+		return
+	}
+	if !checkStagingBatchAccess(c, req.BatchID) {
+		return
+	}
+	updated, err := repository.SetStagingItemsStatus(req.BatchID, req.ItemIDs, req.Status)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(404, response.Error(404, "暂存批次不存在"))
+			return
+		}
+		if errors.Is(err, repository.ErrStagingBatchClosed) {
+			c.JSON(400, response.Error(400, err.Error()))
+			return
+		}
+		c.JSON(500, response.Error(500, err.Error()))
+		return
+	}
+	c.JSON(200, response.Success(gin.H{
+		"updated": updated,
+	}))
+}
+
+// CommitStagingBatch 将批次中已接受的条目校验后写入图谱
+func CommitStagingBatch(c *gin.Context) {
+	batchId, err := strconv.ParseInt(c.Query("batch_id"), 10, 64)
+	if err != nil {
+		c.JSON(400, response.Error(400, "无效的批次ID"))
+		return
+	}
+	items, err := repository.BeginStagingBatchCommit(batchId)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(404, response.Error(404, "暂存批次不存在"))
+			return
+		}
+		if errors.Is(err, repository.ErrStagingBatchClosed) {
+			c.JSON(400, response.Error(400, "批次不在待审核状态，不能提交"))
+			return
+		}
+		c.JSON(500, response.Error(500, err.Error()))
+		return
+	}
+	// 未写入图谱就返回时恢复为待审核，批次可以修改后重新提交
+	written := false
+	defer func() {
+		if written {
+			return
+		}
+		if err := repository.AbortStagingBatchCommit(batchId); err != nil {
+			log.Errorf("reset staging batch %d to pending failed: %v", batchId, err)
+		}
+	}()
+
+	graph, itemIds := acceptedStagingGraph(items)
+	if len(graph.Nodes) == 0 && len(graph.Relations) == 0 {
+		c.JSON(400, response.Error(400, "批次中没有已接受的条目"))
+		return
+	}
+
+	report := ValidateKnowledgeGraph(graph, neo4jNodeLookup)
+	if !report.Valid {
+		c.JSON(400, &response.Response{
+			Code:    400,
+			Message: fmt.Sprintf("知识图谱校验失败: %d 个错误, %d 个警告", len(report.Errors), len(report.Warnings)),
+			Data:    report,
+		})
+		return
+	}
+
+	if err := batchCreateNodesAndRelations(graph.Nodes, graph.Relations); err != nil {
+		log.Errorf("commit staging batch %d failed: %v", batchId, err)
+		c.JSON(500, response.Error(500, "图谱构建失败"))
+		return
+	}
+	written = true
+	if err := repository.MarkStagingBatchCommitted(batchId, itemIds); err != nil {
+		// 图谱已写入，状态更新失败只记录日志，批次停留在提交中，不会被重复提交
+		log.Errorf("mark staging batch %d committed failed: %v", batchId, err)
+	}
+
+	c.JSON(200, response.Success(gin.H{
+		"message":   "知识图谱构建成功",
+		"nodes":     len(graph.Nodes),
+		"relations": len(graph.Relations),
+		"report":    report,
+	}))
+}
+
+// acceptedStagingGraph 由已接受的条目组成待写入的图谱，同时返回这些条目的ID
+func acceptedStagingGraph(items []models.StagingItem) (*KnowledgeGraph, []int64) {
+	graph := &KnowledgeGraph{Nodes: []Node{}, Relations: []Relation{}}
+	var itemIds []int64
+	for _, item := range items {
+		if item.Status != models.StagingAccepted {
+			continue
+		}
+		itemIds = append(itemIds, item.ID)
+		if item.ItemType == models.StagingItemNode {
+			graph.Nodes = append(graph.Nodes, Node{
+				Name:        item.Name,
+				Type:        item.NodeType,
+				Description: item.Description,
+			})
+			continue
+		}
+		graph.Relations = append(graph.Relations, Relation{
+			Type:       item.RelationType,
+			SourceType: item.SourceType,
+			TargetType: item.TargetType,
+			SourceName: item.SourceName,
+			TargetName: item.TargetName,
+		})
+	}
+	return graph, itemIds
+}
+
+// DiscardStagingBatch 放弃整个暂存批次
+func DiscardStagingBatch(c *gin.Context) {
+	batchId, err := strconv.ParseInt(c.Query("batch_id"), 10, 64)
+	if err != nil {
+		c.JSON(400, response.Error(400, "无效的批次ID"))
+		return
+	}
+	if !checkStagingBatchAccess(c, batchId) {
+		return
+	}
+	if err := repository.DiscardStagingBatch(batchId); err != nil {
+		c.JSON(400, response.Error(400, err.Error()))
+		return
+	}
+	c.JSON(200, response.Success("已放弃该批次"))
+}
@@ -0,0 +1,111 @@
+package auto
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/RMS_V3/internal/kg/repository/models"
+)
+
+func strPtr(s string) *string {
+	return &s
+}
+
+func TestApplyStagingItemUpdate(t *testing.T) {
+	node := func() *models.StagingItem {
+		return &models.StagingItem{ItemType: models.StagingItemNode, Name: "栈", NodeType: "point", Status: models.StagingPending}
+	}
+	relation := func() *models.StagingItem {
+		return &models.StagingItem{ItemType: models.StagingItemRelation, RelationType: "前置",
+			SourceType: "point", SourceName: "栈", TargetType: "point", TargetName: "队列", Status: models.StagingPending}
+	}
+	tests := []struct {
+		name  string
+		item  *models.StagingItem
+		req   updateStagingItemRequest
+		valid bool
+		check func(t *testing.T, item *models.StagingItem)
+	}{
+		{name: "rename and retype node", item: node(), req: updateStagingItemRequest{Name: strPtr("2.1 栈"), NodeType: strPtr("section"), Status: strPtr(models.StagingAccepted)}, valid: true,
+			check: func(t *testing.T, item *models.StagingItem) {
+				if item.Name != "2.1 栈" || item.NodeType != "section" || item.Status != models.StagingAccepted {
+					t.Errorf("item = %+v", item)
+				}
+			}},
+		{name: "absent fields unchanged", item: node(), req: updateStagingItemRequest{Description: strPtr("后进先出")}, valid: true,
+			check: func(t *testing.T, item *models.StagingItem) {
+				if item.Name != "栈" || item.NodeType != "point" || item.Description != "后进先出" {
+					t.Errorf("item = %+v", item)
+				}
+			}},
+		{name: "empty node name", item: node(), req: updateStagingItemRequest{Name: strPtr("")}},
+		{name: "invalid node type", item: node(), req: updateStagingItemRequest{NodeType: strPtr("topic")}},
+		{name: "node name too long", item: node(), req: updateStagingItemRequest{Name: strPtr(strings.Repeat("栈", maxNodeNameLength+1))}},
+		{name: "node name at limit", item: node(), req: updateStagingItemRequest{Name: strPtr(strings.Repeat("栈", maxNodeNameLength))}, valid: true},
+		{name: "change relation type", item: relation(), req: updateStagingItemRequest{RelationType: strPtr("相关")}, valid: true,
+			check: func(t *testing.T, item *models.StagingItem) {
+				if item.RelationType != "相关" || item.SourceName != "栈" {
+					t.Errorf("item = %+v", item)
+				}
+			}},
+		{name: "invalid relation type", item: relation(), req: updateStagingItemRequest{RelationType: strPtr("属于")}},
+		{name: "relation target too long", item: relation(), req: updateStagingItemRequest{TargetName: strPtr(strings.Repeat("a", maxNodeNameLength+1))}},
+	}
+	for _, tt := range tests {
+		err := applyStagingItemUpdate(tt.item, &tt.req)
+		if (err == nil) != tt.valid {
+			t.Errorf("%s: applyStagingItemUpdate() error = %v, want valid %v", tt.name, err, tt.valid)
+			continue
+		}
+		if tt.check != nil {
+			tt.check(t, tt.item)
+		}
+	}
+}
+
+func TestAcceptedStagingGraph(t *testing.T) {
+	proposal := &Proposal{
+		Nodes: []ProposedNode{
+			{Node: Node{Name: "1.1", Type: "section"}, Confidence: 0.9},
+			{Node: Node{Name: "栈", Type: "point", Description: "后进先出"}, Confidence: 0.8},
+			{Node: Node{Name: "队列", Type: "point"}, Confidence: 0.4},
+		},
+		Relations: []ProposedRelation{
+			{Relation: Relation{Type: "包含", SourceType: "section", SourceName: "1.1", TargetType: "point", TargetName: "栈"}, Confidence: 0.9},
+			{Relation: Relation{Type: "包含", SourceType: "section", SourceName: "1.1", TargetType: "point", TargetName: "队列"}, Confidence: 0.4},
+		},
+	}
+	items := proposalToStagingItems(proposal)
+	if len(items) != 5 {
+		t.Fatalf("items = %d, want 5", len(items))
+	}
+	for i := range items {
+		items[i].ID = int64(i + 1)
+		if items[i].Status != models.StagingPending {
+			t.Errorf("item %d status = %s, want pending", i, items[i].Status)
+		}
+		// 拒绝低置信度的队列节点及其关系，其余接受
+		if items[i].Confidence >= 0.5 {
+			items[i].Status = models.StagingAccepted
+		} else {
+			items[i].Status = models.StagingRejected
+		}
+	}
+
+	graph, itemIds := acceptedStagingGraph(items)
+	if len(graph.Nodes) != 2 || len(graph.Relations) != 1 {
+		t.Fatalf("graph = %+v, want 2 nodes and 1 relation", graph)
+	}
+	if len(itemIds) != 3 {
+		t.Errorf("item ids = %v, want the 3 accepted items", itemIds)
+	}
+	if graph.Nodes[1] != (Node{Name: "栈", Type: "point", Description: "后进先出"}) {
+		t.Errorf("node = %+v", graph.Nodes[1])
+	}
+	if graph.Relations[0].TargetName != "栈" {
+		t.Errorf("relation = %+v", graph.Relations[0])
+	}
+	if report := ValidateKnowledgeGraph(graph, nil); !report.Valid {
+		t.Errorf("accepted graph should be valid, got %+v", report.Errors)
+	}
+}
@@ -8,7 +8,10 @@ import (
 	"strings"
 
 	"github.com/RMS_V3/config"
+	progress "github.com/RMS_V3/internal/kg/application/Progress"
 	"github.com/RMS_V3/internal/kg/repository"
+	"github.com/RMS_V3/internal/kg/repository/models"
+	"github.com/RMS_V3/internal/user"
 	"github.com/RMS_V3/log"
 	"github.com/RMS_V3/middleware/minioStore"
 	"github.com/RMS_V3/middleware/neo4jUtils"
@@ -178,7 +181,8 @@ func saveLinkToDB(knowledgePointId int64, url, title, resourceType string, descr
 	switch resourceType {
 	case "video":
 		// 调用 AddVideo 函数保存视频链接
-		err := repository.AddVideo(knowledgePointId, title, url, "", description)
+		// 外部链接无法读取时长，观看进度以播放器第一次上报的时长为准
+		err := repository.AddVideo(knowledgePointId, title, url, "", description, 0)
 		if err != nil {
 			log.Errorf("Failed to save video link to DB: %v", err)
 			return err
@@ -358,8 +362,14 @@ func uploadVideo(pointId int64, videoPath string, title string, description *str
 		picToDelete = picFilename
 	}
 
+	// 读取视频时长，观看进度按该时长计算覆盖率；读取失败时退回到播放器上报的时长
+	duration, err := utils.GetVideoDuration(videoPath)
+	if err != nil {
+		log.Errorf("GetVideoDuration err: %v", zap.Error(err))
+	}
+
 	// 将视频信息保存到数据库
-	err = repository.AddVideo(pointId, title, videoUrl, picUrl, description)
+	err = repository.AddVideo(pointId, title, videoUrl, picUrl, description, duration)
 	if err != nil {
 		log.Errorf("InsertVideo err: %v", zap.Error(err))
 		cleanup() // 发生错误时调用清理函数
@@ -508,6 +518,12 @@ func getKnowledgePointIdByName(ctx *gin.Context, name string) (int64, error) {
 	return ids[0], nil
 }
 
+// VideoView 知识点的视频，学生登录时附带观看进度和续播位置
+type VideoView struct {
+	models.Video
+	Watch *progress.VideoState `json:"watch,omitempty"`
+}
+
 func GetPointVideo(c *gin.Context) {
 	pointId, err := strconv.Atoi(c.Query("pointId"))
 	if err != nil {
@@ -521,7 +537,20 @@ func GetPointVideo(c *gin.Context) {
 		return
 	}
 
-	c.JSON(200, response.Success(resources))
+	progress.RecordViewFromRequest(c, int64(pointId))
+	ids := make([]int64, len(resources))
+	for i, v := range resources {
+		ids[i] = v.ID
+	}
+	states := progress.VideoStatesFromRequest(c, ids)
+	views := make([]VideoView, len(resources))
+	for i, v := range resources {
+		views[i] = VideoView{Video: v}
+		if state, ok := states[v.ID]; ok {
+			views[i].Watch = &state
+		}
+	}
+	c.JSON(200, response.Success(views))
 }
 func GetPointExercise(c *gin.Context) {
 	pointId, err := strconv.Atoi(c.Query("pointId"))
@@ -537,6 +566,7 @@ func GetPointExercise(c *gin.Context) {
 	}
 	// log.Infof("exercises: ", resources)
 
+	progress.RecordViewFromRequest(c, int64(pointId))
 	c.JSON(200, response.Success(resources))
 }
 func GetPointCourseware(c *gin.Context) {
@@ -552,6 +582,7 @@ func GetPointCourseware(c *gin.Context) {
 		return
 	}
 
+	progress.RecordViewFromRequest(c, int64(pointId))
 	c.JSON(200, response.Success(resources))
 }
 func DeletePointVideo(c *gin.Context) {
@@ -609,3 +640,36 @@ func DeletePointCourseware(c *gin.Context) {
 	}
 	c.JSON(200, response.Success("删除成功"))
 }
+
+type ResourceFeedbackRequest struct {
+	ResourceType string `json:"resource_type" binding:"required,oneof=video courseware exercise"`
+	ResourceID   int64  `json:"resource_id" binding:"required"`
+	Rating       *int   `json:"rating" binding:"omitempty,min=1,max=5"`
+	Completed    bool   `json:"completed"`
+}
+
+// SubmitResourceFeedback 学生对资源评分或标记完成，用于学习计划中的资源排序
+func SubmitResourceFeedback(c *gin.Context) {
+	u := user.CurrentUser(c)
+	var req ResourceFeedbackRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(400, response.Error(400, "参数验证失败"))
+		return
+	}
+	if req.Rating == nil && !req.Completed {
+		c.JSON(400, response.Error(400, "评分和完成情况至少提供一项"))
+		return
+	}
+	if err := repository.SaveResourceFeedback(u.Id, req.ResourceType, req.ResourceID, req.Rating, req.Completed); err != nil {
+		log.Errorf("save resource feedback failed: %v", err)
+		c.JSON(500, response.Error(500, "保存反馈失败"))
+		return
+	}
+	if req.Completed {
+		// 完成资源后重新评估所属知识点的学习进度
+		if _, err := progress.RecordEvent(u.Id, progress.EventView, req.ResourceType, req.ResourceID); err != nil {
+			log.Errorf("update progress after feedback failed: %v", err)
+		}
+	}
+	c.JSON(200, response.Success(nil))
+}
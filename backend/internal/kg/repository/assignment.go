@@ -0,0 +1,84 @@
+package repository
+
+import (
+	"time"
+
+	"github.com/RMS_V3/internal/kg/repository/models"
+	"github.com/RMS_V3/middleware/db"
+)
+
+func CreateAssignment(assignment *models.Assignment) error {
+	return db.GetDB().Create(assignment).Error
+}
+
+// UpdateAssignment 修改作业的标题、说明和开放、截止时间，作业内容布置后不能修改
+func UpdateAssignment(assignment *models.Assignment) error {
+	return db.GetDB().Model(assignment).
+		Select("title", "description", "open_at", "due_at").
+		Updates(assignment).Error
+}
+
+func DeleteAssignment(id int64) error {
+	return db.GetDB().Delete(&models.Assignment{}, id).Error
+}
+
+func GetAssignment(id int64) (*models.Assignment, error) {
+	var assignment models.Assignment
+	if err := db.GetDB().First(&assignment, id).Error; err != nil {
+		return nil, err
+	}
+	return &assignment, nil
+}
+
+// ListAssignments 列出学生组的作业，openBefore 不为零时只列出在该时间之前已开放的作业；按截止时间排序
+func ListAssignments(groupIds []int64, openBefore time.Time) ([]models.Assignment, error) {
+	var assignments []models.Assignment
+	if len(groupIds) == 0 {
+		return assignments, nil
+	}
+	query := db.GetDB().Where("group_id IN ?", groupIds)
+	if !openBefore.IsZero() {
+		query = query.Where("open_at <= ?", openBefore)
+	}
+	err := query.Order("due_at, id").Find(&assignments).Error
+	return assignments, err
+}
+
+// GetUsersProgress 读取多名学生在指定知识点上的学习进度，按学生分组
+func GetUsersProgress(userIds []string, pointIds []int64) (map[string]map[int64]models.LearnerProgress, error) {
+	progress := make(map[string]map[int64]models.LearnerProgress)
+	if len(userIds) == 0 || len(pointIds) == 0 {
+		return progress, nil
+	}
+	var rows []models.LearnerProgress
+	err := db.GetDB().Where("user_id IN ? AND knowledge_point_id IN ?", userIds, pointIds).Find(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+	for _, row := range rows {
+		if progress[row.UserID] == nil {
+			progress[row.UserID] = make(map[int64]models.LearnerProgress)
+		}
+		progress[row.UserID][row.KnowledgePointID] = row
+	}
+	return progress, nil
+}
+
+// GetFirstQuizSubmissions 读取多名学生在 since 之后对测验的第一次提交
+func GetFirstQuizSubmissions(quizId int64, userIds []string, since time.Time) (map[string]models.QuizSubmission, error) {
+	submissions := make(map[string]models.QuizSubmission)
+	if len(userIds) == 0 {
+		return submissions, nil
+	}
+	var rows []models.QuizSubmission
+	err := db.GetDB().Where("quiz_id = ? AND user_id IN ? AND created_at >= ?", quizId, userIds, since).Order("id").Find(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+	for _, row := range rows {
+		if _, ok := submissions[row.UserID]; !ok {
+			submissions[row.UserID] = row
+		}
+	}
+	return submissions, nil
+}
@@ -0,0 +1,92 @@
+package repository
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/RMS_V3/internal/kg/repository/models"
+	"github.com/RMS_V3/middleware/db"
+)
+
+func CreateChangeRequest(req *models.ChangeRequest) error {
+	return db.GetDB().Create(req).Error
+}
+
+func GetChangeRequest(id int64) (*models.ChangeRequest, error) {
+	var req models.ChangeRequest
+	if err := db.GetDB().First(&req, id).Error; err != nil {
+		return nil, err
+	}
+	return &req, nil
+}
+
+// ListChangeRequests 按状态和提交人筛选修改申请，参数为空表示不筛选
+func ListChangeRequests(status string, submitter string) ([]models.ChangeRequest, error) {
+	query := db.GetDB().Order("id DESC")
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+	if submitter != "" {
+		query = query.Where("submitter = ?", submitter)
+	}
+	var reqs []models.ChangeRequest
+	err := query.Find(&reqs).Error
+	return reqs, err
+}
+
+// ReviewChangeRequest 记录审核结果，只有待审核的申请可以被审核
+func ReviewChangeRequest(id int64, status string, reviewer string, feedback string) error {
+	now := time.Now()
+	updates := map[string]interface{}{
+		"status":      status,
+		"reviewer":    reviewer,
+		"reviewed_at": now,
+	}
+	if feedback != "" {
+		updates["feedback"] = feedback
+	}
+	result := db.GetDB().Model(&models.ChangeRequest{}).
+		Where("id = ? AND status = ?", id, models.ChangeRequestPending).
+		Updates(updates)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return fmt.Errorf("修改申请 %d 不存在或已被审核", id)
+	}
+	return nil
+}
+
+// ReopenChangeRequest 把已批准但执行失败的申请恢复为待审核
+func ReopenChangeRequest(id int64) error {
+	return db.GetDB().Model(&models.ChangeRequest{}).
+		Where("id = ? AND status = ?", id, models.ChangeRequestApproved).
+		Updates(map[string]interface{}{"status": models.ChangeRequestPending, "reviewer": nil, "reviewed_at": nil}).Error
+}
+
+func IsCourseOwner(userId string) (bool, error) {
+	var count int64
+	err := db.GetDB().Model(&models.CourseOwner{}).Where("user_id = ?", userId).Count(&count).Error
+	return count > 0, err
+}
+
+func ListCourseOwners() ([]models.CourseOwner, error) {
+	var owners []models.CourseOwner
+	err := db.GetDB().Order("user_id").Find(&owners).Error
+	return owners, err
+}
+
+func AddCourseOwner(userId string) error {
+	return db.GetDB().Create(&models.CourseOwner{UserID: userId}).Error
+}
+
+func RemoveCourseOwner(userId string) error {
+	result := db.GetDB().Where("user_id = ?", userId).Delete(&models.CourseOwner{})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return fmt.Errorf("用户 %s 不是课程负责人", userId)
+	}
+	return nil
+}
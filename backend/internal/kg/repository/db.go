@@ -8,7 +8,7 @@ import (
 	"github.com/RMS_V3/middleware/db"
 )
 
-func AddVideo(pointId int64, title string, videoUrl string, coverUrl string, description *string) error {
+func AddVideo(pointId int64, title string, videoUrl string, coverUrl string, description *string, duration float64) error {
 	db := db.GetDB()
 	video := models.Video{
 		Title:            title,
@@ -16,6 +16,7 @@ func AddVideo(pointId int64, title string, videoUrl string, coverUrl string, des
 		CoverURL:         coverUrl,
 		Description:      description,
 		KnowledgePointID: pointId,
+		Duration:         duration,
 	}
 	return db.Create(&video).Error
 }
@@ -145,3 +146,88 @@ func getCoursewareByPointId(pointId int64) ([]models.Courseware, error) {
 	}
 	return coursewares, nil
 }
+
+// CountExerciseDifficulty 统计每个知识点下各难度习题的数量，结果为 知识点ID -> 难度 -> 数量
+func CountExerciseDifficulty(pointIds []int64) (map[int64]map[string]int64, error) {
+	counts := make(map[int64]map[string]int64)
+	if len(pointIds) == 0 {
+		return counts, nil
+	}
+	var rows []struct {
+		KnowledgePointID int64
+		Difficulty       string
+		Count            int64
+	}
+	err := db.GetDB().Model(&models.Exercise{}).
+		Select("knowledge_point_id, difficulty, count(*) AS count").
+		Where("knowledge_point_id IN ?", pointIds).
+		Group("knowledge_point_id, difficulty").
+		Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+	for _, row := range rows {
+		if counts[row.KnowledgePointID] == nil {
+			counts[row.KnowledgePointID] = make(map[string]int64)
+		}
+		counts[row.KnowledgePointID][row.Difficulty] = row.Count
+	}
+	return counts, nil
+}
+
+// PointsWithResources 返回至少关联了一个视频、习题或课件的知识点
+func PointsWithResources() (map[int64]bool, error) {
+	points := make(map[int64]bool)
+	for _, model := range []interface{}{&models.Video{}, &models.Exercise{}, &models.Courseware{}} {
+		var ids []int64
+		if err := db.GetDB().Model(model).Distinct().Pluck("knowledge_point_id", &ids).Error; err != nil {
+			return nil, err
+		}
+		for _, id := range ids {
+			points[id] = true
+		}
+	}
+	return points, nil
+}
+
+// ResourceCount 知识点关联的各类资源数量
+type ResourceCount struct {
+	Videos      int64 `json:"videos"`
+	Exercises   int64 `json:"exercises"`
+	Coursewares int64 `json:"coursewares"`
+}
+
+// CountResourcesByPoint 统计每个知识点关联的视频、习题和课件数量
+func CountResourcesByPoint(pointIds []int64) (map[int64]ResourceCount, error) {
+	counts := make(map[int64]ResourceCount)
+	if len(pointIds) == 0 {
+		return counts, nil
+	}
+	for _, model := range []interface{}{&models.Video{}, &models.Exercise{}, &models.Courseware{}} {
+		var rows []struct {
+			KnowledgePointID int64
+			Count            int64
+		}
+		err := db.GetDB().Model(model).
+			Select("knowledge_point_id, count(*) AS count").
+			Where("knowledge_point_id IN ?", pointIds).
+			Group("knowledge_point_id").
+			Scan(&rows).Error
+		if err != nil {
+			return nil, err
+		}
+		for _, row := range rows {
+			c := counts[row.KnowledgePointID]
+			switch model.(type) {
+			case *models.Video:
+				c.Videos = row.Count
+			case *models.Exercise:
+				c.Exercises = row.Count
+			case *models.Courseware:
+				c.Coursewares = row.Count
+			}
+			counts[row.KnowledgePointID] = c
+		}
+	}
+	return counts, nil
+}
@@ -0,0 +1,287 @@
+package repository
+
+import (
+	"github.com/RMS_V3/internal/kg/repository/models"
+	"github.com/RMS_V3/middleware/db"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+func replaceItemPoints(tx *gorm.DB, itemId int64, pointIds []int64) error {
+	if err := tx.Where("item_id = ?", itemId).Delete(&models.ExerciseItemPoint{}).Error; err != nil {
+		return err
+	}
+	return addItemPoints(tx, itemId, pointIds)
+}
+
+func addItemPoints(tx *gorm.DB, itemId int64, pointIds []int64) error {
+	if len(pointIds) == 0 {
+		return nil
+	}
+	links := make([]models.ExerciseItemPoint, len(pointIds))
+	for i, id := range pointIds {
+		links[i] = models.ExerciseItemPoint{ItemID: itemId, KnowledgePointID: id}
+	}
+	return tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&links).Error
+}
+
+func replaceItemTags(tx *gorm.DB, itemId int64, tags []string) error {
+	if err := tx.Where("item_id = ?", itemId).Delete(&models.ExerciseItemTag{}).Error; err != nil {
+		return err
+	}
+	if len(tags) == 0 {
+		return nil
+	}
+	rows := make([]models.ExerciseItemTag, len(tags))
+	for i, tag := range tags {
+		rows[i] = models.ExerciseItemTag{ItemID: itemId, Tag: tag}
+	}
+	return tx.Create(&rows).Error
+}
+
+func saveItemVersion(tx *gorm.DB, item *models.ExerciseItem, editor string) error {
+	return tx.Create(&models.ExerciseItemVersion{
+		ItemID:      item.ID,
+		Version:     item.Version,
+		Type:        item.Type,
+		Stem:        item.Stem,
+		Options:     item.Options,
+		Answer:      item.Answer,
+		Explanation: item.Explanation,
+		Difficulty:  item.Difficulty,
+		BloomLevel:  item.BloomLevel,
+		EditedBy:    editor,
+	}).Error
+}
+
+// CreateExerciseItem 创建习题、关联知识点和标签，并保存第一个版本
+func CreateExerciseItem(item *models.ExerciseItem, pointIds []int64, tags []string) error {
+	return db.GetDB().Transaction(func(tx *gorm.DB) error {
+		item.Version = 1
+		if err := tx.Create(item).Error; err != nil {
+			return err
+		}
+		if err := replaceItemPoints(tx, item.ID, pointIds); err != nil {
+			return err
+		}
+		if err := replaceItemTags(tx, item.ID, tags); err != nil {
+			return err
+		}
+		return saveItemVersion(tx, item, item.CreatedBy)
+	})
+}
+
+// UpdateExerciseItem 修改习题内容，替换关联的知识点和标签，并保存为新版本
+func UpdateExerciseItem(item *models.ExerciseItem, pointIds []int64, tags []string, editor string) error {
+	return db.GetDB().Transaction(func(tx *gorm.DB) error {
+		var current models.ExerciseItem
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&current, item.ID).Error; err != nil {
+			return err
+		}
+		item.Version = current.Version + 1
+		err := tx.Model(item).
+			Select("type", "stem", "options", "answer", "explanation", "difficulty", "bloom_level", "version").
+			Updates(item).Error
+		if err != nil {
+			return err
+		}
+		if err := replaceItemPoints(tx, item.ID, pointIds); err != nil {
+			return err
+		}
+		if err := replaceItemTags(tx, item.ID, tags); err != nil {
+			return err
+		}
+		return saveItemVersion(tx, item, editor)
+	})
+}
+
+// DeleteExerciseItem 删除习题及其知识点关联和标签，版本快照和作答记录保留
+func DeleteExerciseItem(id int64) error {
+	return db.GetDB().Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("item_id = ?", id).Delete(&models.ExerciseItemPoint{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("item_id = ?", id).Delete(&models.ExerciseItemTag{}).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&models.ExerciseItem{}, id).Error
+	})
+}
+
+// LinkItemPoints 把题库中的习题关联到更多知识点，已有的关联忽略
+func LinkItemPoints(itemId int64, pointIds []int64) error {
+	return addItemPoints(db.GetDB(), itemId, pointIds)
+}
+
+// UnlinkItemPoints 取消习题与知识点的关联，习题仍保留在题库中
+func UnlinkItemPoints(itemId int64, pointIds []int64) error {
+	return db.GetDB().Where("item_id = ? AND knowledge_point_id IN ?", itemId, pointIds).
+		Delete(&models.ExerciseItemPoint{}).Error
+}
+
+func GetExerciseItem(id int64) (*models.ExerciseItem, error) {
+	var item models.ExerciseItem
+	if err := db.GetDB().First(&item, id).Error; err != nil {
+		return nil, err
+	}
+	return &item, nil
+}
+
+// GetExerciseItemVersion 读取习题的某个版本
+func GetExerciseItemVersion(itemId int64, version int) (*models.ExerciseItemVersion, error) {
+	var v models.ExerciseItemVersion
+	if err := db.GetDB().Where("item_id = ? AND version = ?", itemId, version).First(&v).Error; err != nil {
+		return nil, err
+	}
+	return &v, nil
+}
+
+// ListExerciseItemVersions 列出习题的全部版本，新版本在前
+func ListExerciseItemVersions(itemId int64) ([]models.ExerciseItemVersion, error) {
+	var versions []models.ExerciseItemVersion
+	err := db.GetDB().Where("item_id = ?", itemId).Order("version DESC").Find(&versions).Error
+	return versions, err
+}
+
+// GetItemPoints 读取多道习题关联的知识点
+func GetItemPoints(itemIds []int64) (map[int64][]int64, error) {
+	points := make(map[int64][]int64)
+	if len(itemIds) == 0 {
+		return points, nil
+	}
+	var links []models.ExerciseItemPoint
+	err := db.GetDB().Where("item_id IN ?", itemIds).Order("item_id, knowledge_point_id").Find(&links).Error
+	if err != nil {
+		return nil, err
+	}
+	for _, link := range links {
+		points[link.ItemID] = append(points[link.ItemID], link.KnowledgePointID)
+	}
+	return points, nil
+}
+
+// GetItemTags 读取多道习题的标签
+func GetItemTags(itemIds []int64) (map[int64][]string, error) {
+	tags := make(map[int64][]string)
+	if len(itemIds) == 0 {
+		return tags, nil
+	}
+	var rows []models.ExerciseItemTag
+	err := db.GetDB().Where("item_id IN ?", itemIds).Order("item_id, tag").Find(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+	for _, row := range rows {
+		tags[row.ItemID] = append(tags[row.ItemID], row.Tag)
+	}
+	return tags, nil
+}
+
+// ItemFilter 题库检索条件，零值表示不筛选；Tags 需要全部命中
+type ItemFilter struct {
+	Keyword    string
+	Type       string
+	Difficulty string
+	BloomLevel string
+	Author     string
+	PointID    int64
+	Tags       []string
+}
+
+// SearchExerciseItems 按条件分页检索题库，返回当前页的习题和总数
+func SearchExerciseItems(filter ItemFilter, page int, pageSize int) ([]models.ExerciseItem, int64, error) {
+	conn := db.GetDB()
+	query := conn.Model(&models.ExerciseItem{})
+	if filter.Keyword != "" {
+		query = query.Where("stem LIKE ?", "%"+escapeLike(filter.Keyword)+"%")
+	}
+	if filter.Type != "" {
+		query = query.Where("type = ?", filter.Type)
+	}
+	if filter.Difficulty != "" {
+		query = query.Where("difficulty = ?", filter.Difficulty)
+	}
+	if filter.BloomLevel != "" {
+		query = query.Where("bloom_level = ?", filter.BloomLevel)
+	}
+	if filter.Author != "" {
+		query = query.Where("created_by = ?", filter.Author)
+	}
+	if filter.PointID != 0 {
+		query = query.Where("id IN (?)", conn.Model(&models.ExerciseItemPoint{}).
+			Select("item_id").Where("knowledge_point_id = ?", filter.PointID))
+	}
+	if len(filter.Tags) > 0 {
+		query = query.Where("id IN (?)", conn.Model(&models.ExerciseItemTag{}).
+			Select("item_id").Where("tag IN ?", filter.Tags).
+			Group("item_id").Having("COUNT(DISTINCT tag) = ?", len(filter.Tags)))
+	}
+	var total int64
+	if err := query.Session(&gorm.Session{}).Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	var items []models.ExerciseItem
+	err := query.Order("id DESC").Offset((page - 1) * pageSize).Limit(pageSize).Find(&items).Error
+	return items, total, err
+}
+
+// escapeLike 转义 LIKE 模式中的通配符
+func escapeLike(s string) string {
+	var out []rune
+	for _, r := range s {
+		if r == '%' || r == '_' || r == '\\' {
+			out = append(out, '\\')
+		}
+		out = append(out, r)
+	}
+	return string(out)
+}
+
+// ListExerciseItemsByPoint 列出关联到知识点的习题
+func ListExerciseItemsByPoint(pointId int64) ([]models.ExerciseItem, error) {
+	var items []models.ExerciseItem
+	err := db.GetDB().
+		Where("id IN (?)", db.GetDB().Model(&models.ExerciseItemPoint{}).Select("item_id").Where("knowledge_point_id = ?", pointId)).
+		Order("id").
+		Find(&items).Error
+	return items, err
+}
+
+// CreateExerciseAttempt 保存作答记录，学生该版本的习题还没有计分作答时记为计分作答。
+// 查询和写入在锁定习题行后进行，同一学生并发提交时只有一次计分
+func CreateExerciseAttempt(attempt *models.ExerciseAttempt) error {
+	return db.GetDB().Transaction(func(tx *gorm.DB) error {
+		var item models.ExerciseItem
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Select("id").First(&item, attempt.ItemID).Error; err != nil {
+			return err
+		}
+		var previous int64
+		err := tx.Model(&models.ExerciseAttempt{}).
+			Where("user_id = ? AND item_id = ? AND item_version = ? AND scored = ?", attempt.UserID, attempt.ItemID, attempt.ItemVersion, true).
+			Count(&previous).Error
+		if err != nil {
+			return err
+		}
+		attempt.Scored = previous == 0
+		return tx.Create(attempt).Error
+	})
+}
+
+func GetExerciseAttempt(id int64) (*models.ExerciseAttempt, error) {
+	var attempt models.ExerciseAttempt
+	if err := db.GetDB().First(&attempt, id).Error; err != nil {
+		return nil, err
+	}
+	return &attempt, nil
+}
+
+// ListExerciseAttempts 列出学生的作答记录，itemId 为 0 时不按习题筛选
+func ListExerciseAttempts(userId string, itemId int64) ([]models.ExerciseAttempt, error) {
+	query := db.GetDB().Where("user_id = ?", userId).Order("id DESC")
+	if itemId != 0 {
+		query = query.Where("item_id = ?", itemId)
+	}
+	var attempts []models.ExerciseAttempt
+	err := query.Find(&attempts).Error
+	return attempts, err
+}
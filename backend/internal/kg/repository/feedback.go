@@ -0,0 +1,88 @@
+package repository
+
+import (
+	"github.com/RMS_V3/internal/kg/repository/models"
+	"github.com/RMS_V3/middleware/db"
+	"gorm.io/gorm/clause"
+)
+
+// SaveResourceFeedback 记录学生对资源的评分或完成情况，rating 为空时保留原评分，completed 只会由 false 变为 true
+func SaveResourceFeedback(userId string, resourceType string, resourceId int64, rating *int, completed bool) error {
+	feedback := models.ResourceFeedback{
+		UserID:       userId,
+		ResourceType: resourceType,
+		ResourceID:   resourceId,
+		Rating:       rating,
+		Completed:    completed,
+	}
+	updates := []string{"updated_at"}
+	if rating != nil {
+		updates = append(updates, "rating")
+	}
+	if completed {
+		updates = append(updates, "completed")
+	}
+	return db.GetDB().Clauses(clause.OnConflict{
+		DoUpdates: clause.AssignmentColumns(updates),
+	}).Create(&feedback).Error
+}
+
+// ResourceStats 资源的评分和完成统计
+type ResourceStats struct {
+	AvgRating   float64 `json:"avg_rating"`
+	Ratings     int64   `json:"ratings"`
+	Completions int64   `json:"completions"`
+	Learners    int64   `json:"learners"`
+}
+
+// GetResourceStats 统计指定类型资源的评分和完成情况
+func GetResourceStats(resourceType string, resourceIds []int64) (map[int64]ResourceStats, error) {
+	stats := make(map[int64]ResourceStats)
+	if len(resourceIds) == 0 {
+		return stats, nil
+	}
+	var rows []struct {
+		ResourceID  int64
+		AvgRating   *float64
+		Ratings     int64
+		Completions int64
+		Learners    int64
+	}
+	err := db.GetDB().Model(&models.ResourceFeedback{}).
+		Select("resource_id, AVG(rating) AS avg_rating, COUNT(rating) AS ratings, "+
+			"SUM(CASE WHEN completed THEN 1 ELSE 0 END) AS completions, COUNT(*) AS learners").
+		Where("resource_type = ? AND resource_id IN ?", resourceType, resourceIds).
+		Group("resource_id").
+		Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+	for _, row := range rows {
+		s := ResourceStats{Ratings: row.Ratings, Completions: row.Completions, Learners: row.Learners}
+		if row.AvgRating != nil {
+			s.AvgRating = *row.AvgRating
+		}
+		stats[row.ResourceID] = s
+	}
+	return stats, nil
+}
+
+// GetResourcesByPoints 读取多个知识点关联的视频、习题和课件
+func GetResourcesByPoints(pointIds []int64) ([]models.Video, []models.Exercise, []models.Courseware, error) {
+	var videos []models.Video
+	var exercises []models.Exercise
+	var coursewares []models.Courseware
+	if len(pointIds) == 0 {
+		return videos, exercises, coursewares, nil
+	}
+	if err := db.GetDB().Where("knowledge_point_id IN ?", pointIds).Find(&videos).Error; err != nil {
+		return nil, nil, nil, err
+	}
+	if err := db.GetDB().Where("knowledge_point_id IN ?", pointIds).Find(&exercises).Error; err != nil {
+		return nil, nil, nil, err
+	}
+	if err := db.GetDB().Where("knowledge_point_id IN ?", pointIds).Find(&coursewares).Error; err != nil {
+		return nil, nil, nil, err
+	}
+	return videos, exercises, coursewares, nil
+}
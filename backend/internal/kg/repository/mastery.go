@@ -0,0 +1,105 @@
+package repository
+
+import (
+	"time"
+
+	"github.com/RMS_V3/internal/kg/repository/models"
+	"github.com/RMS_V3/middleware/db"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// GetLearnerMastery 读取学生对指定知识点的掌握度，没有记录的知识点不出现在结果中
+func GetLearnerMastery(userId string, pointIds []int64) (map[int64]float64, error) {
+	mastery := make(map[int64]float64)
+	if len(pointIds) == 0 {
+		return mastery, nil
+	}
+	var rows []models.LearnerMastery
+	err := db.GetDB().Where("user_id = ? AND knowledge_point_id IN ?", userId, pointIds).Find(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+	for _, row := range rows {
+		mastery[row.KnowledgePointID] = row.Mastery
+	}
+	return mastery, nil
+}
+
+// UpdateLearnerMastery 在事务中读取并更新学生对多个知识点的掌握度，
+// update 收到的 current 中没有记录的知识点不出现，返回值中的知识点会被写入
+func UpdateLearnerMastery(userId string, pointIds []int64, update func(current map[int64]float64) map[int64]float64) error {
+	return db.GetDB().Transaction(func(tx *gorm.DB) error {
+		var rows []models.LearnerMastery
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("user_id = ? AND knowledge_point_id IN ?", userId, pointIds).
+			Find(&rows).Error
+		if err != nil {
+			return err
+		}
+		current := make(map[int64]float64, len(rows))
+		for _, row := range rows {
+			current[row.KnowledgePointID] = row.Mastery
+		}
+		updated := update(current)
+		if len(updated) == 0 {
+			return nil
+		}
+		now := time.Now()
+		records := make([]models.LearnerMastery, 0, len(updated))
+		for id, m := range updated {
+			records = append(records, models.LearnerMastery{UserID: userId, KnowledgePointID: id, Mastery: m, UpdatedAt: now})
+		}
+		return tx.Clauses(clause.OnConflict{
+			DoUpdates: clause.AssignmentColumns([]string{"mastery", "updated_at"}),
+		}).Create(&records).Error
+	})
+}
+
+// GetBKTParams 读取知识点单独设置的 BKT 参数
+func GetBKTParams(pointIds []int64) (map[int64]models.BKTParams, error) {
+	params := make(map[int64]models.BKTParams)
+	if len(pointIds) == 0 {
+		return params, nil
+	}
+	var rows []models.BKTParams
+	if err := db.GetDB().Where("knowledge_point_id IN ?", pointIds).Find(&rows).Error; err != nil {
+		return nil, err
+	}
+	for _, row := range rows {
+		params[row.KnowledgePointID] = row
+	}
+	return params, nil
+}
+
+// SaveBKTParams 设置知识点的 BKT 参数
+func SaveBKTParams(params *models.BKTParams) error {
+	return db.GetDB().Clauses(clause.OnConflict{
+		DoUpdates: clause.AssignmentColumns([]string{"p_init", "p_transit", "p_slip", "p_guess", "updated_at"}),
+	}).Create(params).Error
+}
+
+// DeleteBKTParams 删除知识点单独设置的 BKT 参数，恢复使用默认值
+func DeleteBKTParams(pointId int64) error {
+	return db.GetDB().Where("knowledge_point_id = ?", pointId).Delete(&models.BKTParams{}).Error
+}
+
+// GetUsersMastery 读取多名学生对指定知识点的掌握度，按学生分组，没有记录的知识点不出现在结果中
+func GetUsersMastery(userIds []string, pointIds []int64) (map[string]map[int64]float64, error) {
+	mastery := make(map[string]map[int64]float64)
+	if len(userIds) == 0 || len(pointIds) == 0 {
+		return mastery, nil
+	}
+	var rows []models.LearnerMastery
+	err := db.GetDB().Where("user_id IN ? AND knowledge_point_id IN ?", userIds, pointIds).Find(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+	for _, row := range rows {
+		if mastery[row.UserID] == nil {
+			mastery[row.UserID] = make(map[int64]float64)
+		}
+		mastery[row.UserID][row.KnowledgePointID] = row.Mastery
+	}
+	return mastery, nil
+}
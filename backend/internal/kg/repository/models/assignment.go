@@ -0,0 +1,27 @@
+package models
+
+import "time"
+
+// 作业类型
+const (
+	AssignmentPoints = "points" // 学习一组知识点
+	AssignmentPath   = "path"   // 沿学习路径学到目标知识点
+	AssignmentQuiz   = "quiz"   // 完成一次测验
+)
+
+// Assignment 教师布置给学生组的作业。PointIDs 为 JSON 编码的知识点列表，学习路径类作业在布置时
+// 展开为目标知识点及其全部前置知识点；TargetID 为学习路径的目标知识点或测验ID
+type Assignment struct {
+	ID          int64     `gorm:"primaryKey;autoIncrement" json:"id"`
+	GroupID     int64     `gorm:"not null;index" json:"group_id"`
+	Title       string    `gorm:"size:100;not null" json:"title"`
+	Description string    `gorm:"type:text" json:"description"`
+	Kind        string    `gorm:"type:enum('points', 'path', 'quiz');not null" json:"kind"`
+	TargetID    int64     `gorm:"not null;default:0" json:"target_id"`
+	PointIDs    string    `gorm:"type:text;not null" json:"-"`
+	OpenAt      time.Time `gorm:"not null" json:"open_at"`
+	DueAt       time.Time `gorm:"not null" json:"due_at"`
+	CreatedBy   string    `gorm:"size:32;not null" json:"created_by"`
+	CreatedAt   time.Time `gorm:"default:CURRENT_TIMESTAMP" json:"created_at"`
+	UpdatedAt   time.Time `gorm:"default:CURRENT_TIMESTAMP" json:"updated_at"`
+}
@@ -0,0 +1,17 @@
+package models
+
+import "time"
+
+// BKTParams 单个知识点的贝叶斯知识追踪参数，没有记录的知识点使用配置中的默认值
+type BKTParams struct {
+	KnowledgePointID int64     `gorm:"primaryKey;autoIncrement:false" json:"point_id"`
+	PInit            float64   `gorm:"not null" json:"p_init"`
+	PTransit         float64   `gorm:"not null" json:"p_transit"`
+	PSlip            float64   `gorm:"not null" json:"p_slip"`
+	PGuess           float64   `gorm:"not null" json:"p_guess"`
+	UpdatedAt        time.Time `gorm:"default:CURRENT_TIMESTAMP" json:"updated_at"`
+}
+
+func (BKTParams) TableName() string {
+	return "bkt_params"
+}
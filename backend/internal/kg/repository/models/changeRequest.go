@@ -0,0 +1,30 @@
+package models
+
+import "time"
+
+// 修改申请状态
+const (
+	ChangeRequestPending  = "pending"
+	ChangeRequestApproved = "approved"
+	ChangeRequestRejected = "rejected"
+)
+
+// ChangeRequest 非课程负责人提交的图谱修改申请，Operations 为 JSON 编码的操作列表
+type ChangeRequest struct {
+	ID         int64      `gorm:"primaryKey;autoIncrement" json:"id"`
+	Submitter  string     `gorm:"size:32;index;not null" json:"submitter"`
+	Comment    string     `gorm:"type:text" json:"comment"`
+	Operations string     `gorm:"type:mediumtext;not null" json:"-"`
+	Status     string     `gorm:"type:enum('pending', 'approved', 'rejected');not null;default:pending;index" json:"status"`
+	Reviewer   *string    `gorm:"size:32" json:"reviewer,omitempty"`
+	Feedback   *string    `gorm:"type:text" json:"feedback,omitempty"`
+	ReviewedAt *time.Time `json:"reviewed_at,omitempty"`
+	CreatedAt  time.Time  `gorm:"default:CURRENT_TIMESTAMP" json:"created_at"`
+	UpdatedAt  time.Time  `gorm:"default:CURRENT_TIMESTAMP" json:"updated_at"`
+}
+
+// CourseOwner 课程负责人，可以直接编辑图谱并审核修改申请
+type CourseOwner struct {
+	UserID    string    `gorm:"primaryKey;size:32" json:"user_id"`
+	CreatedAt time.Time `gorm:"default:CURRENT_TIMESTAMP" json:"created_at"`
+}
@@ -0,0 +1,74 @@
+package models
+
+import "time"
+
+// 结构化习题题型
+const (
+	ItemSingleChoice   = "single_choice"
+	ItemMultipleChoice = "multiple_choice"
+	ItemTrueFalse      = "true_false"
+	ItemFillBlank      = "fill_blank"
+	ItemNumeric        = "numeric"
+)
+
+// 布鲁姆认知层次
+var BloomLevels = []string{"remember", "understand", "apply", "analyze", "evaluate", "create"}
+
+// ExerciseItem 题库中可自动判分的结构化习题，Options 和 Answer 为 JSON 编码，Answer 不直接返回给学生；
+// 每次修改 Version 加一，并在 ExerciseItemVersion 中保存快照
+type ExerciseItem struct {
+	ID          int64     `gorm:"primaryKey;autoIncrement" json:"id"`
+	Type        string    `gorm:"type:enum('single_choice', 'multiple_choice', 'true_false', 'fill_blank', 'numeric');not null" json:"type"`
+	Stem        string    `gorm:"type:text;not null" json:"stem"`
+	Options     string    `gorm:"type:text" json:"-"`
+	Answer      string    `gorm:"type:text;not null" json:"-"`
+	Explanation string    `gorm:"type:text" json:"explanation"`
+	Difficulty  string    `gorm:"type:enum('easy', 'medium', 'hard');not null;default:medium" json:"difficulty"`
+	BloomLevel  string    `gorm:"type:enum('remember', 'understand', 'apply', 'analyze', 'evaluate', 'create');not null;default:understand" json:"bloom_level"`
+	Version     int       `gorm:"not null;default:1" json:"version"`
+	CreatedBy   string    `gorm:"size:32;not null;index" json:"created_by"`
+	CreatedAt   time.Time `gorm:"default:CURRENT_TIMESTAMP" json:"created_at"`
+	UpdatedAt   time.Time `gorm:"default:CURRENT_TIMESTAMP" json:"updated_at"`
+}
+
+// ExerciseItemVersion 习题某个版本的快照，作答记录按版本回看题目和答案
+type ExerciseItemVersion struct {
+	ItemID      int64     `gorm:"primaryKey;autoIncrement:false" json:"item_id"`
+	Version     int       `gorm:"primaryKey;autoIncrement:false" json:"version"`
+	Type        string    `gorm:"type:enum('single_choice', 'multiple_choice', 'true_false', 'fill_blank', 'numeric');not null" json:"type"`
+	Stem        string    `gorm:"type:text;not null" json:"stem"`
+	Options     string    `gorm:"type:text" json:"-"`
+	Answer      string    `gorm:"type:text;not null" json:"-"`
+	Explanation string    `gorm:"type:text" json:"explanation"`
+	Difficulty  string    `gorm:"type:enum('easy', 'medium', 'hard');not null" json:"difficulty"`
+	BloomLevel  string    `gorm:"type:enum('remember', 'understand', 'apply', 'analyze', 'evaluate', 'create');not null" json:"bloom_level"`
+	EditedBy    string    `gorm:"size:32;not null" json:"edited_by"`
+	CreatedAt   time.Time `gorm:"default:CURRENT_TIMESTAMP" json:"created_at"`
+}
+
+// ExerciseItemTag 习题标签
+type ExerciseItemTag struct {
+	ItemID int64  `gorm:"primaryKey;autoIncrement:false" json:"item_id"`
+	Tag    string `gorm:"primaryKey;size:32;index" json:"tag"`
+}
+
+// ExerciseItemPoint 习题与知识点的多对多关联
+type ExerciseItemPoint struct {
+	ItemID           int64 `gorm:"primaryKey;autoIncrement:false" json:"item_id"`
+	KnowledgePointID int64 `gorm:"primaryKey;autoIncrement:false;index" json:"knowledge_point_id"`
+}
+
+// ExerciseAttempt 学生的一次作答，Response 为 JSON 编码的作答内容
+type ExerciseAttempt struct {
+	ID     int64  `gorm:"primaryKey;autoIncrement" json:"id"`
+	UserID string `gorm:"size:32;not null;index:idx_user_item" json:"user_id"`
+	ItemID int64  `gorm:"not null;index:idx_user_item" json:"item_id"`
+	// ItemVersion 作答时习题的版本
+	ItemVersion int     `gorm:"not null;default:1" json:"item_version"`
+	Response    string  `gorm:"type:text;not null" json:"-"`
+	Correct     bool    `gorm:"not null" json:"correct"`
+	Score       float64 `gorm:"not null" json:"score"`
+	// Scored 是否计入掌握度和学习进度，每个学生对同一版本习题只有第一次作答计入
+	Scored    bool      `gorm:"not null;default:false" json:"scored"`
+	CreatedAt time.Time `gorm:"default:CURRENT_TIMESTAMP" json:"created_at"`
+}
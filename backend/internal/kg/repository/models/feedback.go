@@ -0,0 +1,14 @@
+package models
+
+import "time"
+
+// ResourceFeedback 学生对学习资源的评分和完成情况，每个学生对每个资源一条记录
+type ResourceFeedback struct {
+	UserID       string    `gorm:"primaryKey;size:32" json:"user_id"`
+	ResourceType string    `gorm:"primaryKey;type:enum('video', 'exercise', 'courseware')" json:"resource_type"`
+	ResourceID   int64     `gorm:"primaryKey" json:"resource_id"`
+	Rating       *int      `json:"rating,omitempty"` // 1-5 分，未评分时为空
+	Completed    bool      `gorm:"not null;default:false" json:"completed"`
+	CreatedAt    time.Time `gorm:"default:CURRENT_TIMESTAMP" json:"created_at"`
+	UpdatedAt    time.Time `gorm:"default:CURRENT_TIMESTAMP" json:"updated_at"`
+}
@@ -0,0 +1,11 @@
+package models
+
+import "time"
+
+// LearnerMastery 学生对知识点的掌握度，取值 0-1
+type LearnerMastery struct {
+	UserID           string    `gorm:"primaryKey;size:32" json:"user_id"`
+	KnowledgePointID int64     `gorm:"primaryKey" json:"knowledge_point_id"`
+	Mastery          float64   `gorm:"not null;default:0" json:"mastery"`
+	UpdatedAt        time.Time `gorm:"default:CURRENT_TIMESTAMP" json:"updated_at"`
+}
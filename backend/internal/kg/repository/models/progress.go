@@ -0,0 +1,19 @@
+package models
+
+import "time"
+
+const (
+	ProgressNotStarted = "not_started"
+	ProgressInProgress = "in_progress"
+	ProgressCompleted  = "completed"
+)
+
+// LearnerProgress 学生在知识点上的学习进度，没有记录的知识点视为未开始
+type LearnerProgress struct {
+	UserID           string     `gorm:"primaryKey;size:32" json:"user_id"`
+	KnowledgePointID int64      `gorm:"primaryKey" json:"knowledge_point_id"`
+	Status           string     `gorm:"type:enum('not_started', 'in_progress', 'completed');not null;default:not_started" json:"status"`
+	StartedAt        *time.Time `json:"started_at,omitempty"`
+	CompletedAt      *time.Time `json:"completed_at,omitempty"`
+	LastActivityAt   time.Time  `gorm:"default:CURRENT_TIMESTAMP" json:"last_activity_at"`
+}
@@ -0,0 +1,31 @@
+package models
+
+import "time"
+
+// Quiz 按蓝图从题库组成的测验，Blueprint 和 Questions 为 JSON 编码；
+// Questions 记录每道题的版本，题目修改后测验内容不变
+type Quiz struct {
+	ID               int64     `gorm:"primaryKey;autoIncrement" json:"id"`
+	CreatedBy        string    `gorm:"size:32;not null;index" json:"created_by"`
+	ScopeType        string    `gorm:"type:enum('chapter', 'section');not null" json:"scope_type"`
+	ScopeID          int64     `gorm:"not null" json:"scope_id"`
+	Seed             int64     `gorm:"not null" json:"seed"`
+	Blueprint        string    `gorm:"type:text;not null" json:"-"`
+	Questions        string    `gorm:"type:mediumtext;not null" json:"-"`
+	TimeLimit        int       `gorm:"not null;default:0" json:"time_limit"` // 分钟，0 表示不限时
+	EstimatedMinutes float64   `gorm:"not null" json:"estimated_minutes"`
+	CreatedAt        time.Time `gorm:"default:CURRENT_TIMESTAMP" json:"created_at"`
+}
+
+// QuizSubmission 学生提交的一次测验，Results 为 JSON 编码的逐题结果
+type QuizSubmission struct {
+	ID       int64   `gorm:"primaryKey;autoIncrement" json:"id"`
+	QuizID   int64   `gorm:"not null;index" json:"quiz_id"`
+	UserID   string  `gorm:"size:32;not null;index" json:"user_id"`
+	Score    float64 `gorm:"not null" json:"score"`
+	MaxScore float64 `gorm:"not null" json:"max_score"`
+	Results  string  `gorm:"type:mediumtext;not null" json:"-"`
+	// Scored 是否计入掌握度和学习进度，只有学生对测验的第一次提交计分，之后的提交作为练习
+	Scored    bool      `gorm:"not null;default:false" json:"scored"`
+	CreatedAt time.Time `gorm:"default:CURRENT_TIMESTAMP" json:"created_at"`
+}
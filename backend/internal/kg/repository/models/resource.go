@@ -9,6 +9,7 @@ type Video struct {
 	CoverURL         string    `gorm:"size:255;not null" json:"cover_url"`
 	Description      *string   `gorm:"type:text" json:"description,omitempty"`
 	KnowledgePointID int64     `gorm:"index;not null" json:"knowledge_point_id"`
+	Duration         float64   `gorm:"not null;default:0" json:"duration"` // 上传时读取的视频时长（秒），外部链接为 0
 	CreatedAt        time.Time `gorm:"default:CURRENT_TIMESTAMP" json:"created_at"`
 	UpdatedAt        time.Time `gorm:"default:CURRENT_TIMESTAMP" json:"updated_at"`
 }
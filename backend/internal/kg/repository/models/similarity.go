@@ -0,0 +1,14 @@
+package models
+
+import "time"
+
+// ResourceSimilarity 离线计算的资源相似度，每个资源只保存最相似的若干个
+type ResourceSimilarity struct {
+	ResourceType string    `gorm:"primaryKey;type:enum('video', 'exercise', 'courseware')" json:"resource_type"`
+	ResourceID   int64     `gorm:"primaryKey" json:"resource_id"`
+	SimilarType  string    `gorm:"primaryKey;type:enum('video', 'exercise', 'courseware')" json:"similar_type"`
+	SimilarID    int64     `gorm:"primaryKey" json:"similar_id"`
+	Score        float64   `gorm:"not null" json:"score"`
+	CommonUsers  int       `gorm:"not null" json:"common_users"`
+	ComputedAt   time.Time `gorm:"not null" json:"computed_at"`
+}
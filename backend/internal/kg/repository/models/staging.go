@@ -0,0 +1,50 @@
+package models
+
+import "time"
+
+// 暂存区条目状态
+const (
+	StagingPending   = "pending"
+	StagingAccepted  = "accepted"
+	StagingRejected  = "rejected"
+	StagingCommitted = "committed"
+)
+
+// 暂存区条目类型
+const (
+	StagingItemNode     = "node"
+	StagingItemRelation = "relation"
+)
+
+// StagingBatch 一次抽取产生的候选结果
+type StagingBatch struct {
+	ID        int64     `gorm:"primaryKey;autoIncrement" json:"id"`
+	FileName  string    `gorm:"size:255;not null" json:"file_name"`
+	Extractor string    `gorm:"size:32;not null" json:"extractor"`
+	CreatedBy string    `gorm:"size:32;index;not null" json:"created_by"`
+	Status    string    `gorm:"type:enum('pending', 'committing', 'committed', 'discarded');not null;default:pending" json:"status"`
+	CreatedAt time.Time `gorm:"default:CURRENT_TIMESTAMP" json:"created_at"`
+	UpdatedAt time.Time `gorm:"default:CURRENT_TIMESTAMP" json:"updated_at"`
+}
+
+// StagingItem 候选的节点或关系，教师审核后才会写入图谱
+type StagingItem struct {
+	ID       int64  `gorm:"primaryKey;autoIncrement" json:"id"`
+	BatchID  int64  `gorm:"index;not null" json:"batch_id"`
+	ItemType string `gorm:"type:enum('node', 'relation');not null" json:"item_type"`
+	// 节点字段
+	Name        string `gorm:"size:100" json:"name,omitempty"`
+	NodeType    string `gorm:"size:16" json:"node_type,omitempty"`
+	Description string `gorm:"type:text" json:"description,omitempty"`
+	// 关系字段
+	RelationType string `gorm:"size:16" json:"relation_type,omitempty"`
+	SourceType   string `gorm:"size:16" json:"source_type,omitempty"`
+	SourceName   string `gorm:"size:100" json:"source_name,omitempty"`
+	TargetType   string `gorm:"size:16" json:"target_type,omitempty"`
+	TargetName   string `gorm:"size:100" json:"target_name,omitempty"`
+
+	Confidence float64   `gorm:"not null;default:0" json:"confidence"`
+	Status     string    `gorm:"type:enum('pending', 'accepted', 'rejected', 'committed');not null;default:pending" json:"status"`
+	CreatedAt  time.Time `gorm:"default:CURRENT_TIMESTAMP" json:"created_at"`
+	UpdatedAt  time.Time `gorm:"default:CURRENT_TIMESTAMP" json:"updated_at"`
+}
@@ -0,0 +1,16 @@
+package models
+
+import "time"
+
+// VideoWatch 学生观看视频的情况，Intervals 为 JSON 编码的已观看区间（秒），按起点排序且互不重叠
+type VideoWatch struct {
+	UserID         string     `gorm:"primaryKey;size:32" json:"user_id"`
+	VideoID        int64      `gorm:"primaryKey" json:"video_id"`
+	Duration       float64    `gorm:"not null" json:"duration"` // 计算覆盖率使用的视频时长（秒）
+	Intervals      string     `gorm:"type:text;not null" json:"-"`
+	WatchedSeconds float64    `gorm:"not null;default:0" json:"watched_seconds"`
+	LastPosition   float64    `gorm:"not null;default:0" json:"last_position"`
+	Completed      bool       `gorm:"not null;default:false" json:"completed"`
+	CompletedAt    *time.Time `json:"completed_at,omitempty"`
+	UpdatedAt      time.Time  `gorm:"default:CURRENT_TIMESTAMP" json:"updated_at"`
+}
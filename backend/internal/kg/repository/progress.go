@@ -0,0 +1,120 @@
+package repository
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/RMS_V3/internal/kg/repository/models"
+	"github.com/RMS_V3/middleware/db"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// ResourcePointID 查询资源所属的知识点
+func ResourcePointID(resourceType string, resourceId int64) (int64, error) {
+	var model interface{}
+	switch resourceType {
+	case "video":
+		model = &models.Video{}
+	case "exercise":
+		model = &models.Exercise{}
+	case "courseware":
+		model = &models.Courseware{}
+	default:
+		return 0, fmt.Errorf("unsupported resource type: %s", resourceType)
+	}
+	var pointId int64
+	err := db.GetDB().Model(model).Where("id = ?", resourceId).Pluck("knowledge_point_id", &pointId).Error
+	if err != nil {
+		return 0, err
+	}
+	if pointId == 0 {
+		return 0, gorm.ErrRecordNotFound
+	}
+	return pointId, nil
+}
+
+// MarkPointInProgress 记录学生开始学习知识点，已完成的知识点只更新最近学习时间
+func MarkPointInProgress(userId string, pointId int64, at time.Time) error {
+	progress := models.LearnerProgress{
+		UserID:           userId,
+		KnowledgePointID: pointId,
+		Status:           models.ProgressInProgress,
+		StartedAt:        &at,
+		LastActivityAt:   at,
+	}
+	return db.GetDB().Clauses(clause.OnConflict{
+		DoUpdates: clause.Assignments(map[string]interface{}{
+			"status":           gorm.Expr("IF(status = ?, status, ?)", models.ProgressCompleted, models.ProgressInProgress),
+			"started_at":       gorm.Expr("COALESCE(started_at, ?)", at),
+			"last_activity_at": at,
+		}),
+	}).Create(&progress).Error
+}
+
+// MarkPointCompleted 记录学生完成知识点，重复完成时保留第一次的完成时间
+func MarkPointCompleted(userId string, pointId int64, at time.Time) error {
+	progress := models.LearnerProgress{
+		UserID:           userId,
+		KnowledgePointID: pointId,
+		Status:           models.ProgressCompleted,
+		StartedAt:        &at,
+		CompletedAt:      &at,
+		LastActivityAt:   at,
+	}
+	return db.GetDB().Clauses(clause.OnConflict{
+		DoUpdates: clause.Assignments(map[string]interface{}{
+			"status":           models.ProgressCompleted,
+			"started_at":       gorm.Expr("COALESCE(started_at, ?)", at),
+			"completed_at":     gorm.Expr("COALESCE(completed_at, ?)", at),
+			"last_activity_at": at,
+		}),
+	}).Create(&progress).Error
+}
+
+// GetLearnerProgress 读取学生全部知识点的学习进度
+func GetLearnerProgress(userId string) (map[int64]models.LearnerProgress, error) {
+	var rows []models.LearnerProgress
+	if err := db.GetDB().Where("user_id = ?", userId).Find(&rows).Error; err != nil {
+		return nil, err
+	}
+	progress := make(map[int64]models.LearnerProgress, len(rows))
+	for _, row := range rows {
+		progress[row.KnowledgePointID] = row
+	}
+	return progress, nil
+}
+
+// PointCompletion 学生在一个知识点上完成视频和提交习题的情况
+type PointCompletion struct {
+	Videos             int64
+	FinishedVideos     int64
+	Exercises          int64
+	SubmittedExercises int64
+}
+
+// GetPointCompletion 统计知识点的视频、题库习题数量以及学生已完成的数量。
+// 看完的视频以观看记录为准，作答过的习题以作答记录为准，不使用学生可以自行设置的资源反馈
+func GetPointCompletion(userId string, pointId int64) (PointCompletion, error) {
+	var pc PointCompletion
+	conn := db.GetDB()
+	videoIds := conn.Model(&models.Video{}).Select("id").Where("knowledge_point_id = ?", pointId)
+	itemIds := conn.Model(&models.ExerciseItemPoint{}).Select("item_id").Where("knowledge_point_id = ?", pointId)
+	if err := conn.Model(&models.Video{}).Where("knowledge_point_id = ?", pointId).Count(&pc.Videos).Error; err != nil {
+		return pc, err
+	}
+	if err := conn.Model(&models.ExerciseItemPoint{}).Where("knowledge_point_id = ?", pointId).Count(&pc.Exercises).Error; err != nil {
+		return pc, err
+	}
+	err := conn.Model(&models.VideoWatch{}).
+		Where("user_id = ? AND completed AND video_id IN (?)", userId, videoIds).
+		Count(&pc.FinishedVideos).Error
+	if err != nil {
+		return pc, err
+	}
+	err = conn.Model(&models.ExerciseAttempt{}).
+		Where("user_id = ? AND item_id IN (?)", userId, itemIds).
+		Distinct("item_id").
+		Count(&pc.SubmittedExercises).Error
+	return pc, err
+}
@@ -0,0 +1,103 @@
+package repository
+
+import (
+	"github.com/RMS_V3/internal/kg/repository/models"
+	"github.com/RMS_V3/middleware/db"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// ListExerciseItemsForPoints 读取关联到任一知识点的习题，以及每道题关联到其中哪些知识点
+func ListExerciseItemsForPoints(pointIds []int64) ([]models.ExerciseItem, map[int64][]int64, error) {
+	links := make(map[int64][]int64)
+	if len(pointIds) == 0 {
+		return nil, links, nil
+	}
+	var rows []models.ExerciseItemPoint
+	err := db.GetDB().Where("knowledge_point_id IN ?", pointIds).Order("item_id, knowledge_point_id").Find(&rows).Error
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(rows) == 0 {
+		return nil, links, nil
+	}
+	itemIds := make([]int64, 0, len(rows))
+	for _, row := range rows {
+		if _, ok := links[row.ItemID]; !ok {
+			itemIds = append(itemIds, row.ItemID)
+		}
+		links[row.ItemID] = append(links[row.ItemID], row.KnowledgePointID)
+	}
+	var items []models.ExerciseItem
+	if err := db.GetDB().Where("id IN ?", itemIds).Order("id").Find(&items).Error; err != nil {
+		return nil, nil, err
+	}
+	return items, links, nil
+}
+
+// GetExerciseItemVersions 批量读取习题的指定版本，keys 为 {习题ID, 版本} 对
+func GetExerciseItemVersions(keys [][2]int64) (map[[2]int64]models.ExerciseItemVersion, error) {
+	versions := make(map[[2]int64]models.ExerciseItemVersion, len(keys))
+	if len(keys) == 0 {
+		return versions, nil
+	}
+	pairs := make([][]interface{}, len(keys))
+	for i, k := range keys {
+		pairs[i] = []interface{}{k[0], k[1]}
+	}
+	var rows []models.ExerciseItemVersion
+	if err := db.GetDB().Where("(item_id, version) IN ?", pairs).Find(&rows).Error; err != nil {
+		return nil, err
+	}
+	for _, row := range rows {
+		versions[[2]int64{row.ItemID, int64(row.Version)}] = row
+	}
+	return versions, nil
+}
+
+func CreateQuiz(quiz *models.Quiz) error {
+	return db.GetDB().Create(quiz).Error
+}
+
+func GetQuiz(id int64) (*models.Quiz, error) {
+	var quiz models.Quiz
+	if err := db.GetDB().First(&quiz, id).Error; err != nil {
+		return nil, err
+	}
+	return &quiz, nil
+}
+
+// CreateQuizSubmission 在一个事务中保存测验提交和逐题的作答记录
+func CreateQuizSubmission(submission *models.QuizSubmission, attempts []models.ExerciseAttempt) error {
+	return db.GetDB().Transaction(func(tx *gorm.DB) error {
+		var quiz models.Quiz
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Select("id").First(&quiz, submission.QuizID).Error; err != nil {
+			return err
+		}
+		var previous int64
+		err := tx.Model(&models.QuizSubmission{}).
+			Where("quiz_id = ? AND user_id = ?", submission.QuizID, submission.UserID).
+			Count(&previous).Error
+		if err != nil {
+			return err
+		}
+		submission.Scored = previous == 0
+		for i := range attempts {
+			attempts[i].Scored = submission.Scored
+		}
+		if err := tx.Create(submission).Error; err != nil {
+			return err
+		}
+		if len(attempts) == 0 {
+			return nil
+		}
+		return tx.Create(&attempts).Error
+	})
+}
+
+// ListQuizSubmissions 列出学生对测验的提交，新提交在前
+func ListQuizSubmissions(quizId int64, userId string) ([]models.QuizSubmission, error) {
+	var submissions []models.QuizSubmission
+	err := db.GetDB().Where("quiz_id = ? AND user_id = ?", quizId, userId).Order("id DESC").Find(&submissions).Error
+	return submissions, err
+}
@@ -0,0 +1,92 @@
+package repository
+
+import (
+	"github.com/RMS_V3/internal/kg/repository/models"
+	"github.com/RMS_V3/middleware/db"
+	"gorm.io/gorm"
+)
+
+// ListResourceFeedback 读取全部学生对资源的评分和完成记录
+func ListResourceFeedback() ([]models.ResourceFeedback, error) {
+	var rows []models.ResourceFeedback
+	err := db.GetDB().Find(&rows).Error
+	return rows, err
+}
+
+// ListVideoWatches 读取全部学生观看视频的记录
+func ListVideoWatches() ([]models.VideoWatch, error) {
+	var rows []models.VideoWatch
+	err := db.GetDB().Select("user_id", "video_id", "duration", "watched_seconds").Find(&rows).Error
+	return rows, err
+}
+
+// ReplaceResourceSimilarities 在一个事务中用新计算的结果替换全部资源相似度
+func ReplaceResourceSimilarities(rows []models.ResourceSimilarity) error {
+	return db.GetDB().Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("1 = 1").Delete(&models.ResourceSimilarity{}).Error; err != nil {
+			return err
+		}
+		if len(rows) == 0 {
+			return nil
+		}
+		return tx.CreateInBatches(&rows, 500).Error
+	})
+}
+
+// ResourceKey 资源的类型和ID
+type ResourceKey struct {
+	Type string `json:"type"`
+	ID   int64  `json:"id"`
+}
+
+// GetSimilarResources 读取多个资源的相似资源
+func GetSimilarResources(keys []ResourceKey) ([]models.ResourceSimilarity, error) {
+	var rows []models.ResourceSimilarity
+	if len(keys) == 0 {
+		return rows, nil
+	}
+	pairs := make([][]interface{}, len(keys))
+	for i, k := range keys {
+		pairs[i] = []interface{}{k.Type, k.ID}
+	}
+	err := db.GetDB().Where("(resource_type, resource_id) IN ?", pairs).Find(&rows).Error
+	return rows, err
+}
+
+// GetResourcesByIds 按ID读取视频、习题和课件
+func GetResourcesByIds(videoIds, exerciseIds, coursewareIds []int64) ([]models.Video, []models.Exercise, []models.Courseware, error) {
+	var videos []models.Video
+	var exercises []models.Exercise
+	var coursewares []models.Courseware
+	if len(videoIds) > 0 {
+		if err := db.GetDB().Where("id IN ?", videoIds).Find(&videos).Error; err != nil {
+			return nil, nil, nil, err
+		}
+	}
+	if len(exerciseIds) > 0 {
+		if err := db.GetDB().Where("id IN ?", exerciseIds).Find(&exercises).Error; err != nil {
+			return nil, nil, nil, err
+		}
+	}
+	if len(coursewareIds) > 0 {
+		if err := db.GetDB().Where("id IN ?", coursewareIds).Find(&coursewares).Error; err != nil {
+			return nil, nil, nil, err
+		}
+	}
+	return videos, exercises, coursewares, nil
+}
+
+// ListUserFeedback 读取学生对资源的全部评分和完成记录
+func ListUserFeedback(userId string) ([]models.ResourceFeedback, error) {
+	var rows []models.ResourceFeedback
+	err := db.GetDB().Where("user_id = ?", userId).Find(&rows).Error
+	return rows, err
+}
+
+// ListUserVideoWatches 读取学生观看视频的全部记录
+func ListUserVideoWatches(userId string) ([]models.VideoWatch, error) {
+	var rows []models.VideoWatch
+	err := db.GetDB().Select("user_id", "video_id", "duration", "watched_seconds").
+		Where("user_id = ?", userId).Find(&rows).Error
+	return rows, err
+}
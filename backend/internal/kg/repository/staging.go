@@ -0,0 +1,184 @@
+package repository
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/RMS_V3/internal/kg/repository/models"
+	"github.com/RMS_V3/middleware/db"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// ErrStagingBatchClosed 批次已提交或已放弃，其中的条目不能再修改
+var ErrStagingBatchClosed = errors.New("暂存批次已提交或已放弃，不能修改")
+
+// lockPendingStagingBatch 锁定待审核的批次，与提交、放弃批次的操作串行执行
+func lockPendingStagingBatch(tx *gorm.DB, batchId int64) error {
+	var batch models.StagingBatch
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&batch, batchId).Error; err != nil {
+		return err
+	}
+	if batch.Status != "pending" {
+		return ErrStagingBatchClosed
+	}
+	return nil
+}
+
+// CreateStagingBatch 保存一次抽取的批次及其全部候选条目
+func CreateStagingBatch(batch *models.StagingBatch, items []models.StagingItem) error {
+	return db.GetDB().Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(batch).Error; err != nil {
+			return err
+		}
+		if len(items) == 0 {
+			return nil
+		}
+		for i := range items {
+			items[i].BatchID = batch.ID
+		}
+		return tx.CreateInBatches(items, 200).Error
+	})
+}
+
+func ListStagingBatches() ([]models.StagingBatch, error) {
+	var batches []models.StagingBatch
+	err := db.GetDB().Order("id DESC").Find(&batches).Error
+	return batches, err
+}
+
+func GetStagingBatch(batchId int64) (*models.StagingBatch, []models.StagingItem, error) {
+	var batch models.StagingBatch
+	if err := db.GetDB().First(&batch, batchId).Error; err != nil {
+		return nil, nil, err
+	}
+	var items []models.StagingItem
+	if err := db.GetDB().Where("batch_id = ?", batchId).Order("id").Find(&items).Error; err != nil {
+		return nil, nil, err
+	}
+	return &batch, items, nil
+}
+
+// FindStagingBatch 只读取批次本身，不含条目
+func FindStagingBatch(batchId int64) (*models.StagingBatch, error) {
+	var batch models.StagingBatch
+	if err := db.GetDB().First(&batch, batchId).Error; err != nil {
+		return nil, err
+	}
+	return &batch, nil
+}
+
+func GetStagingItem(itemId int64) (*models.StagingItem, error) {
+	var item models.StagingItem
+	if err := db.GetDB().First(&item, itemId).Error; err != nil {
+		return nil, err
+	}
+	return &item, nil
+}
+
+// UpdateStagingItem 保存教师对条目的修改。节点改名或修改类型时，同批次中引用该节点的关系一并更新
+func UpdateStagingItem(item *models.StagingItem, oldType, oldName string) error {
+	return db.GetDB().Transaction(func(tx *gorm.DB) error {
+		if err := lockPendingStagingBatch(tx, item.BatchID); err != nil {
+			return err
+		}
+		if err := tx.Save(item).Error; err != nil {
+			return err
+		}
+		if item.ItemType != models.StagingItemNode || (oldType == item.NodeType && oldName == item.Name) {
+			return nil
+		}
+		// 关系按修改前的类型和名称匹配
+		if err := tx.Model(&models.StagingItem{}).
+			Where("batch_id = ? AND item_type = ? AND source_type = ? AND source_name = ?", item.BatchID, models.StagingItemRelation, oldType, oldName).
+			Updates(map[string]interface{}{"source_type": item.NodeType, "source_name": item.Name}).Error; err != nil {
+			return err
+		}
+		return tx.Model(&models.StagingItem{}).
+			Where("batch_id = ? AND item_type = ? AND target_type = ? AND target_name = ?", item.BatchID, models.StagingItemRelation, oldType, oldName).
+			Updates(map[string]interface{}{"target_type": item.NodeType, "target_name": item.Name}).Error
+	})
+}
+
+// SetStagingItemsStatus 批量设置条目的审核状态，批次必须仍在待审核状态，已提交的条目不再修改
+func SetStagingItemsStatus(batchId int64, itemIds []int64, status string) (int64, error) {
+	var updated int64
+	err := db.GetDB().Transaction(func(tx *gorm.DB) error {
+		if err := lockPendingStagingBatch(tx, batchId); err != nil {
+			return err
+		}
+		result := tx.Model(&models.StagingItem{}).
+			Where("batch_id = ? AND id IN ? AND status <> ?", batchId, itemIds, models.StagingCommitted).
+			Update("status", status)
+		updated = result.RowsAffected
+		return result.Error
+	})
+	return updated, err
+}
+
+// BeginStagingBatchCommit 将待审核的批次置为提交中并读取其条目。状态按条件更新，
+// 同一批次只有一个提交能进入写入图谱的阶段，提交中的批次不能再修改或放弃
+func BeginStagingBatchCommit(batchId int64) ([]models.StagingItem, error) {
+	result := db.GetDB().Model(&models.StagingBatch{}).
+		Where("id = ? AND status = ?", batchId, "pending").
+		Update("status", "committing")
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	if result.RowsAffected == 0 {
+		var batch models.StagingBatch
+		if err := db.GetDB().Select("id").First(&batch, batchId).Error; err != nil {
+			return nil, err
+		}
+		return nil, ErrStagingBatchClosed
+	}
+	var items []models.StagingItem
+	if err := db.GetDB().Where("batch_id = ?", batchId).Order("id").Find(&items).Error; err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+// AbortStagingBatchCommit 提交未写入图谱时将批次恢复为待审核
+func AbortStagingBatchCommit(batchId int64) error {
+	return db.GetDB().Model(&models.StagingBatch{}).
+		Where("id = ? AND status = ?", batchId, "committing").
+		Update("status", "pending").Error
+}
+
+// MarkStagingBatchCommitted 将已写入图谱的条目和提交中的批次标记为已提交
+func MarkStagingBatchCommitted(batchId int64, itemIds []int64) error {
+	return db.GetDB().Transaction(func(tx *gorm.DB) error {
+		if len(itemIds) > 0 {
+			if err := tx.Model(&models.StagingItem{}).
+				Where("batch_id = ? AND id IN ?", batchId, itemIds).
+				Update("status", models.StagingCommitted).Error; err != nil {
+				return err
+			}
+		}
+		result := tx.Model(&models.StagingBatch{}).
+			Where("id = ? AND status = ?", batchId, "committing").
+			Update("status", "committed")
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return fmt.Errorf("未找到提交中的暂存批次: %d", batchId)
+		}
+		return nil
+	})
+}
+
+// DiscardStagingBatch 放弃整个批次
+func DiscardStagingBatch(batchId int64) error {
+	result := db.GetDB().Model(&models.StagingBatch{}).
+		Where("id = ? AND status = ?", batchId, "pending").
+		Update("status", "discarded")
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return fmt.Errorf("未找到待审核的暂存批次: %d", batchId)
+	}
+	return nil
+}
@@ -0,0 +1,57 @@
+package repository
+
+import (
+	"errors"
+
+	"github.com/RMS_V3/internal/kg/repository/models"
+	"github.com/RMS_V3/middleware/db"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// UpdateVideoWatch 在事务中读取并更新学生观看视频的记录，没有记录时 update 收到的 watch 只有主键
+func UpdateVideoWatch(userId string, videoId int64, update func(watch *models.VideoWatch) error) (*models.VideoWatch, error) {
+	watch := &models.VideoWatch{UserID: userId, VideoID: videoId}
+	err := db.GetDB().Transaction(func(tx *gorm.DB) error {
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("user_id = ? AND video_id = ?", userId, videoId).
+			First(watch).Error
+		if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+			return err
+		}
+		if err := update(watch); err != nil {
+			return err
+		}
+		return tx.Clauses(clause.OnConflict{UpdateAll: true}).Create(watch).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return watch, nil
+}
+
+// GetVideoWatches 读取学生观看多个视频的记录，没有观看过的视频不出现在结果中
+func GetVideoWatches(userId string, videoIds []int64) (map[int64]models.VideoWatch, error) {
+	watches := make(map[int64]models.VideoWatch)
+	if len(videoIds) == 0 {
+		return watches, nil
+	}
+	var rows []models.VideoWatch
+	err := db.GetDB().Where("user_id = ? AND video_id IN ?", userId, videoIds).Find(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+	for _, row := range rows {
+		watches[row.VideoID] = row
+	}
+	return watches, nil
+}
+
+// GetVideoDuration 读取视频记录中的时长，时长未知时为 0
+func GetVideoDuration(videoId int64) (float64, error) {
+	var video models.Video
+	if err := db.GetDB().Select("id", "duration").First(&video, videoId).Error; err != nil {
+		return 0, err
+	}
+	return video.Duration, nil
+}
@@ -0,0 +1,60 @@
+package user
+
+import (
+	"net/http"
+
+	"github.com/RMS_V3/pkg/errcode"
+	"github.com/gin-gonic/gin"
+)
+
+// 认证中间件把当前用户及其会话存入 gin.Context 时使用的键
+const (
+	contextUserKey    = "user"
+	contextSessionKey = "session_id"
+)
+
+// RequireAuth 认证中间件：从 Authorization: Bearer 请求头读取并校验 token，把用户存入上下文；
+// publicRoutes 中的接口（"方法 路由"，如 "POST /api/user/login"）无需登录，携带有效 token 时同样会存入用户
+func RequireAuth(publicRoutes ...string) gin.HandlerFunc {
+	public := make(map[string]bool, len(publicRoutes))
+	for _, r := range publicRoutes {
+		public[r] = true
+	}
+	return func(c *gin.Context) {
+		if c.Request.Method == http.MethodOptions {
+			c.Next()
+			return
+		}
+		u, sessionId, err := parseAccessToken(RequestToken(c))
+		if err == nil {
+			u.Password = ""
+			c.Set(contextUserKey, u)
+			c.Set(contextSessionKey, sessionId)
+			c.Next()
+			return
+		}
+		if public[c.Request.Method+" "+c.FullPath()] {
+			c.Next()
+			return
+		}
+		c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{
+			"ret": errcode.JWT_ERR,
+			"msg": "登录信息过期，请重新登录",
+		})
+	}
+}
+
+// CurrentUser 返回认证中间件存入上下文的用户，未登录时返回 nil
+func CurrentUser(c *gin.Context) *User {
+	if v, ok := c.Get(contextUserKey); ok {
+		if u, ok := v.(*User); ok {
+			return u
+		}
+	}
+	return nil
+}
+
+// currentSession 返回当前请求所属的登录会话，未登录时返回空字符串
+func currentSession(c *gin.Context) string {
+	return c.GetString(contextSessionKey)
+}
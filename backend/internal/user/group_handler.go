@@ -14,10 +14,7 @@ import (
 )
 
 func CreateGroup(c *gin.Context) {
-	u, ok := CheckUserPermission(c.Query("token"), Teacher, c)
-	if !ok {
-		return
-	}
+	u := CurrentUser(c)
 	res, err := commonlib.DB_user.Exec("INSERT INTO t_group SET owner=?,name=?", u.Id, c.Query("name"))
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{
@@ -34,10 +31,7 @@ func CreateGroup(c *gin.Context) {
 	})
 }
 func AddGroupUser(c *gin.Context) {
-	u, ok := CheckUserPermission(c.Query("token"), Teacher, c)
-	if !ok {
-		return
-	}
+	u := CurrentUser(c)
 	group_id, _ := strconv.Atoi(c.Query("group_id"))
 	var postJson struct {
 		Users []string
@@ -112,10 +106,7 @@ func deleteGroupUsersDB(group_id int, users []string) (err error) {
 }
 
 func DeleteGroupUser(c *gin.Context) {
-	u, ok := CheckUserPermission(c.Query("token"), Teacher, c)
-	if !ok {
-		return
-	}
+	u := CurrentUser(c)
 	group_id, _ := strconv.Atoi(c.Query("group_id"))
 	var postJson struct {
 		Users []string
@@ -193,10 +184,7 @@ func GetGroupDB(group_id int) (group_info GroupInfo, err error) {
 
 // only the owner or member of group can get user list
 func GetGroupUser(c *gin.Context) {
-	u, ok := CheckUserPermission(c.Query("token"), Student, c)
-	if !ok {
-		return
-	}
+	u := CurrentUser(c)
 	group_id, _ := strconv.Atoi(c.Query("group_id"))
 	var tmp int
 	err := commonlib.DB_user.QueryRow("SELECT 1 FROM t_group_user"+
@@ -231,10 +219,7 @@ func GetGroupUser(c *gin.Context) {
 }
 
 func DeleteGroup(c *gin.Context) {
-	u, ok := CheckUserPermission(c.Query("token"), Teacher, c)
-	if !ok {
-		return
-	}
+	u := CurrentUser(c)
 	group_id, _ := strconv.Atoi(c.Query("group_id"))
 	var tmp int
 	err := commonlib.DB_user.QueryRow("SELECT 1 FROM t_group"+
@@ -272,10 +257,7 @@ func DeleteGroup(c *gin.Context) {
 }
 
 func GetGroupList(c *gin.Context) {
-	u, ok := CheckUserPermission(c.Query("token"), Student, c)
-	if !ok {
-		return
-	}
+	u := CurrentUser(c)
 	type group_info struct {
 		Group_id int    `json:"group_id"`
 		Name     string `json:"name"`
@@ -318,10 +300,6 @@ func GetGroupList(c *gin.Context) {
 }
 
 func GetAllGroup(c *gin.Context) {
-	_, ok := CheckUserPermission(c.Query("token"), Admin, c)
-	if !ok {
-		return
-	}
 	type group_info struct {
 		Group_id int    `json:"group_id"`
 		Name     string `json:"name"`
@@ -345,10 +323,7 @@ func GetAllGroup(c *gin.Context) {
 }
 
 func EditGroupName(c *gin.Context) {
-	u, ok := CheckUserPermission(c.Query("token"), Teacher, c)
-	if !ok {
-		return
-	}
+	u := CurrentUser(c)
 	group_id, _ := strconv.Atoi(c.Query("group_id"))
 	name := c.Query("name")
 	var tmp int
@@ -410,3 +385,21 @@ func IsGroupOwnerOrMember(u *User, group_id int, c *gin.Context) (ok bool) {
 	}
 	return true
 }
+
+// check if student is member of a group owned by user,
+// return false and response 403 if not
+func IsStudentOwner(u *User, student_id string, c *gin.Context) (ok bool) {
+	var tmp int
+	if commonlib.DB_user.
+		QueryRow("SELECT 1 FROM t_group_user inner join t_group"+
+			" ON t_group_user.group_id=t_group.group_id"+
+			" WHERE t_group_user.user_id=? AND t_group.owner=? LIMIT 1", student_id, u.Id).
+		Scan(&tmp) != nil {
+		c.JSON(http.StatusForbidden, gin.H{
+			"ret": errcode.AUTH_ERR,
+			"msg": "student not in any group owned by user",
+		})
+		return false
+	}
+	return true
+}
@@ -9,3 +9,23 @@ func GetGroupSize(group_id int) (count int) {
 		Scan(&count)
 	return
 }
+
+// GetUserGroupIds 返回用户所在的全部组
+func GetUserGroupIds(user_id string) (groups []int64, err error) {
+	rows, err := commonlib.DB_user.
+		Query("SELECT group_id FROM t_group_user"+
+			" WHERE user_id=?", user_id)
+	if err != nil {
+		return
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var group_id int64
+		if err = rows.Scan(&group_id); err != nil {
+			return
+		}
+		groups = append(groups, group_id)
+	}
+	err = rows.Err()
+	return
+}
@@ -11,7 +11,6 @@ import (
 	"net/http"
 	"regexp"
 	"strconv"
-	"time"
 	"unicode/utf8"
 
 	"github.com/RMS_V3/log/logger"
@@ -102,26 +101,21 @@ func GenerateToken(c *gin.Context) {
 		return
 	}
 
-	if userInfo.Password == md5Gen(c.PostForm("password")) {
-		token, err := jwtGenerateToken(&userInfo, time.Hour*10)
+	ok, needsRehash := verifyPassword(userInfo.Password, c.PostForm("password"))
+	if ok && needsRehash {
+		upgradePasswordHash(userInfo.Id, userInfo.Password, c.PostForm("password"), c)
+	}
+	if ok {
+		sessionId, refreshToken, err := createSession(userInfo.Id)
 		if err != nil {
 			logger.ERROR_LOG(err.Error(), c)
 			c.JSON(http.StatusInternalServerError, gin.H{
-				"ret": errcode.JWT_ERR,
+				"ret": errcode.DB_CONN_ERR,
 				"msg": "Internal Server Error",
 			})
 			return
 		}
-		c.JSON(http.StatusOK, gin.H{
-			"ret": "0",
-			"msg": "ok",
-			"user": map[string]string{
-				"id":       userInfo.Id,
-				"nickname": userInfo.Nickname,
-				"usertype": string(userInfo.UserType),
-				"token":    token,
-			},
-		})
+		respondTokens(c, &userInfo, sessionId, refreshToken)
 	} else {
 		c.JSON(http.StatusOK, gin.H{
 			"ret": errcode.WRONG_PASSWORD,
@@ -130,16 +124,105 @@ func GenerateToken(c *gin.Context) {
 	}
 }
 
-func CheckToken(c *gin.Context) {
-	userInfo, err := JwtParseToken(c.Query("token"))
+// respondTokens 签发访问令牌，与刷新令牌一起返回
+func respondTokens(c *gin.Context, u *User, sessionId, refreshToken string) {
+	token, err := jwtGenerateToken(u, sessionId)
 	if err != nil {
-		logger.DEBUG_LOG(err.Error(), c)
+		logger.ERROR_LOG(err.Error(), c)
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"ret": errcode.JWT_ERR,
 			"msg": "Internal Server Error",
 		})
 		return
 	}
+	c.JSON(http.StatusOK, gin.H{
+		"ret": "0",
+		"msg": "ok",
+		"user": map[string]string{
+			"id":            u.Id,
+			"nickname":      u.Nickname,
+			"usertype":      string(u.UserType),
+			"token":         token,
+			"refresh_token": refreshToken,
+		},
+		"expires_in": int(accessTokenTTL().Seconds()),
+	})
+}
+
+// RefreshToken 使用刷新令牌换取新的访问令牌和刷新令牌，旧的刷新令牌随即失效；
+// 账号类型等信息从数据库重新读取
+func RefreshToken(c *gin.Context) {
+	userId, sessionId, refreshToken, err := rotateRefreshToken(c.PostForm("refresh_token"))
+	if err == errRefreshTokenInvalid {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"ret": errcode.JWT_ERR,
+			"msg": err.Error(),
+		})
+		return
+	}
+	if err != nil {
+		logger.ERROR_LOG(err.Error(), c)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"ret": errcode.DB_CONN_ERR,
+			"msg": "Internal Server Error",
+		})
+		return
+	}
+
+	var userInfo User
+	err = commonlib.DB_user.QueryRow("SELECT user_id,nickname,user_type FROM t_account WHERE user_id=?", userId).
+		Scan(&userInfo.Id, &userInfo.Nickname, &userInfo.UserType)
+	if err == sql.ErrNoRows {
+		revokeSession(sessionId)
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"ret": errcode.JWT_ERR,
+			"msg": "账号不存在",
+		})
+		return
+	}
+	if err != nil {
+		logger.ERROR_LOG(err.Error(), c)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"ret": errcode.DB_CONN_ERR,
+			"msg": "Internal Server Error",
+		})
+		return
+	}
+	respondTokens(c, &userInfo, sessionId, refreshToken)
+}
+
+// Logout 退出登录，撤销访问令牌所属的会话；访问令牌已过期时可以只提供 refresh_token
+func Logout(c *gin.Context) {
+	var err error
+	if sessionId := currentSession(c); sessionId != "" {
+		err = revokeSession(sessionId)
+	} else if refreshToken := c.PostForm("refresh_token"); refreshToken != "" {
+		err = revokeRefreshToken(refreshToken)
+	}
+	if err != nil {
+		logger.ERROR_LOG(err.Error(), c)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"ret": errcode.DB_CONN_ERR,
+			"msg": "Internal Server Error",
+		})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"ret": "0",
+		"msg": "ok",
+	})
+}
+
+func CheckToken(c *gin.Context) {
+	userInfo, err := JwtParseToken(RequestToken(c))
+	if err != nil {
+		logger.DEBUG_LOG(err.Error(), c)
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"ret": errcode.JWT_ERR,
+			"msg": "登录信息过期，请重新登录",
+		})
+		return
+	}
 	c.JSON(http.StatusOK, gin.H{
 		"ret": "0",
 		"msg": "ok",
@@ -148,6 +231,7 @@ func CheckToken(c *gin.Context) {
 			"nickname": userInfo.Nickname,
 			"usertype": string(userInfo.UserType),
 		},
+		"permissions": PermissionsOf(userInfo.UserType),
 	})
 }
 
@@ -184,9 +268,18 @@ func Register(c *gin.Context) {
 		})
 		return
 	}
-	userInfo.Password = md5Gen(userInfo.Password)
+	hash, err := hashPassword(userInfo.Password)
+	if err != nil {
+		logger.ERROR_LOG(err.Error(), c)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"ret": errcode.SERVER_BASE,
+			"msg": "Internal Server Error",
+		})
+		return
+	}
+	userInfo.Password = hash
 
-	_, err := commonlib.DB_user.Exec("INSERT INTO t_account (user_id,nickname,password,user_type) VALUES (?,?,?,?)",
+	_, err = commonlib.DB_user.Exec("INSERT INTO t_account (user_id,nickname,password,user_type) VALUES (?,?,?,?)",
 		userInfo.Id,
 		userInfo.Nickname,
 		userInfo.Password,
@@ -215,30 +308,58 @@ func Register(c *gin.Context) {
 
 // ChangePsw godoc
 // @Summary 修改用户密码
-// @Description 根据token验证身份后修改用户密码
+// @Description 校验当前密码后修改登录用户的密码，其他设备上的登录会话随之失效
 // @Tags 用户管理
-// @Accept  json
+// @Accept  multipart/form-data
 // @Produce  json
-// @Param token query string true "用户token"
-// @Param new_password query string true "新密码"
+// @Param Authorization header string true "Bearer 访问令牌"
+// @Param old_password formData string true "当前密码"
+// @Param new_password formData string true "新密码"
 // @Success 200 {object} map[string]interface{} "成功响应"
-// @Failure 400 {object} map[string]interface{} "无效参数或权限不足"
+// @Failure 400 {object} map[string]interface{} "无效参数或当前密码错误"
 // @Failure 500 {object} map[string]interface{} "内部服务器错误"
-// @Router /api/change_psw [get]
+// @Router /api/user/change-password [post]
 func ChangePsw(c *gin.Context) {
-	u, ok := CheckUserPermission(c.Query("token"), Student, c)
-	if !ok {
-		return
-	}
-	u.Password = c.Query("new_password")
-	if !passwordRegexp.MatchString(u.Password) {
+	u := CurrentUser(c)
+	newPassword := c.PostForm("new_password")
+	if !passwordRegexp.MatchString(newPassword) {
 		c.JSON(http.StatusBadRequest, gin.H{
 			"ret": errcode.WRONG_PARAM,
 			"msg": "invalid new password",
 		})
 		return
 	}
-	_, err := commonlib.DB_user.Exec("UPDATE t_account SET password = ? WHERE user_id = ?", md5Gen(u.Password), u.Id)
+	var oldHash string
+	err := commonlib.DB_user.QueryRow("SELECT password FROM t_account WHERE user_id = ?", u.Id).Scan(&oldHash)
+	if err != nil {
+		logger.ERROR_LOG(err.Error(), c)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"ret": errcode.DB_CONN_ERR,
+			"msg": "Internal Server Error",
+		})
+		return
+	}
+	if ok, _ := verifyPassword(oldHash, c.PostForm("old_password")); !ok {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"ret": errcode.WRONG_PASSWORD,
+			"msg": "wrong password",
+		})
+		return
+	}
+	hash, err := hashPassword(newPassword)
+	if err != nil {
+		logger.ERROR_LOG(err.Error(), c)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"ret": errcode.SERVER_BASE,
+			"msg": "Internal Server Error",
+		})
+		return
+	}
+	_, err = commonlib.DB_user.Exec("UPDATE t_account SET password = ? WHERE user_id = ?", hash, u.Id)
+	if err == nil {
+		// 其他设备上的登录会话全部失效，当前会话保留
+		err = revokeUserSessions(u.Id, currentSession(c))
+	}
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"ret": errcode.DB_CONN_ERR,
@@ -268,10 +389,7 @@ func ChangePsw(c *gin.Context) {
 // @Failure 500 {object} map[string]interface{} "内部服务器错误"
 // @Router /api/add_user [post]
 func AddUserBatch(c *gin.Context) {
-	u, ok := CheckUserPermission(c.Query("token"), Teacher, c)
-	if !ok {
-		return
-	}
+	u := CurrentUser(c)
 	group_id, _ := strconv.Atoi(c.Query("group_id"))
 	if group_id == 0 {
 		c.JSON(http.StatusBadRequest, gin.H{
@@ -300,8 +418,6 @@ func AddUserBatch(c *gin.Context) {
 	reader := csv.NewReader(bufio.NewReader(file))
 	sqlTeml := "INSERT into t_account (user_id,nickname,password,user_type) VALUES (?,?,?,?)" //fix
 	sqlParam := make([]interface{}, 0)
-	// 默认密码为12345678
-	default_psw := md5Gen("12345678")
 	users := []string{}
 	validUserType := map[string]bool{}
 	validUserType["student"] = true
@@ -373,6 +489,16 @@ func AddUserBatch(c *gin.Context) {
 			return
 		}
 		users = append(users, line[0])
+		// 默认密码为12345678，每个账号单独加盐
+		default_psw, err := hashPassword(defaultImportPassword)
+		if err != nil {
+			logger.ERROR_LOG(err.Error(), c)
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"ret": errcode.SERVER_BASE,
+				"msg": "服务器内部错误",
+			})
+			return
+		}
 		sqlParam = make([]interface{}, 0) //fix
 		sqlParam = append(sqlParam, line[0], line[1], default_psw, line[2])
 		tx, _ := commonlib.DB_user.Begin()
@@ -434,12 +560,6 @@ func AddUserBatch(c *gin.Context) {
 
 // ListUsers 获取所有用户列表
 func ListUsers(c *gin.Context) {
-	// 验证用户权限（仅管理员可以查看所有用户）
-	_, ok := CheckUserPermission(c.Query("token"), Admin, c)
-	if !ok {
-		return
-	}
-
 	// 查询所有用户信息
 	rows, err := commonlib.DB_user.Query(`
 		SELECT user_id, nickname, user_type
@@ -485,12 +605,6 @@ func ListUsers(c *gin.Context) {
 
 // UpdateUser 更新用户信息
 func UpdateUser(c *gin.Context) {
-	// 验证用户权限（仅管理员可以更新用户信息）
-	_, ok := CheckUserPermission(c.Query("token"), Admin, c)
-	if !ok {
-		return
-	}
-
 	// 解析请求参数
 	var req struct {
 		UserID   string `json:"user_id"`
@@ -524,12 +638,27 @@ func UpdateUser(c *gin.Context) {
 		return
 	}
 
+	// 账号类型记录在访问令牌中，修改后需要撤销该用户的全部会话
+	var oldType string
+	err := commonlib.DB_user.QueryRow("SELECT user_type FROM t_account WHERE user_id = ?", req.UserID).Scan(&oldType)
+	if err != nil && err != sql.ErrNoRows {
+		logger.ERROR_LOG(err.Error(), c)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"code":    errcode.DBERR_BASE,
+			"message": "更新用户信息失败",
+		})
+		return
+	}
+
 	// 更新用户信息
-	_, err := commonlib.DB_user.Exec(`
+	_, err = commonlib.DB_user.Exec(`
 		UPDATE t_account 
 		SET nickname = ?, user_type = ? 
 		WHERE user_id = ?
 	`, req.Nickname, req.UserType, req.UserID)
+	if err == nil && oldType != "" && oldType != req.UserType {
+		err = revokeUserSessions(req.UserID, "")
+	}
 
 	if err != nil {
 		logger.ERROR_LOG(err.Error(), c)
@@ -549,11 +678,7 @@ func UpdateUser(c *gin.Context) {
 
 // DeleteUser 删除用户
 func DeleteUser(c *gin.Context) {
-	// 验证用户权限（仅管理员可以删除用户）
-	u, ok := CheckUserPermission(c.Query("token"), Admin, c)
-	if !ok {
-		return
-	}
+	u := CurrentUser(c)
 
 	// 解析请求参数
 	var req struct {
@@ -609,6 +734,18 @@ func DeleteUser(c *gin.Context) {
 		return
 	}
 
+	// 删除登录会话，已签发的访问令牌随即失效
+	_, err = tx.Exec("DELETE FROM t_session WHERE user_id = ?", req.UserID)
+	if err != nil {
+		tx.Rollback()
+		logger.ERROR_LOG(err.Error(), c)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"code":    errcode.DBERR_BASE,
+			"message": "删除登录会话失败",
+		})
+		return
+	}
+
 	// 删除用户帐号
 	_, err = tx.Exec("DELETE FROM t_account WHERE user_id = ?", req.UserID)
 	if err != nil {
@@ -630,6 +767,7 @@ func DeleteUser(c *gin.Context) {
 		})
 		return
 	}
+	forgetSessions(func(_ string, s sessionStatus) bool { return s.userId == req.UserID })
 
 	// 返回成功响应
 	c.JSON(http.StatusOK, gin.H{
@@ -640,11 +778,7 @@ func DeleteUser(c *gin.Context) {
 
 // AddUser 添加单个用户
 func AddUser(c *gin.Context) {
-	// 验证用户权限（仅管理员和教师可以添加用户）
-	u, ok := CheckUserPermission(c.Query("token"), Teacher, c)
-	if !ok {
-		return
-	}
+	u := CurrentUser(c)
 
 	// 解析请求参数
 	var req struct {
@@ -698,8 +832,16 @@ func AddUser(c *gin.Context) {
 		return
 	}
 
-	// 密码加密
-	hashedPassword := md5Gen(req.Password)
+	// 密码加盐哈希
+	hashedPassword, err := hashPassword(req.Password)
+	if err != nil {
+		logger.ERROR_LOG(err.Error(), c)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"code":    errcode.SERVER_BASE,
+			"message": "服务器内部错误",
+		})
+		return
+	}
 
 	// 插入用户
 	tx, err := commonlib.DB_user.Begin()
@@ -2,11 +2,9 @@ package user
 
 import (
 	"errors"
-	"net/http"
+	"strings"
 	"time"
 
-	"github.com/RMS_V3/pkg/errcode"
-
 	"github.com/RMS_V3/config"
 
 	"github.com/gin-gonic/gin"
@@ -16,11 +14,14 @@ import (
 type userClaims struct {
 	jwt.StandardClaims
 	*User
+	// SessionID 登录会话，会话被撤销后访问令牌随即失效
+	SessionID string `json:"sid"`
 }
 
-func jwtGenerateToken(u *User, d time.Duration) (string, error) {
+// jwtGenerateToken 签发属于 sessionId 会话的访问令牌
+func jwtGenerateToken(u *User, sessionId string) (string, error) {
 	u.Password = ""
-	expireTime := time.Now().Add(d)
+	expireTime := time.Now().Add(accessTokenTTL())
 	stdClams := jwt.StandardClaims{
 		ExpiresAt: expireTime.Unix(),
 		IssuedAt:  time.Now().Unix(),
@@ -30,50 +31,40 @@ func jwtGenerateToken(u *User, d time.Duration) (string, error) {
 	uClaims := userClaims{
 		StandardClaims: stdClams,
 		User:           u,
+		SessionID:      sessionId,
 	}
 	token := jwt.NewWithClaims(jwt.SigningMethodHS256, uClaims)
 	return token.SignedString([]byte(config.GetGlobalConfig().JwtConfig.JwtSalt))
 }
 
+// JwtParseToken 校验访问令牌的签名、有效期及所属会话
 func JwtParseToken(token string) (*User, error) {
+	u, _, err := parseAccessToken(token)
+	return u, err
+}
+
+func parseAccessToken(token string) (*User, string, error) {
 	if token == "" {
-		return nil, errors.New("empty token")
+		return nil, "", errors.New("empty token")
 	}
 	uClaims := userClaims{}
 	_, err := jwt.ParseWithClaims(token, &uClaims, func(token *jwt.Token) (interface{}, error) {
 		return []byte(config.GetGlobalConfig().JwtConfig.JwtSalt), nil
 	})
-	return uClaims.User, err
-}
-
-// parse token and confirm user_type,
-//
-// return false and response StatusUnauthorized if token invalid or user_type lower than expected.
-//
-// Admin > Teacher > Student
-func CheckUserPermission(token string, userType UserTypes, c *gin.Context) (user *User, ok bool) {
-	ok = false
-	user, err := JwtParseToken(token)
 	if err != nil {
-		c.JSON(http.StatusUnauthorized, gin.H{
-			"ret": errcode.JWT_ERR,
-			"msg": "登录信息过期，请重新登录",
-		})
-		return
+		return nil, "", err
 	}
-	user.Password = ""
-	
-	ok = PermissionCmp(user.UserType, userType)
-	if !ok {
-		c.JSON(http.StatusUnauthorized, gin.H{
-			"ret": errcode.AUTH_ERR,
-			"msg": "权限不足",
-		})
+	if uClaims.User == nil || uClaims.SessionID == "" || !sessionActive(uClaims.SessionID, uClaims.User.Id) {
+		return nil, "", errors.New("session revoked")
 	}
-	return
+	return uClaims.User, uClaims.SessionID, nil
 }
 
-// return true if a>=b
-func PermissionCmp(a UserTypes, b UserTypes) bool {
-	return typeVal[a] >= typeVal[b]
+// RequestToken 读取 Authorization: Bearer 请求头中的 token，不再接受 token 查询参数以免 token 出现在日志和浏览记录中
+func RequestToken(c *gin.Context) string {
+	header := c.GetHeader("Authorization")
+	if !strings.HasPrefix(header, "Bearer ") {
+		return ""
+	}
+	return strings.TrimSpace(strings.TrimPrefix(header, "Bearer "))
 }
@@ -0,0 +1,70 @@
+package user
+
+import (
+	"crypto/subtle"
+	"strings"
+
+	"github.com/RMS_V3/log/logger"
+	"github.com/RMS_V3/pkg/commonlib"
+	"github.com/gin-gonic/gin"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// t_account.password 的存储格式：
+//
+//	v1$<bcrypt 哈希>  当前格式，bcrypt 自带随机盐，共 63 个字符
+//	32 位十六进制      旧版无盐 MD5，登录成功后自动升级为当前格式
+const passwordHashV1 = "v1$"
+
+const bcryptCost = bcrypt.DefaultCost
+
+// defaultImportPassword 批量导入账号的初始密码，每个账号单独加盐哈希
+const defaultImportPassword = "12345678"
+
+// hashPassword 生成当前格式的密码哈希
+func hashPassword(password string) (string, error) {
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcryptCost)
+	if err != nil {
+		return "", err
+	}
+	return passwordHashV1 + string(hash), nil
+}
+
+func isLegacyMD5(hash string) bool {
+	if len(hash) != 32 {
+		return false
+	}
+	for _, ch := range hash {
+		if !(ch >= '0' && ch <= '9' || ch >= 'a' && ch <= 'f') {
+			return false
+		}
+	}
+	return true
+}
+
+// verifyPassword 校验密码，needsRehash 表示哈希不是当前格式或参数，校验通过后应重新哈希保存
+func verifyPassword(hash, password string) (ok bool, needsRehash bool) {
+	if strings.HasPrefix(hash, passwordHashV1) {
+		encoded := []byte(strings.TrimPrefix(hash, passwordHashV1))
+		if bcrypt.CompareHashAndPassword(encoded, []byte(password)) != nil {
+			return false, false
+		}
+		cost, err := bcrypt.Cost(encoded)
+		return true, err != nil || cost != bcryptCost
+	}
+	if isLegacyMD5(hash) {
+		return subtle.ConstantTimeCompare([]byte(hash), []byte(md5Gen(password))) == 1, true
+	}
+	return false, false
+}
+
+// upgradePasswordHash 登录成功后把旧格式的哈希替换为当前格式；期间密码已被修改时不覆盖，失败只记录日志
+func upgradePasswordHash(userId, oldHash, password string, c *gin.Context) {
+	hash, err := hashPassword(password)
+	if err == nil {
+		_, err = commonlib.DB_user.Exec("UPDATE t_account SET password = ? WHERE user_id = ? AND password = ?", hash, userId, oldHash)
+	}
+	if err != nil {
+		logger.ERROR_LOG(err.Error(), c)
+	}
+}
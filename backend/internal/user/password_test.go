@@ -0,0 +1,75 @@
+package user
+
+import (
+	"strings"
+	"testing"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+func TestHashAndVerifyPassword(t *testing.T) {
+	hash, err := hashPassword("secret123")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(hash, passwordHashV1) || len(hash) != 63 {
+		t.Fatalf("hash = %q, want v1$ followed by a bcrypt hash", hash)
+	}
+	if ok, rehash := verifyPassword(hash, "secret123"); !ok || rehash {
+		t.Errorf("verify = %v, %v, want match without rehash", ok, rehash)
+	}
+	if ok, _ := verifyPassword(hash, "secret124"); ok {
+		t.Error("wrong password should not match")
+	}
+	// 每次哈希使用不同的盐
+	if again, _ := hashPassword("secret123"); again == hash {
+		t.Error("hashing twice should produce different hashes")
+	}
+
+	// 参数与当前不同的 bcrypt 哈希校验通过后需要重新哈希
+	weak, err := bcrypt.GenerateFromPassword([]byte("secret123"), bcrypt.MinCost)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ok, rehash := verifyPassword(passwordHashV1+string(weak), "secret123"); !ok || !rehash {
+		t.Errorf("verify = %v, %v, want match with rehash", ok, rehash)
+	}
+}
+
+func TestLegacyMD5Password(t *testing.T) {
+	legacy := md5Gen("secret123")
+	if !isLegacyMD5(legacy) {
+		t.Fatalf("%q should be detected as legacy MD5", legacy)
+	}
+	for _, hash := range []string{
+		"",
+		strings.ToUpper(legacy),
+		legacy[:31],
+		legacy + "0",
+		strings.Repeat("g", 32),
+	} {
+		if isLegacyMD5(hash) {
+			t.Errorf("%q should not be detected as legacy MD5", hash)
+		}
+	}
+	if ok, rehash := verifyPassword(legacy, "secret123"); !ok || !rehash {
+		t.Errorf("verify = %v, %v, want match with rehash", ok, rehash)
+	}
+	if ok, _ := verifyPassword(legacy, "secret124"); ok {
+		t.Error("wrong password should not match legacy hash")
+	}
+}
+
+func TestVerifyMalformedPassword(t *testing.T) {
+	for _, hash := range []string{
+		"",
+		"secret123",
+		passwordHashV1,
+		passwordHashV1 + "not-a-bcrypt-hash",
+		"v2$" + md5Gen("secret123"),
+	} {
+		if ok, rehash := verifyPassword(hash, "secret123"); ok || rehash {
+			t.Errorf("verify(%q) = %v, %v, want rejected", hash, ok, rehash)
+		}
+	}
+}
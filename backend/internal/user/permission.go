@@ -0,0 +1,275 @@
+package user
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/RMS_V3/log"
+	"github.com/RMS_V3/log/logger"
+	"github.com/RMS_V3/pkg/commonlib"
+	"github.com/RMS_V3/pkg/errcode"
+	"github.com/gin-gonic/gin"
+)
+
+type Permission string
+
+const (
+	GraphRead      Permission = "graph.read"      // 浏览图谱、资源及学习相关功能
+	GraphWrite     Permission = "graph.write"     // 修改图谱或提交图谱修改申请
+	ResourceUpload Permission = "resource.upload" // 上传、删除学习资源和维护题库
+	UserManage     Permission = "user.manage"     // 管理账号、课程负责人和角色权限
+	GroupManage    Permission = "group.manage"    // 管理用户组、组内作业和学习情况
+	SystemManage   Permission = "system.manage"   // 系统维护，如手动重新计算推荐数据
+)
+
+var allPermissions = []Permission{GraphRead, GraphWrite, ResourceUpload, UserManage, GroupManage, SystemManage}
+
+// defaultRolePermissions t_role_permission 为空时使用的默认映射
+var defaultRolePermissions = map[UserTypes][]Permission{
+	Admin:   allPermissions,
+	Teacher: {GraphRead, GraphWrite, ResourceUpload, GroupManage},
+	Student: {GraphRead},
+}
+
+// permissionCacheTTL 角色权限缓存时间，本实例修改时立即失效，其他实例最多延迟该时间生效
+const permissionCacheTTL = time.Minute
+
+var (
+	permissionMu       sync.RWMutex
+	permissionCache    map[UserTypes]map[Permission]bool
+	permissionLoadedAt time.Time
+)
+
+func validPermission(p Permission) bool {
+	for _, known := range allPermissions {
+		if p == known {
+			return true
+		}
+	}
+	return false
+}
+
+func permissionSet(mapping map[UserTypes][]Permission) map[UserTypes]map[Permission]bool {
+	set := make(map[UserTypes]map[Permission]bool, len(mapping))
+	for t, perms := range mapping {
+		set[t] = make(map[Permission]bool, len(perms))
+		for _, p := range perms {
+			set[t][p] = true
+		}
+	}
+	return set
+}
+
+// loadRolePermissions 读取 t_role_permission，表为空时返回默认映射
+func loadRolePermissions() (map[UserTypes]map[Permission]bool, error) {
+	rows, err := commonlib.DB_user.Query("SELECT user_type, permission FROM t_role_permission")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	mapping := make(map[UserTypes][]Permission)
+	for rows.Next() {
+		var t, p string
+		if err := rows.Scan(&t, &p); err != nil {
+			return nil, err
+		}
+		mapping[UserTypes(t)] = append(mapping[UserTypes(t)], Permission(p))
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	if len(mapping) == 0 {
+		mapping = defaultRolePermissions
+	}
+	return permissionSet(mapping), nil
+}
+
+// rolePermissions 返回缓存的角色权限，读取失败时沿用上一次的结果，从未成功读取过则使用默认映射
+func rolePermissions() map[UserTypes]map[Permission]bool {
+	permissionMu.RLock()
+	cached, loadedAt := permissionCache, permissionLoadedAt
+	permissionMu.RUnlock()
+	if cached != nil && time.Since(loadedAt) < permissionCacheTTL {
+		return cached
+	}
+
+	permissionMu.Lock()
+	defer permissionMu.Unlock()
+	if permissionCache != nil && time.Since(permissionLoadedAt) < permissionCacheTTL {
+		return permissionCache
+	}
+	loaded, err := loadRolePermissions()
+	if err != nil {
+		log.Errorf("load role permissions failed: %v", err)
+		if permissionCache == nil {
+			loaded = permissionSet(defaultRolePermissions)
+		} else {
+			loaded = permissionCache
+		}
+	}
+	permissionCache, permissionLoadedAt = loaded, time.Now()
+	return permissionCache
+}
+
+func invalidatePermissionCache() {
+	permissionMu.Lock()
+	permissionCache = nil
+	permissionMu.Unlock()
+}
+
+// HasPermission 判断账号类型是否拥有权限，管理员始终拥有 user.manage 以免无法恢复权限配置
+func HasPermission(userType UserTypes, p Permission) bool {
+	if userType == Admin && p == UserManage {
+		return true
+	}
+	return rolePermissions()[userType][p]
+}
+
+// PermissionsOf 账号类型拥有的全部权限，按名称排序
+func PermissionsOf(userType UserTypes) []Permission {
+	perms := []Permission{}
+	for _, p := range allPermissions {
+		if HasPermission(userType, p) {
+			perms = append(perms, p)
+		}
+	}
+	sort.Slice(perms, func(i, j int) bool { return perms[i] < perms[j] })
+	return perms
+}
+
+// RequirePermission 路由级权限校验，需要在 RequireAuth 之后使用
+func RequirePermission(p Permission) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		u := CurrentUser(c)
+		if u == nil {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{
+				"ret": errcode.JWT_ERR,
+				"msg": "登录信息过期，请重新登录",
+			})
+			return
+		}
+		if !HasPermission(u.UserType, p) {
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{
+				"ret": errcode.AUTH_ERR,
+				"msg": fmt.Sprintf("权限不足，需要 %s 权限", p),
+			})
+			return
+		}
+		c.Next()
+	}
+}
+
+// setRolePermissions 替换账号类型的权限；表为空时先写入其他账号类型的默认权限，避免它们失去默认映射
+func setRolePermissions(userType UserTypes, perms []Permission) error {
+	tx, err := commonlib.DB_user.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var count int
+	if err := tx.QueryRow("SELECT COUNT(1) FROM t_role_permission").Scan(&count); err != nil {
+		return err
+	}
+	if count == 0 {
+		for t, defaults := range defaultRolePermissions {
+			if t == userType {
+				continue
+			}
+			for _, p := range defaults {
+				if _, err := tx.Exec("INSERT INTO t_role_permission (user_type, permission) VALUES (?, ?)", t, p); err != nil {
+					return err
+				}
+			}
+		}
+	}
+	if _, err := tx.Exec("DELETE FROM t_role_permission WHERE user_type = ?", userType); err != nil {
+		return err
+	}
+	for _, p := range perms {
+		if _, err := tx.Exec("INSERT INTO t_role_permission (user_type, permission) VALUES (?, ?)", userType, p); err != nil {
+			return err
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+	invalidatePermissionCache()
+	return nil
+}
+
+// ListRolePermissions 查看各账号类型的权限
+func ListRolePermissions(c *gin.Context) {
+	roles := make(map[UserTypes][]Permission, len(typeVal))
+	for t := range typeVal {
+		roles[t] = PermissionsOf(t)
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"ret":         "0",
+		"msg":         "ok",
+		"roles":       roles,
+		"permissions": allPermissions,
+	})
+}
+
+type updateRolePermissionsRequest struct {
+	UserType    UserTypes    `json:"user_type" binding:"required"`
+	Permissions []Permission `json:"permissions"`
+}
+
+// UpdateRolePermissions 修改账号类型的权限
+func UpdateRolePermissions(c *gin.Context) {
+	var req updateRolePermissionsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"ret": errcode.WRONG_PARAM,
+			"msg": "参数格式错误",
+		})
+		return
+	}
+	if _, ok := typeVal[req.UserType]; !ok {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"ret": errcode.WRONG_PARAM,
+			"msg": fmt.Sprintf("未知的账号类型: %s", req.UserType),
+		})
+		return
+	}
+	seen := make(map[Permission]bool)
+	perms := []Permission{}
+	for _, p := range req.Permissions {
+		if !validPermission(p) {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"ret": errcode.WRONG_PARAM,
+				"msg": fmt.Sprintf("未知的权限: %s", p),
+			})
+			return
+		}
+		if !seen[p] {
+			seen[p] = true
+			perms = append(perms, p)
+		}
+	}
+	if req.UserType == Admin && !seen[UserManage] {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"ret": errcode.WRONG_PARAM,
+			"msg": "管理员必须保留 user.manage 权限",
+		})
+		return
+	}
+	if err := setRolePermissions(req.UserType, perms); err != nil {
+		logger.ERROR_LOG(err.Error(), c)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"ret": errcode.DB_CONN_ERR,
+			"msg": "Internal Server Error",
+		})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"ret":         "0",
+		"msg":         "ok",
+		"permissions": PermissionsOf(req.UserType),
+	})
+}
@@ -0,0 +1,238 @@
+package user
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/RMS_V3/config"
+	"github.com/RMS_V3/log"
+	"github.com/RMS_V3/pkg/commonlib"
+)
+
+const (
+	defaultAccessTokenTTL  = 15     // 分钟
+	defaultRefreshTokenTTL = 7 * 24 // 小时
+	// sessionCacheTTL 会话状态的缓存时间：本实例撤销会话时立即失效，其他实例最多延迟该时间
+	sessionCacheTTL = 30 * time.Second
+	// refreshGracePeriod 刷新令牌轮换后，上一个令牌仍可使用的时间，
+	// 用于多个标签页同时刷新、响应丢失后重试等情况
+	refreshGracePeriod = 30 * time.Second
+)
+
+var errRefreshTokenInvalid = errors.New("刷新令牌无效或已过期")
+
+func accessTokenTTL() time.Duration {
+	if cfg := config.GetGlobalConfig().JwtConfig; cfg != nil && cfg.AccessTokenTTL > 0 {
+		return time.Duration(cfg.AccessTokenTTL) * time.Minute
+	}
+	return defaultAccessTokenTTL * time.Minute
+}
+
+// refreshTokenHours 刷新令牌有效期（小时）
+func refreshTokenHours() int {
+	if cfg := config.GetGlobalConfig().JwtConfig; cfg != nil && cfg.RefreshTokenTTL > 0 {
+		return cfg.RefreshTokenTTL
+	}
+	return defaultRefreshTokenTTL
+}
+
+func randomHex(n int) (string, error) {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+func hashRefreshSecret(secret string) string {
+	sum := sha256.Sum256([]byte(secret))
+	return hex.EncodeToString(sum[:])
+}
+
+// 刷新令牌格式为 <session_id>.<secret>，服务端只保存 secret 的 SHA-256
+func splitRefreshToken(token string) (sessionId, secret string, ok bool) {
+	sessionId, secret, ok = strings.Cut(token, ".")
+	return sessionId, secret, ok && len(sessionId) == 32 && secret != ""
+}
+
+// createSession 为用户创建登录会话，返回会话 ID 和刷新令牌，同时清理该用户已过期的会话
+func createSession(userId string) (sessionId, refreshToken string, err error) {
+	if sessionId, err = randomHex(16); err != nil {
+		return
+	}
+	secret, err := randomHex(32)
+	if err != nil {
+		return
+	}
+	if _, err = commonlib.DB_user.Exec("DELETE FROM t_session WHERE user_id = ? AND expires_at < NOW()", userId); err != nil {
+		return
+	}
+	_, err = commonlib.DB_user.Exec("INSERT INTO t_session (session_id, user_id, refresh_hash, expires_at) "+
+		"VALUES (?, ?, ?, DATE_ADD(NOW(), INTERVAL ? HOUR))", sessionId, userId, hashRefreshSecret(secret), refreshTokenHours())
+	return sessionId, sessionId + "." + secret, err
+}
+
+// rotateRefreshToken 校验刷新令牌并换发新的刷新令牌。刚被轮换的上一个令牌在 refreshGracePeriod 内仍可换发，
+// 超过该时间再次出现说明令牌可能泄露，整个会话会被撤销；其他不匹配的令牌只返回无效
+func rotateRefreshToken(token string) (userId, sessionId, newToken string, err error) {
+	sessionId, secret, ok := splitRefreshToken(token)
+	if !ok {
+		return "", "", "", errRefreshTokenInvalid
+	}
+	tx, err := commonlib.DB_user.Begin()
+	if err != nil {
+		return
+	}
+	defer tx.Rollback()
+
+	var refreshHash, prevHash string
+	var usable, inGrace bool
+	err = tx.QueryRow("SELECT user_id, refresh_hash, IFNULL(prev_refresh_hash, ''), "+
+		"revoked_at IS NULL AND expires_at > NOW(), IFNULL(rotated_at > DATE_SUB(NOW(), INTERVAL ? SECOND), FALSE) "+
+		"FROM t_session WHERE session_id = ? FOR UPDATE", int(refreshGracePeriod/time.Second), sessionId).
+		Scan(&userId, &refreshHash, &prevHash, &usable, &inGrace)
+	if err == sql.ErrNoRows {
+		return "", "", "", errRefreshTokenInvalid
+	}
+	if err != nil {
+		return
+	}
+	if !usable {
+		return "", "", "", errRefreshTokenInvalid
+	}
+
+	presented := []byte(hashRefreshSecret(secret))
+	isCurrent := subtle.ConstantTimeCompare([]byte(refreshHash), presented) == 1
+	isPrevious := prevHash != "" && subtle.ConstantTimeCompare([]byte(prevHash), presented) == 1
+	switch {
+	case isCurrent:
+	case isPrevious && inGrace:
+		// 并发刷新或重试：再换发一次，上一个令牌和宽限期起点保持不变
+	case isPrevious:
+		log.Errorf("reused refresh token of session %s (user %s), revoking the session", sessionId, userId)
+		if _, err = tx.Exec("UPDATE t_session SET revoked_at = NOW() WHERE session_id = ?", sessionId); err != nil {
+			return
+		}
+		if err = tx.Commit(); err != nil {
+			return
+		}
+		forgetSessions(func(sid string, _ sessionStatus) bool { return sid == sessionId })
+		return "", "", "", errRefreshTokenInvalid
+	default:
+		return "", "", "", errRefreshTokenInvalid
+	}
+
+	newSecret, err := randomHex(32)
+	if err != nil {
+		return
+	}
+	if isCurrent {
+		_, err = tx.Exec("UPDATE t_session SET prev_refresh_hash = refresh_hash, rotated_at = NOW(), refresh_hash = ?, "+
+			"expires_at = DATE_ADD(NOW(), INTERVAL ? HOUR) WHERE session_id = ?", hashRefreshSecret(newSecret), refreshTokenHours(), sessionId)
+	} else {
+		_, err = tx.Exec("UPDATE t_session SET refresh_hash = ?, expires_at = DATE_ADD(NOW(), INTERVAL ? HOUR) "+
+			"WHERE session_id = ?", hashRefreshSecret(newSecret), refreshTokenHours(), sessionId)
+	}
+	if err != nil {
+		return
+	}
+	if err = tx.Commit(); err != nil {
+		return
+	}
+	return userId, sessionId, sessionId + "." + newSecret, nil
+}
+
+type sessionStatus struct {
+	userId    string
+	active    bool
+	checkedAt time.Time
+}
+
+var (
+	sessionMu        sync.Mutex
+	sessionCache     = make(map[string]sessionStatus)
+	sessionSweepOnce sync.Once
+)
+
+// sweepSessionCache 定时清理过期的缓存条目，避免缓存随访问过的会话数无限增长
+func sweepSessionCache() {
+	for range time.Tick(sessionCacheTTL) {
+		sessionMu.Lock()
+		for sid, s := range sessionCache {
+			if time.Since(s.checkedAt) >= sessionCacheTTL {
+				delete(sessionCache, sid)
+			}
+		}
+		sessionMu.Unlock()
+	}
+}
+
+// sessionActive 会话未撤销、未过期且账号仍存在；结果缓存 sessionCacheTTL，读取失败时视为无效
+func sessionActive(sessionId, userId string) bool {
+	sessionMu.Lock()
+	status, ok := sessionCache[sessionId]
+	sessionMu.Unlock()
+	if ok && time.Since(status.checkedAt) < sessionCacheTTL {
+		return status.active && status.userId == userId
+	}
+
+	var owner string
+	err := commonlib.DB_user.QueryRow("SELECT s.user_id FROM t_session s JOIN t_account a ON a.user_id = s.user_id "+
+		"WHERE s.session_id = ? AND s.revoked_at IS NULL AND s.expires_at > NOW()", sessionId).Scan(&owner)
+	if err != nil && err != sql.ErrNoRows {
+		log.Errorf("check session %s failed: %v", sessionId, err)
+		return false
+	}
+	status = sessionStatus{userId: owner, active: err == nil, checkedAt: time.Now()}
+
+	sessionSweepOnce.Do(func() { go sweepSessionCache() })
+	sessionMu.Lock()
+	sessionCache[sessionId] = status
+	sessionMu.Unlock()
+	return status.active && status.userId == userId
+}
+
+// forgetSessions 删除满足条件的缓存条目，下次校验时重新读取数据库
+func forgetSessions(match func(sessionId string, status sessionStatus) bool) {
+	sessionMu.Lock()
+	defer sessionMu.Unlock()
+	for sid, s := range sessionCache {
+		if match(sid, s) {
+			delete(sessionCache, sid)
+		}
+	}
+}
+
+// revokeSession 撤销单个会话
+func revokeSession(sessionId string) error {
+	_, err := commonlib.DB_user.Exec("UPDATE t_session SET revoked_at = NOW() WHERE session_id = ? AND revoked_at IS NULL", sessionId)
+	forgetSessions(func(sid string, _ sessionStatus) bool { return sid == sessionId })
+	return err
+}
+
+// revokeRefreshToken 撤销刷新令牌所属的会话，令牌不是会话当前的刷新令牌时忽略
+func revokeRefreshToken(token string) error {
+	sessionId, secret, ok := splitRefreshToken(token)
+	if !ok {
+		return nil
+	}
+	_, err := commonlib.DB_user.Exec("UPDATE t_session SET revoked_at = NOW() "+
+		"WHERE session_id = ? AND refresh_hash = ? AND revoked_at IS NULL", sessionId, hashRefreshSecret(secret))
+	forgetSessions(func(sid string, _ sessionStatus) bool { return sid == sessionId })
+	return err
+}
+
+// revokeUserSessions 撤销用户除 keepSessionId 外的全部会话，用于修改密码、修改账号类型和删除账号
+func revokeUserSessions(userId, keepSessionId string) error {
+	_, err := commonlib.DB_user.Exec("UPDATE t_session SET revoked_at = NOW() "+
+		"WHERE user_id = ? AND session_id <> ? AND revoked_at IS NULL", userId, keepSessionId)
+	forgetSessions(func(sid string, s sessionStatus) bool { return s.userId == userId && sid != keepSessionId })
+	return err
+}
@@ -0,0 +1,30 @@
+package user
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestSplitRefreshToken(t *testing.T) {
+	sessionId := strings.Repeat("a", 32)
+	id, secret, ok := splitRefreshToken(sessionId + ".s3cret")
+	if !ok || id != sessionId || secret != "s3cret" {
+		t.Errorf("split = %q, %q, %v", id, secret, ok)
+	}
+	// secret 中的点属于 secret
+	if _, secret, ok := splitRefreshToken(sessionId + ".a.b"); !ok || secret != "a.b" {
+		t.Errorf("secret = %q, %v, want a.b", secret, ok)
+	}
+	for _, token := range []string{
+		"",
+		sessionId,
+		sessionId + ".",
+		strings.Repeat("a", 31) + ".s3cret",
+		strings.Repeat("a", 33) + ".s3cret",
+		".s3cret",
+	} {
+		if _, _, ok := splitRefreshToken(token); ok {
+			t.Errorf("token %q should be rejected", token)
+		}
+	}
+}
@@ -17,11 +17,7 @@ import (
 )
 
 func BatchAddUserToGroup(c *gin.Context) {
-	// 检查用户权限
-	u, ok := CheckUserPermission(c.Query("token"), Admin, c)
-	if !ok {
-		return
-	}
+	u := CurrentUser(c)
 
 	// 获取 group_id 并验证
 	groupID, err := strconv.Atoi(c.Query("group_id"))
@@ -59,8 +55,7 @@ func BatchAddUserToGroup(c *gin.Context) {
 	// 初始化 CSV 读取器
 	reader := csv.NewReader(bufio.NewReader(file))
 
-	// 默认密码和允许的用户类型
-	defaultPassword := md5Gen("12345678")
+	// 允许的用户类型
 	validUserTypes := map[string]bool{"student": true}
 	if u.UserType == Admin {
 		validUserTypes["teacher"] = true
@@ -79,7 +74,7 @@ func BatchAddUserToGroup(c *gin.Context) {
 	}
 
 	// 插入用户数据到数据库
-	err = insertUsersIntoDB(users, defaultPassword)
+	err = insertUsersIntoDB(users, defaultImportPassword)
 	if err != nil {
 		logger.ERROR_LOG(err.Error(), c)
 		c.JSON(http.StatusInternalServerError, gin.H{
@@ -176,7 +171,13 @@ func insertUsersIntoDB(users []string, defaultPassword string) error {
 			return fmt.Errorf("无法开启事务: %v", err)
 		}
 
-		_, err = tx.Exec(sqlTemplate, user[0], user[1], defaultPassword, user[2])
+		// 每个账号单独加盐哈希初始密码
+		hash, err := hashPassword(defaultPassword)
+		if err != nil {
+			tx.Rollback()
+			return fmt.Errorf("生成密码哈希失败: %v", err)
+		}
+		_, err = tx.Exec(sqlTemplate, user[0], user[1], hash, user[2])
 		if err != nil {
 			tx.Rollback()
 			mysqlErr, ok := err.(*mysql.MySQLError)
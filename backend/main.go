@@ -2,9 +2,12 @@ package main
 
 import (
 	"fmt"
+	"os"
 	"strconv"
 
 	"github.com/RMS_V3/config"
+	analysis "github.com/RMS_V3/internal/kg/application/Analysis"
+	recommend "github.com/RMS_V3/internal/kg/application/Recommend"
 	"github.com/RMS_V3/log"
 	"github.com/RMS_V3/log/logger"
 	"github.com/RMS_V3/pkg/commonlib"
@@ -31,6 +34,13 @@ func main() {
 	Init()
 	defer log.Sync()
 
+	// 子命令: lint 检查图谱质量后退出
+	if len(os.Args) > 1 && os.Args[1] == "lint" {
+		code := analysis.RunLintCommand(os.Args[2:], os.Stdout)
+		log.Sync()
+		os.Exit(code)
+	}
+
 	// 设置Gin模式
 	if config.GetGlobalConfig().Mode == "release" {
 		gin.SetMode(gin.ReleaseMode)
@@ -38,6 +48,9 @@ func main() {
 		gin.SetMode(gin.DebugMode)
 	}
 
+	// 后台定期计算资源相似度，供协同过滤推荐使用
+	recommend.StartSimilarityJob()
+
 	r := routes.SetRoute()
 
 	// 启用日志和恢复中间件
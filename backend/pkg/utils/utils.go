@@ -6,6 +6,7 @@ import (
 	"os"
 	"os/exec"
 	"path/filepath"
+	"strconv"
 	"strings"
 	"time"
 
@@ -64,3 +65,21 @@ func GetImageFile(videoPath string) (string, error) {
 	// 返回提取的图片文件路径。
 	return picFullPath, nil
 }
+
+// GetVideoDuration 使用 ffprobe 读取视频时长（秒）
+func GetVideoDuration(videoPath string) (float64, error) {
+	cmd := exec.Command("ffprobe", "-v", "error", "-show_entries", "format=duration",
+		"-of", "default=noprint_wrappers=1:nokey=1", videoPath)
+	var outBuf, errBuf bytes.Buffer
+	cmd.Stdout = &outBuf
+	cmd.Stderr = &errBuf
+	if err := cmd.Run(); err != nil {
+		log.Errorf("ffprobe error: %s\n", errBuf.String())
+		return 0, err
+	}
+	duration, err := strconv.ParseFloat(strings.TrimSpace(outBuf.String()), 64)
+	if err != nil {
+		return 0, err
+	}
+	return duration, nil
+}
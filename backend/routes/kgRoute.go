@@ -3,24 +3,37 @@ package routes
 import (
 	"github.com/RMS_V3/internal/kg/application"
 	analysis "github.com/RMS_V3/internal/kg/application/Analysis"
+	assignment "github.com/RMS_V3/internal/kg/application/Assignment"
+	exercise "github.com/RMS_V3/internal/kg/application/Exercise"
+	mastery "github.com/RMS_V3/internal/kg/application/Mastery"
+	progress "github.com/RMS_V3/internal/kg/application/Progress"
 	recommend "github.com/RMS_V3/internal/kg/application/Recommend"
+	review "github.com/RMS_V3/internal/kg/application/Review"
 	auto "github.com/RMS_V3/internal/kg/application/autoConstuct"
+	"github.com/RMS_V3/internal/user"
 	"github.com/gin-gonic/gin"
 )
 
 func KgRoutes(r *gin.RouterGroup) {
-	// 知识图谱相关路由
-	knowledge := r.Group("/")
+	// 知识图谱相关路由，均需要 graph.read 权限
+	knowledge := r.Group("/", user.RequirePermission(user.GraphRead))
+	write := user.RequirePermission(user.GraphWrite)
+	upload := user.RequirePermission(user.ResourceUpload)
+	groups := user.RequirePermission(user.GroupManage)
+	manageUsers := user.RequirePermission(user.UserManage)
+	system := user.RequirePermission(user.SystemManage)
+	// 直接修改图谱的接口只对课程负责人和管理员开放
+	editor := review.RequireGraphEditor()
 	{
 		// 节点相关路由
-		knowledge.POST("/knowledge/addNode", application.AddNode)
-		knowledge.POST("/knowledge/deleteNode", application.DeleteNode)
-		knowledge.POST("/knowledge/updateNode", application.UpdateNode)
+		knowledge.POST("/knowledge/addNode", write, editor, application.AddNode)
+		knowledge.POST("/knowledge/deleteNode", write, editor, application.DeleteNode)
+		knowledge.POST("/knowledge/updateNode", write, editor, application.UpdateNode)
 		knowledge.GET("knowledge/searchByKeyword", application.SearchNodesByKeyword)
 		// 关系相关路由
-		knowledge.POST("/knowledge/addLink", application.AddRelationBetweenNodes)
-		knowledge.POST("/knowledge/deleteLink", application.DeleteRelationBetweenNodes)
-		knowledge.POST("/knowledge/updateLink", application.UpdateRelationBetweenNodes)
+		knowledge.POST("/knowledge/addLink", write, editor, application.AddRelationBetweenNodes)
+		knowledge.POST("/knowledge/deleteLink", write, editor, application.DeleteRelationBetweenNodes)
+		knowledge.POST("/knowledge/updateLink", write, editor, application.UpdateRelationBetweenNodes)
 		knowledge.GET("knowledge/relation", application.QueryRelationsBetweenTypes)
 		// 图相关路由
 		knowledge.GET("/knowledge/chapter", application.QueryChapterNodesAndRelations)
@@ -29,17 +42,86 @@ func KgRoutes(r *gin.RouterGroup) {
 		knowledge.GET("/knowledge/sectionByID", application.QuerySectionsByChapterId)
 		knowledge.GET("/knowledge/pointByID", application.QueryPointsBySectionId)
 		// 资源相关路由
-		knowledge.POST("/knowledge/uploadResource", application.UploadResource)
+		knowledge.POST("/knowledge/uploadResource", upload, application.UploadResource)
 		knowledge.GET("/knowledge/videosByPointId", application.GetPointVideo)
 		knowledge.GET("/knowledge/exercisesByPointId", application.GetPointExercise)
 		knowledge.GET("/knowledge/coursewaresByPointId", application.GetPointCourseware)
-		knowledge.POST("/knowledge/deleteVideo", application.DeletePointVideo)
-		knowledge.POST("/knowledge/deleteExercise", application.DeletePointExercise)
-		knowledge.POST("/knowledge/deleteCourseware", application.DeletePointCourseware)
+		knowledge.POST("/knowledge/deleteVideo", upload, application.DeletePointVideo)
+		knowledge.POST("/knowledge/deleteExercise", upload, application.DeletePointExercise)
+		knowledge.POST("/knowledge/deleteCourseware", upload, application.DeletePointCourseware)
+		knowledge.POST("/knowledge/resource/feedback", application.SubmitResourceFeedback)
+		// 学习进度
+		knowledge.POST("/knowledge/progress/event", progress.ReportProgressEvent)
+		knowledge.GET("/knowledge/progress/graph", progress.GetProgressGraph)
+		knowledge.GET("/knowledge/progress/summary", progress.GetProgressSummary)
+		knowledge.POST("/knowledge/progress/video/heartbeat", progress.VideoHeartbeat)
+		knowledge.GET("/knowledge/progress/video", progress.GetVideoProgress)
+		knowledge.GET("/knowledge/progress/class", groups, progress.GetClassHeatmap)
+		// 结构化习题
+		knowledge.POST("/knowledge/exercise/item/create", upload, exercise.CreateItem)
+		knowledge.POST("/knowledge/exercise/item/update", upload, exercise.UpdateItem)
+		knowledge.POST("/knowledge/exercise/item/delete", upload, exercise.DeleteItem)
+		knowledge.GET("/knowledge/exercise/item", exercise.GetItem)
+		knowledge.GET("/knowledge/exercise/items", exercise.ListItems)
+		knowledge.POST("/knowledge/exercise/submit", exercise.SubmitAnswer)
+		knowledge.GET("/knowledge/exercise/attempts", exercise.ListAttempts)
+		knowledge.GET("/knowledge/exercise/attempt", exercise.GetAttempt)
+		// 题库
+		knowledge.GET("/knowledge/exercise/bank/search", upload, exercise.SearchItems)
+		knowledge.GET("/knowledge/exercise/item/versions", upload, exercise.ListItemVersions)
+		knowledge.POST("/knowledge/exercise/item/link", upload, exercise.LinkItemPoints)
+		knowledge.POST("/knowledge/exercise/item/unlink", upload, exercise.UnlinkItemPoints)
+		// 测验
+		knowledge.POST("/knowledge/quiz/generate", exercise.GenerateQuiz)
+		knowledge.GET("/knowledge/quiz", exercise.GetQuiz)
+		knowledge.POST("/knowledge/quiz/submit", exercise.SubmitQuiz)
+		knowledge.GET("/knowledge/quiz/submissions", exercise.ListQuizSubmissions)
+		// 作业
+		knowledge.POST("/knowledge/assignment/create", groups, assignment.CreateAssignment)
+		knowledge.POST("/knowledge/assignment/update", groups, assignment.UpdateAssignment)
+		knowledge.POST("/knowledge/assignment/delete", groups, assignment.DeleteAssignment)
+		knowledge.GET("/knowledge/assignment/mine", assignment.MyAssignments)
+		knowledge.GET("/knowledge/assignment/group", assignment.ListGroupAssignments)
+		knowledge.GET("/knowledge/assignment/overview", groups, assignment.AssignmentOverview)
+		// 掌握度
+		knowledge.GET("/knowledge/mastery", mastery.GetMastery)
+		knowledge.GET("/knowledge/mastery/params", mastery.GetPointParams)
+		knowledge.POST("/knowledge/mastery/params", write, editor, mastery.SetPointParams)
+		knowledge.POST("/knowledge/mastery/params/reset", write, editor, mastery.ResetPointParams)
 		// 高级特性
-		knowledge.POST("/knowledge/autoConstruct", auto.ExtractKnowledgeFromFile)
+		knowledge.POST("/knowledge/autoConstruct", write, editor, auto.ExtractKnowledgeFromFile)
+		knowledge.POST("/knowledge/autoConstruct/validate", write, auto.ValidateKnowledgeFile)
+		knowledge.POST("/knowledge/autoConstruct/extract", write, auto.ExtractKnowledgeFromDocument)
+		knowledge.POST("/knowledge/autoConstruct/commit", write, editor, auto.CommitKnowledgeGraph)
+		// 抽取结果暂存区
+		knowledge.POST("/knowledge/staging/extract", write, auto.ExtractToStaging)
+		knowledge.GET("/knowledge/staging/batches", write, auto.ListStagingBatches)
+		knowledge.GET("/knowledge/staging/batch", write, auto.GetStagingBatch)
+		knowledge.POST("/knowledge/staging/updateItem", write, auto.UpdateStagingItem)
+		knowledge.POST("/knowledge/staging/review", write, auto.ReviewStagingItems)
+		knowledge.POST("/knowledge/staging/commit", write, editor, auto.CommitStagingBatch)
+		knowledge.POST("/knowledge/staging/discard", write, auto.DiscardStagingBatch)
+		// 图谱修改申请
+		knowledge.POST("/knowledge/changeRequest/submit", write, review.SubmitChangeRequest)
+		knowledge.GET("/knowledge/changeRequest/list", write, review.ListChangeRequests)
+		knowledge.GET("/knowledge/changeRequest/detail", write, review.GetChangeRequest)
+		knowledge.POST("/knowledge/changeRequest/approve", write, review.ApproveChangeRequest)
+		knowledge.POST("/knowledge/changeRequest/reject", write, review.RejectChangeRequest)
+		knowledge.GET("/knowledge/owners", write, review.ListCourseOwners)
+		knowledge.POST("/knowledge/owners/add", manageUsers, review.AddCourseOwner)
+		knowledge.POST("/knowledge/owners/remove", manageUsers, review.RemoveCourseOwner)
 		knowledge.GET("/knowledge/learningDifficulty", analysis.AssessLearningDifficulty)
+		knowledge.GET("/knowledge/learningDifficulty/batch", analysis.AssessLearningDifficultyBatch)
 		knowledge.POST("/knowledge/pathRecommend", recommend.GenerateLearningPath)
+		knowledge.GET("/knowledge/curriculum", recommend.GenerateCurriculum)
+		knowledge.GET("/knowledge/pathRecommend/personal", recommend.GeneratePersonalizedPath)
+		knowledge.GET("/knowledge/pathRecommend/alternatives", recommend.GenerateAlternativePaths)
+		knowledge.GET("/knowledge/pathRecommend/studyPlan", recommend.GenerateStudyPlan)
+		knowledge.GET("/knowledge/recommend/point", recommend.RecommendForPoint)
+		knowledge.GET("/knowledge/recommend/user", recommend.RecommendForUser)
+		knowledge.POST("/knowledge/recommend/similarity/refresh", system, recommend.RefreshSimilaritiesNow)
 		knowledge.GET("/knowledge/analyzeConnections", analysis.AnalyzeKnowledgeConnections)
+		knowledge.GET("/knowledge/communities", analysis.DetectKnowledgeCommunities)
+		knowledge.GET("/knowledge/lint", analysis.LintKnowledgeGraph)
 	}
 }
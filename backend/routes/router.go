@@ -2,10 +2,20 @@ package routes
 
 import (
 	"github.com/RMS_V3/config"
+	"github.com/RMS_V3/internal/user"
 	"github.com/gin-contrib/cors"
 	"github.com/gin-gonic/gin"
 )
 
+// publicRoutes 无需登录即可访问的接口
+var publicRoutes = []string{
+	"POST /api/user/login",
+	"POST /api/user/register",
+	"GET /api/user/checktoken",
+	"POST /api/user/refresh",
+	"POST /api/user/logout",
+}
+
 func SetRoute() *gin.Engine {
 	if config.GetGlobalConfig().Mode == "release" {
 		gin.SetMode(gin.ReleaseMode)
@@ -21,8 +31,8 @@ func SetRoute() *gin.Engine {
 		ExposeHeaders:    []string{"Content-Length"},
 		AllowCredentials: true,
 	}))
-	// 使用group方法为gin engine创建一个路由组
-	rms := r.Group("/api")
+	// 使用group方法为gin engine创建一个路由组，除公开接口外都需要携带 Authorization: Bearer token
+	rms := r.Group("/api", user.RequireAuth(publicRoutes...))
 	{
 		UserRoutes(rms)
 		KgRoutes(rms)
@@ -8,46 +8,56 @@ import (
 func UserRoutes(r *gin.RouterGroup) {
 	// 创建用户相关API的路由组
 	userGroup := r.Group("/user")
+	manageUsers := user.RequirePermission(user.UserManage)
+	manageGroups := user.RequirePermission(user.GroupManage)
+	read := user.RequirePermission(user.GraphRead)
 	{
 		// 校验用户token
 		userGroup.GET("/checktoken", user.CheckToken)
 		// 用户登录接口，生成并返回token
 		userGroup.POST("/login", user.GenerateToken)
+		// 使用刷新令牌换取新的访问令牌
+		userGroup.POST("/refresh", user.RefreshToken)
+		// 退出登录，撤销当前会话
+		userGroup.POST("/logout", user.Logout)
 		// 修改用户密码
 		userGroup.POST("/change-password", user.ChangePsw)
 		// 添加单个用户
-		userGroup.POST("/add-user", user.AddUser)
+		userGroup.POST("/add-user", manageGroups, user.AddUser)
 		// 批量导入用户
-		userGroup.POST("/batchAddUserToGroup", user.AddUserBatch)
+		userGroup.POST("/batchAddUserToGroup", manageGroups, user.AddUserBatch)
 		// 用户注册接口（可根据需求开启或禁用）
 		userGroup.POST("/register", user.Register)
 		// 获取用户列表
-		userGroup.GET("/list", user.ListUsers)
+		userGroup.GET("/list", manageUsers, user.ListUsers)
 		// 更新用户信息
-		userGroup.POST("/update", user.UpdateUser)
+		userGroup.POST("/update", manageUsers, user.UpdateUser)
 		// 删除用户
-		userGroup.POST("/delete", user.DeleteUser)
+		userGroup.POST("/delete", manageUsers, user.DeleteUser)
+		// 查看和修改各账号类型的权限
+		userGroup.GET("/permissions", manageUsers, user.ListRolePermissions)
+		userGroup.POST("/permissions", manageUsers, user.UpdateRolePermissions)
 	}
 
 	// 创建用户组相关API的路由组
 	user_groupGroup := r.Group("/user-group")
 	{
 		// 获取用户组下的用户信息
-		user_groupGroup.GET("/get-user", user.GetGroupUser)
+		user_groupGroup.GET("/get-user", read, user.GetGroupUser)
 		// 获取所有用户组列表
-		user_groupGroup.GET("/get-groups", user.GetGroupList)
+		user_groupGroup.GET("/get-groups", read, user.GetGroupList)
 		// 创建新的用户组
-		user_groupGroup.POST("/create", user.CreateGroup)
+		user_groupGroup.POST("/create", manageGroups, user.CreateGroup)
 		// 向用户组添加用户
-		user_groupGroup.POST("/add-user", user.AddGroupUser)
+		user_groupGroup.POST("/add-user", manageGroups, user.AddGroupUser)
 		// 从用户组中删除用户
-		user_groupGroup.POST("/delete-user", user.DeleteGroupUser)
+		user_groupGroup.POST("/delete-user", manageGroups, user.DeleteGroupUser)
 		// 删除用户组
-		user_groupGroup.POST("/delete-group", user.DeleteGroup)
+		user_groupGroup.POST("/delete-group", manageGroups, user.DeleteGroup)
 		// 获取所有用户组信息
-		user_groupGroup.GET("/all-groups", user.GetAllGroup)
+		user_groupGroup.GET("/all-groups", manageUsers, user.GetAllGroup)
 		// 编辑用户组名称
-		user_groupGroup.POST("/edit-name", user.EditGroupName)
+		user_groupGroup.POST("/edit-name", manageGroups, user.EditGroupName)
 		// 检查是否为用户组所有者（可根据需求开启或禁用）
 		//user_groupGroup.GET("/if-is-owner", IsGroupOwner)
 	}
@@ -14,37 +14,42 @@ export async function login(username, password) {
   })
 }
 
-// 用户登出
-export async function logout() {
-  // 后端可能不需要实际的登出请求，前端清理即可
-  // 但为了兼容性，我们保留请求
-  return Promise.resolve({ ret: "0", msg: "登出成功" })
+// 用户登出，撤销当前登录会话；访问令牌过期时后端根据刷新令牌撤销
+export async function logout(refreshToken) {
+  const formData = new FormData()
+  if (refreshToken) {
+    formData.append('refresh_token', refreshToken)
+  }
+
+  return request({
+    url: '/api/user/logout',
+    method: 'post',
+    data: formData
+  })
 }
 
 // 校验用户token
-export async function checkToken(token) {
+export async function checkToken() {
   return request({
     url: '/api/user/checktoken',
-    method: 'get',
-    params: { token }
+    method: 'get'
   })
 }
 
-// 修改密码
-export async function changePassword(token, newPassword, userId = null) {
-  const params = { 
-    token,
-    new_password: newPassword
-  };
-  
+// 修改密码，密码放在请求体中，避免出现在 URL 和日志里
+export async function changePassword(oldPassword, newPassword, userId = null) {
+  const formData = new FormData()
+  formData.append('old_password', oldPassword)
+  formData.append('new_password', newPassword)
+
   if (userId) {
-    params.user_id = userId;
+    formData.append('user_id', userId)
   }
     
   return request({
     url: '/api/user/change-password',
     method: 'post',
-    params
+    data: formData
   })
 }
 
@@ -66,58 +71,50 @@ export async function register(username, password, nickname) {
 
 /**
  * 获取用户列表
- * @param {string} token 用户token
  * @returns {Promise} 用户列表
  */
-export function getUserList(token) {
+export function getUserList() {
   return request({
     url: '/api/user/list',
-    method: 'get',
-    params: { token }
+    method: 'get'
   })
 }
 
 /**
  * 添加新用户
  * @param {Object} data 用户数据
- * @param {string} token 用户token
  * @returns {Promise} 添加结果
  */
-export function addUser(data, token) {
+export function addUser(data) {
   return request({
     url: '/api/user/add-user',
     method: 'post',
-    data,
-    params: { token }
+    data
   })
 }
 
 /**
  * 更新用户信息
  * @param {Object} data 用户数据
- * @param {string} token 用户token
  * @returns {Promise} 更新结果
  */
-export function updateUser(data, token) {
+export function updateUser(data) {
   return request({
     url: '/api/user/update',
     method: 'post',
-    data,
-    params: { token }
+    data
   })
 }
 
 /**
  * 删除用户
  * @param {string} userId 用户ID
- * @param {string} token 用户token
  * @returns {Promise} 删除结果
  */
-export function deleteUser(userId, token) {
+export function deleteUser(userId) {
   return request({
     url: '/api/user/delete',
     method: 'post',
-    data: { user_id: userId },
-    params: { token }
+    data: { user_id: userId }
   })
 } 
\ No newline at end of file
@@ -5,6 +5,7 @@ import { message } from 'ant-design-vue'
 export const useUserStore = defineStore('user', {
   state: () => ({
     token: localStorage.getItem('token') || '',
+    refreshToken: localStorage.getItem('refreshToken') || '',
     username: localStorage.getItem('username') || '',
     role: localStorage.getItem('role') || '',
     nickname: localStorage.getItem('nickname') || '',
@@ -25,13 +26,12 @@ export const useUserStore = defineStore('user', {
         if (response.ret === "0") {
           // 后端返回用户信息，包含token
           const userData = response.user
-          this.token = userData.token
+          this.setTokens(userData.token, userData.refresh_token)
           this.username = userData.id
           this.nickname = userData.nickname
           this.role = userData.usertype
           
           // 保存到本地存储
-          localStorage.setItem('token', userData.token)
           localStorage.setItem('username', userData.id)
           localStorage.setItem('nickname', userData.nickname)
           localStorage.setItem('role', userData.usertype)
@@ -51,7 +51,7 @@ export const useUserStore = defineStore('user', {
       if (!this.token) return false
       
       try {
-        const response = await checkToken(this.token)
+        const response = await checkToken()
         if (response.ret === "0") {
           // 更新用户信息
           const userData = response.user
@@ -79,7 +79,7 @@ export const useUserStore = defineStore('user', {
     async logout() {
       try {
         // 尝试调用后端登出API，但不依赖其结果
-        await logout()
+        await logout(this.refreshToken)
       } catch (error) {
         console.error('登出API调用失败:', error)
       }
@@ -88,15 +88,25 @@ export const useUserStore = defineStore('user', {
       this.clearUserInfo()
     },
 
+    // 保存访问令牌和刷新令牌，刷新令牌每次使用后都会更换
+    setTokens(token, refreshToken) {
+      this.token = token
+      this.refreshToken = refreshToken || ''
+      localStorage.setItem('token', token)
+      localStorage.setItem('refreshToken', this.refreshToken)
+    },
+
     clearUserInfo() {
       // 清除状态
       this.token = ''
+      this.refreshToken = ''
       this.username = ''
       this.role = ''
       this.nickname = ''
       
       // 清除本地存储
       localStorage.removeItem('token')
+      localStorage.removeItem('refreshToken')
       localStorage.removeItem('username')
       localStorage.removeItem('role')
       localStorage.removeItem('nickname')
@@ -10,6 +10,33 @@ const service = axios.create({
   timeout: 15000
 })
 
+// 使用刷新令牌换取新的访问令牌，多个请求同时过期时共用一次刷新；
+// 其他标签页已经刷新过时直接使用 localStorage 中的新令牌
+let refreshing = null
+function refreshAccessToken(userStore) {
+  const storedToken = localStorage.getItem('token')
+  if (storedToken && storedToken !== userStore.token) {
+    userStore.setTokens(storedToken, localStorage.getItem('refreshToken'))
+    return Promise.resolve(storedToken)
+  }
+  if (!refreshing) {
+    const formData = new FormData()
+    formData.append('refresh_token', userStore.refreshToken)
+    refreshing = axios.post(`${service.defaults.baseURL}/api/user/refresh`, formData)
+      .then(({ data }) => {
+        if (data.ret !== '0') {
+          throw new Error(data.msg || '登录已过期')
+        }
+        userStore.setTokens(data.user.token, data.user.refresh_token)
+        return data.user.token
+      })
+      .finally(() => {
+        refreshing = null
+      })
+  }
+  return refreshing
+}
+
 // 请求拦截器
 service.interceptors.request.use(
   config => {
@@ -80,15 +107,29 @@ service.interceptors.response.use(
     
     return res
   },
-  error => {
+  async error => {
     NProgress.done()
+
+    // 访问令牌过期时先尝试刷新，成功后重发原请求
+    const userStore = useUserStore()
+    const original = error.config
+    if (error.response && error.response.status === 401 && original && !original._retried && userStore.refreshToken) {
+      original._retried = true
+      try {
+        const token = await refreshAccessToken(userStore)
+        original.headers['Authorization'] = `Bearer ${token}`
+        return service(original)
+      } catch (refreshError) {
+        console.error('刷新登录状态失败:', refreshError)
+      }
+    }
+
     console.error('API请求异常:', error)
     
     handleHttpError(error)
     
     // 如果是401错误，清除用户信息并跳转到登录页
     if (error.response && error.response.status === 401) {
-      const userStore = useUserStore()
       userStore.clearUserInfo()
       router.push('/login')
     }